@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/server"
+)
+
+// chaosFlags registers the flags of the hidden `chaos` mode, which runs the
+// manager as usual but delays, drops and corrupts upstream responses of the
+// proxy. The returned func reads them once the flags are parsed.
+func chaosFlags() func() (*server.Chaos, error) {
+	servers := flag.String("servers", "", "Comma-separated upstream servers to disturb (default: all)")
+	seed := flag.Int64("seed", 0, "Random seed to reproduce a run (default: time based)")
+	delay := flag.Float64("delay", 0.2, "Share of responses to delay (0-1)")
+	maxDelay := flag.Duration("max-delay", 3*time.Second, "Longest delay")
+	drop := flag.Float64("drop", 0.05, "Share of responses to drop, so the call times out (0-1)")
+	corrupt := flag.Float64("corrupt", 0.05, "Share of responses to corrupt (0-1)")
+	return func() (*server.Chaos, error) {
+		for name, rate := range map[string]float64{"delay": *delay, "drop": *drop, "corrupt": *corrupt} {
+			if rate < 0 || rate > 1 {
+				return nil, fmt.Errorf("--%s must be between 0 and 1", name)
+			}
+		}
+		c := &server.Chaos{
+			Seed:        *seed,
+			DelayRate:   *delay,
+			MaxDelay:    *maxDelay,
+			DropRate:    *drop,
+			CorruptRate: *corrupt,
+		}
+		if c.Seed == 0 {
+			c.Seed = time.Now().UnixNano()
+		}
+		for _, name := range strings.Split(*servers, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				c.Servers = append(c.Servers, name)
+			}
+		}
+		return c, nil
+	}
+}
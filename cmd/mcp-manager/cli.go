@@ -0,0 +1,617 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+	"github.com/naukograd-software/mcp-catalog/internal/storage"
+)
+
+// envFlag collects repeated --env KEY=VALUE flags
+type envFlag map[string]string
+
+func (e envFlag) String() string { return "" }
+
+func (e envFlag) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", v)
+	}
+	e[k] = val
+	return nil
+}
+
+// catalogClient performs catalog operations either directly on the config
+// store or through the API of a running instance.
+type catalogClient struct {
+	store  *config.Store
+	apiURL string
+	// db keeps logs and history with --storage sqlite, nil otherwise
+	db *storage.DB
+	// token authenticates API calls once the instance requires logins
+	token string
+}
+
+func newCatalogClient(fs *flag.FlagSet, args []string) (*catalogClient, []string, error) {
+	configPath := fs.String("config", "", "Config file path (default: ~/.config/mcp-manager/config.json)")
+	profile := fs.String("profile", "", "Config profile to use (default: the active one)")
+	storageKind := fs.String("storage", "", storageUsage)
+	apiURL := fs.String("api", "", "Talk to a running instance instead of the config file (e.g. http://localhost:9847)")
+	token := fs.String("token", os.Getenv("MCP_MANAGER_TOKEN"), "API token for --api once logins are required (default: $MCP_MANAGER_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	c := &catalogClient{apiURL: strings.TrimRight(*apiURL, "/"), token: *token}
+	if c.apiURL == "" {
+		path := *configPath
+		if path == "" {
+			path = defaultConfigPath()
+		}
+		store, db, err := newStore(path, *storageKind)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.store, c.db = store, db
+		if *profile != "" {
+			if err := c.store.UseProfile(*profile); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := c.store.Load(); err != nil {
+			return nil, nil, fmt.Errorf("load config: %w", err)
+		}
+	}
+	return c, fs.Args(), nil
+}
+
+func (c *catalogClient) call(method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.apiURL+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error.Message)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, strings.TrimSpace(string(raw)))
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
+
+func (c *catalogClient) get(name string) (*config.MCPServer, error) {
+	if c.store != nil {
+		srv, ok := c.store.GetServer(name)
+		if !ok {
+			return nil, fmt.Errorf("server %q not found", name)
+		}
+		return srv, nil
+	}
+	var info manager.ServerInfo
+	if err := c.call("GET", "/api/servers/"+name, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info.Config, nil
+}
+
+func (c *catalogClient) put(name string, srv *config.MCPServer) error {
+	if c.store != nil {
+		return c.store.AddServer(name, srv)
+	}
+	return c.call("PUT", "/api/servers/"+name, srv, nil)
+}
+
+func (c *catalogClient) remove(name string) error {
+	if c.store != nil {
+		if _, ok := c.store.GetServer(name); !ok {
+			return fmt.Errorf("server %q not found", name)
+		}
+		return c.store.RemoveServer(name)
+	}
+	return c.call("DELETE", "/api/servers/"+name, nil, nil)
+}
+
+// rename moves a server to a new name together with its logs and history.
+func (c *catalogClient) rename(name, newName string) error {
+	if c.store != nil {
+		mgr := manager.New(c.store)
+		if c.db != nil {
+			mgr.UseStores(c.db, c.db)
+		}
+		return mgr.RenameServer(name, newName)
+	}
+	return c.call("POST", "/api/servers/"+name+"/rename", map[string]string{"name": newName}, nil)
+}
+
+func (c *catalogClient) list() (map[string]*manager.ServerInfo, error) {
+	if c.store != nil {
+		mgr := manager.New(c.store)
+		return mgr.GetAllInfo(), nil
+	}
+	var infos map[string]*manager.ServerInfo
+	err := c.call("GET", "/api/servers", nil, &infos)
+	return infos, err
+}
+
+func (c *catalogClient) enable(name string) error {
+	if c.store != nil {
+		return c.store.Enable(name)
+	}
+	return c.call("POST", "/api/servers/"+name+"/enable", nil, nil)
+}
+
+func (c *catalogClient) disable(name, reason string, until *time.Time, remind bool) error {
+	if c.store != nil {
+		return c.store.Disable(name, reason, until, remind)
+	}
+	body := map[string]any{"reason": reason, "until": until, "remind": remind}
+	return c.call("POST", "/api/servers/"+name+"/disable", body, nil)
+}
+
+// snoozeEnd turns the --for and --until flags of `disable` into the snooze
+// end, nil when neither is set.
+func snoozeEnd(forFlag, untilFlag string) (*time.Time, error) {
+	var t time.Time
+	switch {
+	case forFlag != "" && untilFlag != "":
+		return nil, fmt.Errorf("--for and --until are mutually exclusive")
+	case forFlag != "":
+		d, err := time.ParseDuration(forFlag)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid --for %q", forFlag)
+		}
+		t = time.Now().Add(d)
+	case untilFlag != "":
+		var err error
+		if t, err = time.Parse(time.RFC3339, untilFlag); err != nil {
+			if t, err = time.ParseInLocation(time.DateOnly, untilFlag, time.Local); err != nil {
+				return nil, fmt.Errorf("invalid --until %q", untilFlag)
+			}
+		}
+		if !t.After(time.Now()) {
+			return nil, fmt.Errorf("--until %s is in the past", untilFlag)
+		}
+	default:
+		return nil, nil
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// trust pins the server's current package hash after an intended change.
+func (c *catalogClient) trust(name string) (string, error) {
+	if c.store != nil {
+		return manager.New(c.store).Trust(name)
+	}
+	var out struct {
+		Integrity string `json:"integrity"`
+	}
+	err := c.call("POST", "/api/servers/"+name+"/trust", nil, &out)
+	return out.Integrity, err
+}
+
+// check runs synchronous checks locally, or asks the running instance to
+// check and returns the resulting states. An empty name checks every
+// enabled server.
+func (c *catalogClient) check(name string) (map[string]*manager.ServerInfo, error) {
+	if c.store != nil {
+		mgr := manager.New(c.store)
+		if name == "" {
+			mgr.CheckAll()
+		} else if _, ok := c.store.GetServer(name); !ok {
+			return nil, fmt.Errorf("server %q not found", name)
+		} else {
+			mgr.Check(name)
+		}
+		result := make(map[string]*manager.ServerInfo)
+		for n, info := range mgr.GetAllInfo() {
+			if (name == "" && info.Config.Enabled) || n == name {
+				result[n] = info
+			}
+		}
+		return result, nil
+	}
+	var infos map[string]*manager.ServerInfo
+	err := c.call("POST", "/api/check?wait=1&servers="+name, nil, &infos)
+	return infos, err
+}
+
+// summary is the catalog summary of the running instance. Without one,
+// sessions and calls are zero and server states are as of no checks.
+func (c *catalogClient) summary() (summary, error) {
+	if c.store != nil {
+		return summary{CatalogSummary: manager.New(c.store).Summary()}, nil
+	}
+	var sum summary
+	err := c.call("GET", "/api/summary", nil, &sum)
+	return sum, err
+}
+
+// vendorDir is where `vendor` installs a server's package: next to the
+// config file, or next to the default config when talking to the API.
+func (c *catalogClient) vendorDir(name string) string {
+	base := filepath.Dir(defaultConfigPath())
+	if c.store != nil {
+		base = c.store.Dir()
+	}
+	return filepath.Join(base, "vendor", name)
+}
+
+// checkResult is the machine-readable outcome printed by `check --json`
+type checkResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	Tools      int    `json:"tools"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// runCatalogCommand implements add|remove|list|enable|disable|check|vendor|trust|status.
+func runCatalogCommand(cmd string, args []string) int {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	var (
+		url      *string
+		typ      *string
+		subject  *string
+		group    *string
+		disabled *bool
+		env      = envFlag{}
+	)
+	var all, jsonOut, undo, remind *bool
+	var reason, snoozeFor, snoozeUntil, tags, description, docsURL *string
+	var priority *int
+	if cmd == "vendor" {
+		undo = fs.Bool("undo", false, "Restore the original npx/uvx command and delete the vendored copy")
+	}
+	if cmd == "check" {
+		all = fs.Bool("all", false, "Check every enabled server")
+		jsonOut = fs.Bool("json", false, "Print machine-readable results")
+	}
+	if cmd == "status" {
+		jsonOut = fs.Bool("json", false, "Print machine-readable summary")
+	}
+	if cmd == "list" {
+		tags = fs.String("tag", "", "Only servers with one of these comma-separated tags")
+	}
+	if cmd == "disable" {
+		reason = fs.String("reason", "", "Why the server is disabled")
+		snoozeFor = fs.String("for", "", "Snooze for a duration (e.g. 2h), then enable again")
+		snoozeUntil = fs.String("until", "", "Snooze until a time (RFC 3339 or YYYY-MM-DD), then enable again")
+		remind = fs.Bool("remind", false, "At the end of the snooze only log a reminder instead of enabling")
+	}
+	if cmd == "add" {
+		url = fs.String("url", "", "Remote server URL (streamable HTTP)")
+		typ = fs.String("type", "", "Server type (default: stdio, or streamableHttp with --url; exec-transport runs the command as a helper carrying messages to --url; nats sends requests to --subject at --url)")
+		subject = fs.String("subject", "", "NATS subject the server listens on (with --type nats)")
+		group = fs.String("group", "", "Group whose env the server inherits")
+		tags = fs.String("tag", "", "Comma-separated tags of the server (e.g. db,cloud)")
+		description = fs.String("description", "", "What the server is for")
+		docsURL = fs.String("docs-url", "", "Link to the server's documentation")
+		priority = fs.Int("priority", 0, "Order in aggregated lists and conflicts; higher goes first")
+		disabled = fs.Bool("disabled", false, "Add the server disabled")
+		fs.Var(env, "env", "Environment variable KEY=VALUE (repeatable)")
+	}
+
+	// Allow the server name before the flags: `add NAME --env K=V -- cmd args`
+	var name, newName string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if cmd == "rename" && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		newName, args = args[0], args[1:]
+	}
+	client, rest, err := newCatalogClient(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	unnamed := cmd == "list" || cmd == "status"
+	if name == "" && len(rest) > 0 && !unnamed {
+		name, rest = rest[0], rest[1:]
+	}
+	checkAll := cmd == "check" && *all
+	if name == "" && !unnamed && !checkAll {
+		fmt.Fprintf(os.Stderr, "usage: mcp-manager %s <name>\n", cmd)
+		return 2
+	}
+
+	switch cmd {
+	case "add":
+		srv := &config.MCPServer{Type: *typ, URL: *url, Subject: *subject, Group: *group, Tags: splitList(*tags), Enabled: !*disabled}
+		srv.Description, srv.DocsURL, srv.Priority = *description, *docsURL, *priority
+		if len(env) > 0 {
+			srv.Env = env
+		}
+		if len(rest) > 0 {
+			srv.Command = rest[0]
+			srv.Args = rest[1:]
+		}
+		if srv.Command == "" && srv.URL == "" {
+			fmt.Fprintln(os.Stderr, "usage: mcp-manager add <name> [--env K=V] [--url URL | -- command args...]")
+			return 2
+		}
+		err = client.put(name, srv)
+	case "remove":
+		if err = client.remove(name); err == nil && client.store != nil {
+			manager.New(client.store).PruneAfterRemove()
+		}
+	case "rename":
+		if newName == "" && len(rest) > 0 {
+			newName = rest[0]
+		}
+		if newName == "" {
+			fmt.Fprintln(os.Stderr, "usage: mcp-manager rename <name> <new-name>")
+			return 2
+		}
+		err = client.rename(name, newName)
+	case "enable":
+		err = client.enable(name)
+	case "disable":
+		var until *time.Time
+		until, err = snoozeEnd(*snoozeFor, *snoozeUntil)
+		if err == nil {
+			err = client.disable(name, *reason, until, *remind)
+		}
+	case "list":
+		var infos map[string]*manager.ServerInfo
+		if infos, err = client.list(); err == nil {
+			if want := splitList(*tags); len(want) > 0 {
+				for name, info := range infos {
+					if !info.Config.HasAnyTag(want) {
+						delete(infos, name)
+					}
+				}
+			}
+			printServerTable(infos)
+		}
+	case "status":
+		var sum summary
+		if sum, err = client.summary(); err == nil {
+			printSummary(sum, *jsonOut)
+		}
+	case "vendor":
+		var srv *config.MCPServer
+		if srv, err = client.get(name); err == nil {
+			if *undo {
+				srv, err = manager.Unvendor(srv)
+			} else {
+				fmt.Printf("Installing %s into %s...\n", name, client.vendorDir(name))
+				srv, err = manager.Vendor(srv, client.vendorDir(name))
+			}
+		}
+		if err == nil {
+			if err = client.put(name, srv); err == nil {
+				fmt.Printf("%s: %s %s\n", name, srv.Command, strings.Join(srv.Args, " "))
+			}
+		}
+	case "trust":
+		var hash string
+		if hash, err = client.trust(name); err == nil {
+			fmt.Printf("%s: pinned %s\n", name, hash)
+		}
+	case "check":
+		if checkAll {
+			name = ""
+		}
+		var infos map[string]*manager.ServerInfo
+		if infos, err = client.check(name); err == nil {
+			return reportChecks(infos, *jsonOut)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// reportChecks prints check results and returns 1 if any server is unhealthy.
+func reportChecks(infos map[string]*manager.ServerInfo, jsonOut bool) int {
+	names := orderedInfos(infos)
+
+	code := 0
+	results := make([]checkResult, 0, len(names))
+	for _, name := range names {
+		info := infos[name]
+		if info.Status != manager.StatusHealthy {
+			code = 1
+		}
+		results = append(results, checkResult{
+			Name:       name,
+			Status:     string(info.Status),
+			Error:      info.Error,
+			Tools:      len(info.Tools),
+			DurationMs: info.CheckDuration,
+		})
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+		return code
+	}
+	for _, r := range results {
+		fmt.Printf("%s: %s", r.Name, r.Status)
+		if r.Error != "" {
+			fmt.Printf(" (%s)", r.Error)
+		}
+		fmt.Printf(", %d tools, %dms\n", r.Tools, r.DurationMs)
+	}
+	return code
+}
+
+// summary mirrors the /api/summary response
+type summary struct {
+	manager.CatalogSummary
+	Sessions   int   `json:"sessions"`
+	CallsToday int64 `json:"callsToday"`
+}
+
+func printSummary(sum summary, jsonOut bool) {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(sum)
+		return
+	}
+	fmt.Printf("servers:   %d (%d enabled, %d healthy, %d error)\n", sum.Servers, sum.Enabled, sum.Healthy, sum.Errors)
+	fmt.Printf("tools:     %d, prompts: %d, resources: %d\n", sum.Tools, sum.Prompts, sum.Resources)
+	fmt.Printf("sessions:  %d, calls today: %d\n", sum.Sessions, sum.CallsToday)
+}
+
+// orderedInfos returns the server names in the order the proxy lists them:
+// by descending priority, then by name.
+func orderedInfos(infos map[string]*manager.ServerInfo) []string {
+	servers := make(map[string]*config.MCPServer, len(infos))
+	for name, info := range infos {
+		servers[name] = &info.Config
+	}
+	return config.OrderedNames(servers)
+}
+
+func printServerTable(infos map[string]*manager.ServerInfo) {
+	names := orderedInfos(infos)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tENABLED\tSTATUS\tTAGS\tTARGET")
+	for _, name := range names {
+		info := infos[name]
+		target := info.Config.URL
+		if info.Config.Command != "" {
+			target = strings.Join(append([]string{info.Config.Command}, info.Config.Args...), " ")
+		}
+		enabled := fmt.Sprint(info.Config.Enabled)
+		if cfg := info.Config; !cfg.Enabled && (cfg.DisabledReason != "" || cfg.SnoozeUntil != nil) {
+			var notes []string
+			if cfg.DisabledReason != "" {
+				notes = append(notes, cfg.DisabledReason)
+			}
+			if cfg.SnoozeUntil != nil {
+				notes = append(notes, "until "+cfg.SnoozeUntil.Local().Format(time.DateTime))
+			}
+			enabled += " (" + strings.Join(notes, ", ") + ")"
+		}
+		tags := strings.Join(info.Config.Tags, ",")
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, enabled, info.Status, tags, target)
+	}
+	tw.Flush()
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// runLint reports catalog problems and exits 1 if any were found, so it can
+// guard shared catalogs in pre-commit hooks.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	runChecks := fs.Bool("check", false, "Check enabled servers first to find failing servers and duplicate tools")
+	jsonOut := fs.Bool("json", false, "Print machine-readable results")
+	client, _, err := newCatalogClient(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Check results come from the running instance, or from a fresh local
+	// check with --check; without either those rules are skipped.
+	var servers map[string]*config.MCPServer
+	var infos map[string]*manager.ServerInfo
+	switch {
+	case client.store == nil:
+		infos, err = client.list()
+		servers = make(map[string]*config.MCPServer, len(infos))
+		for name, info := range infos {
+			cfg := info.Config
+			servers[name] = &cfg
+		}
+	case *runChecks:
+		servers = client.store.Get().MCPServers
+		infos, err = client.check("")
+	default:
+		servers = client.store.Get().MCPServers
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	issues := manager.Lint(servers, infos)
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if issues == nil {
+			issues = []manager.LintIssue{}
+		}
+		enc.Encode(issues)
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s: [%s] %s\n", issue.Server, issue.Rule, issue.Message)
+		}
+		if infos == nil {
+			fmt.Fprintln(os.Stderr, "note: never-checked and duplicate-tool rules need --check or --api")
+		}
+		if len(issues) > 0 {
+			fmt.Printf("%d problem(s) in %d server(s)\n", len(issues), countServers(issues))
+		}
+	}
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func countServers(issues []manager.LintIssue) int {
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		seen[issue.Server] = true
+	}
+	return len(seen)
+}
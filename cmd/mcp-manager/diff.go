@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// runDiff implements `mcp-manager diff [FROM] TO`: the per-server difference
+// between two config files, or between the current config and a file. Like
+// diff(1) it exits 1 when they differ.
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the diff as JSON")
+	var files []string
+	for len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		files, args = append(files, args[0]), args[1:]
+	}
+	client, rest, err := newCatalogClient(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	files = append(files, rest...)
+	if len(files) == 0 || len(files) > 2 {
+		fmt.Fprintln(os.Stderr, "usage: mcp-manager diff [FROM] TO  (FROM defaults to the current config)")
+		return 2
+	}
+	blobs := make([]json.RawMessage, len(files))
+	for i, path := range files {
+		if blobs[i], err = os.ReadFile(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	var from, to json.RawMessage
+	if len(blobs) == 2 {
+		from, to = blobs[0], blobs[1]
+	} else {
+		to = blobs[0]
+	}
+
+	var diff *config.ConfigDiff
+	if client.store != nil {
+		diff, err = diffLocal(client.store, from, to)
+	} else {
+		diff = &config.ConfigDiff{}
+		err = client.call("POST", "/api/config/diff", map[string]json.RawMessage{"from": from, "to": to}, diff)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printConfigDiff(diff, *jsonOut)
+	if diff.Empty() {
+		return 0
+	}
+	return 1
+}
+
+func diffLocal(store *config.Store, from, to json.RawMessage) (*config.ConfigDiff, error) {
+	a := store.Get()
+	if from != nil {
+		var err error
+		if a, err = config.Parse(from); err != nil {
+			return nil, err
+		}
+	}
+	b, err := config.Parse(to)
+	if err != nil {
+		return nil, err
+	}
+	return config.Diff(a, b)
+}
+
+func printConfigDiff(diff *config.ConfigDiff, jsonOut bool) {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(diff)
+		return
+	}
+	marks := map[string]string{config.DiffAdded: "+", config.DiffRemoved: "-", config.DiffChanged: "~"}
+	for _, sd := range diff.Servers {
+		fmt.Printf("%s %s\n", marks[sd.Change], sd.Name)
+		if sd.Change == config.DiffChanged {
+			printFieldChanges(sd.Fields)
+		}
+	}
+	if len(diff.Settings) > 0 {
+		fmt.Println("settings:")
+		printFieldChanges(diff.Settings)
+	}
+	if diff.Empty() {
+		fmt.Println("No differences.")
+	}
+}
+
+func printFieldChanges(changes []config.FieldChange) {
+	for _, c := range changes {
+		fmt.Printf("    %s: %s -> %s\n", c.Field, diffValue(c.Old), diffValue(c.New))
+	}
+}
+
+func diffValue(v any) string {
+	if v == nil {
+		return "(unset)"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
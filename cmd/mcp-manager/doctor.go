@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// ownConfigDir reports whether the config's directory belongs to the manager
+// alone, like ~/.config/mcp-manager or /etc/mcp-manager, rather than being
+// shared with other files.
+func ownConfigDir(path string) bool {
+	dir := filepath.Dir(path)
+	return dir == filepath.Dir(defaultConfigPath()) || filepath.Base(dir) == "mcp-manager"
+}
+
+// runDoctor checks the installation for problems and exits 1 if any remain.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file path (default: ~/.config/mcp-manager/config.json)")
+	fix := fs.Bool("fix", false, "Fix the problems that can be fixed automatically")
+	fs.Parse(args)
+	path := *configPath
+	if path == "" {
+		path = defaultConfigPath()
+	}
+
+	issues := config.PermissionIssues(path, ownConfigDir(path))
+	if *fix && len(issues) > 0 {
+		if err := config.FixPermissions(issues); err != nil {
+			fmt.Fprintf(os.Stderr, "fix permissions: %v\n", err)
+			return 1
+		}
+		for _, issue := range issues {
+			fmt.Printf("fixed  %s: %04o -> %04o\n", issue.Path, issue.Mode, issue.Want)
+		}
+		issues = config.PermissionIssues(path, ownConfigDir(path))
+	}
+	if len(issues) == 0 {
+		fmt.Printf("ok     config permissions (%s)\n", path)
+		return 0
+	}
+	for _, issue := range issues {
+		fmt.Printf("warn   %s\n", issue)
+	}
+	fmt.Println("Run `mcp-manager doctor --fix` to restrict them to the owner.")
+	return 1
+}
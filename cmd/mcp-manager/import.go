@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+// runImportDesktop implements `mcp-manager import-desktop`: it merges the
+// servers of Claude Desktop's config into the catalog, after showing what
+// changes.
+func runImportDesktop(args []string) int {
+	fs := flag.NewFlagSet("import-desktop", flag.ExitOnError)
+	path := fs.String("path", "", "claude_desktop_config.json to read (default: "+manager.ClaudeDesktopConfigPath()+")")
+	strategy := fs.String("strategy", "", "For servers that differ from the catalog's: skip (default), overwrite or rename")
+	overwrite := fs.Bool("overwrite", false, "Same as --strategy overwrite")
+	only := fs.String("servers", "", "Comma-separated servers to import (default: all)")
+	dryRun := fs.Bool("dry-run", false, "Only show what would change")
+	jsonOut := fs.Bool("json", false, "Print the plan as JSON")
+	client, _, err := newCatalogClient(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	opts := manager.ImportOptions{Strategy: *strategy, Merge: true}
+	if opts.Strategy == "" && *overwrite {
+		opts.Strategy = manager.StrategyOverwrite
+	}
+	for _, name := range strings.Split(*only, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Servers = append(opts.Servers, name)
+		}
+	}
+
+	var plan *manager.ImportPlan
+	if client.store != nil {
+		src, found, err := manager.ReadClaudeDesktop(*path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		plan, err = manager.New(client.store).ImportServers(src, found, opts, *dryRun)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		q := url.Values{}
+		if *path != "" {
+			q.Set("path", *path)
+		}
+		if opts.Strategy != "" {
+			q.Set("strategy", opts.Strategy)
+		}
+		if len(opts.Servers) > 0 {
+			q.Set("servers", strings.Join(opts.Servers, ","))
+		}
+		method := "POST"
+		if *dryRun {
+			method = "GET"
+		}
+		plan = &manager.ImportPlan{}
+		if err := client.call(method, "/api/import/claude-desktop?"+q.Encode(), nil, plan); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	printImportPlan(plan, *jsonOut)
+	return 0
+}
+
+func printImportPlan(plan *manager.ImportPlan, jsonOut bool) {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(plan)
+		return
+	}
+	fmt.Printf("From %s:\n", plan.Source)
+	applied, skipped := 0, 0
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case manager.ImportAdd:
+			target := c.Proposed.URL
+			if c.Proposed.Command != "" {
+				target = strings.Join(append([]string{c.Proposed.Command}, c.Proposed.Args...), " ")
+			}
+			fmt.Printf("  + %s: %s\n", c.Name, target)
+			applied++
+		case manager.ImportUpdate:
+			fmt.Printf("  ~ %s: %s\n", c.Name, strings.Join(c.Fields, ", "))
+			applied++
+		case manager.ImportRename:
+			fmt.Printf("  + %s: as %s, differs (%s)\n", c.Name, c.As, strings.Join(c.Fields, ", "))
+			applied++
+		case manager.ImportSkip:
+			fmt.Printf("  ! %s: differs (%s), skipped\n", c.Name, strings.Join(c.Fields, ", "))
+			skipped++
+		default:
+			fmt.Printf("  = %s\n", c.Name)
+		}
+	}
+	switch {
+	case len(plan.Changes) == 0:
+		fmt.Println("No servers found.")
+	case plan.DryRun:
+		fmt.Printf("Dry run: %d server(s) would be imported.\n", applied)
+	default:
+		fmt.Printf("Imported %d server(s).\n", applied)
+	}
+	if skipped > 0 {
+		fmt.Println("Use --strategy overwrite or rename to import the servers that differ.")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/catalog"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+// prompter asks questions on stdin; with assumeYes every question takes its
+// default answer.
+type prompter struct {
+	in        *bufio.Reader
+	out       io.Writer
+	assumeYes bool
+}
+
+func (p *prompter) ask(question, def string) string {
+	if p.assumeYes {
+		return def
+	}
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, _ := p.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def
+	}
+	return line
+}
+
+func (p *prompter) confirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer := strings.ToLower(p.ask(question+" ("+hint+")", ""))
+	if answer == "" {
+		return def
+	}
+	return answer == "y" || answer == "yes"
+}
+
+// runInit implements `mcp-manager init`: a first-run wizard that imports
+// servers from installed CLI tools and adds servers from the curated catalog.
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file path (default: ~/.config/mcp-manager/config.json)")
+	profile := fs.String("profile", "", "Config profile to set up (default: the active one)")
+	storageKind := fs.String("storage", "", storageUsage)
+	yes := fs.Bool("yes", false, "Non-interactive: import every server found in CLI tool configs and skip catalog suggestions")
+	force := fs.Bool("force", false, "Run even if the config already has servers")
+	fs.Parse(args)
+
+	path := *configPath
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	store, _, err := newStore(path, *storageKind)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *profile != "" {
+		if err := store.UseProfile(*profile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if err := store.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "load config: %v\n", err)
+		return 1
+	}
+	if n := len(store.Get().MCPServers); n > 0 && !*force {
+		fmt.Fprintf(os.Stderr, "%s already has %d server(s); use --force to run the wizard anyway\n", path, n)
+		return 1
+	}
+
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout, assumeYes: *yes}
+	mgr := manager.New(store)
+	servers := store.Get().MCPServers
+	added := 0
+
+	// Step 1: import what the user already configured in their CLI tools
+	tools := mgr.DetectTools()
+	if len(tools) == 0 {
+		fmt.Println("No supported CLI tools detected.")
+	}
+	for _, tool := range tools {
+		if !tool.HasConfig {
+			fmt.Printf("%s: installed, no config yet\n", tool.DisplayName)
+			continue
+		}
+		found, err := mgr.ToolServers(tool.Name)
+		if err != nil {
+			fmt.Printf("%s: cannot read %s: %v\n", tool.DisplayName, tool.ConfigPath, err)
+			continue
+		}
+		var names []string
+		for name := range found {
+			if _, exists := servers[name]; !exists {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			fmt.Printf("%s: no new servers in %s\n", tool.DisplayName, tool.ConfigPath)
+			continue
+		}
+		fmt.Printf("%s: %s\n", tool.DisplayName, strings.Join(names, ", "))
+		if !p.confirm(fmt.Sprintf("Import %d server(s) from %s?", len(names), tool.DisplayName), true) {
+			continue
+		}
+		for _, name := range names {
+			servers[name] = found[name]
+			added++
+		}
+	}
+
+	// Step 2: suggest servers from the curated catalog
+	if !p.assumeYes {
+		templates := catalog.List()
+		fmt.Println()
+		fmt.Println("Popular servers from the catalog:")
+		for i, t := range templates {
+			fmt.Printf("  %2d. %-20s %s\n", i+1, t.ID, t.Description)
+		}
+		selection := p.ask("Add servers (numbers separated by commas, empty to skip)", "")
+		for _, field := range strings.FieldsFunc(selection, func(r rune) bool { return r == ',' || r == ' ' }) {
+			idx, err := strconv.Atoi(field)
+			if err != nil || idx < 1 || idx > len(templates) {
+				fmt.Printf("skipping %q: not a number from the list\n", field)
+				continue
+			}
+			t := templates[idx-1]
+			values := make(map[string]string)
+			for _, param := range t.Params {
+				question := param.Name
+				if param.Description != "" {
+					question += " (" + param.Description + ")"
+				}
+				values[param.Name] = p.ask(question, param.Default)
+			}
+			srv, err := t.Render(values)
+			if err != nil {
+				fmt.Printf("%s: %v\n", t.ID, err)
+				continue
+			}
+			name := t.ID
+			if _, exists := servers[name]; exists {
+				name = p.ask(fmt.Sprintf("A server named %q exists; name for the new one", name), name+"-2")
+			}
+			servers[name] = srv
+			added++
+		}
+	}
+
+	if added == 0 {
+		fmt.Println("Nothing to add; config left unchanged.")
+		return 0
+	}
+	cfg := store.Get()
+	cfg.MCPServers = servers
+	os.MkdirAll(filepath.Dir(path), 0700)
+	if err := store.Set(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "write config: %v\n", err)
+		return 1
+	}
+	fmt.Printf("\nWrote %d server(s) to %s\n", added, path)
+	fmt.Println("Next: `mcp-manager check --all` to verify them, then start the panel with `mcp-manager`.")
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const takeoverTimeout = 15 * time.Second
+
+// errInstanceRunning is returned when another instance holds the config lock
+var errInstanceRunning = errors.New("another instance is running")
+
+// instanceInfo is written to the lock file so other processes can find us
+type instanceInfo struct {
+	PID      int    `json:"pid"`
+	Addr     string `json:"addr,omitempty"`
+	BasePath string `json:"basePath,omitempty"`
+	// ShutdownKey authorizes the takeover request once logins are required
+	ShutdownKey string    `json:"shutdownKey,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+}
+
+// instanceLock guards a config file against concurrent mcp-manager processes.
+// The lock is held for the lifetime of the process and released by the OS
+// when it exits.
+type instanceLock struct {
+	f *os.File
+}
+
+// acquireInstanceLock locks <config>.lock. If another instance holds it, the
+// returned info describes that instance and the error is errInstanceRunning.
+func acquireInstanceLock(configPath string) (*instanceLock, *instanceInfo, error) {
+	f, err := os.OpenFile(configPath+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	// It holds the shutdown key; lock files of older versions were 0644
+	f.Chmod(0600)
+	if err := lockFile(f); err != nil {
+		var other instanceInfo
+		data, _ := os.ReadFile(f.Name())
+		f.Close()
+		if json.Unmarshal(data, &other) != nil {
+			return nil, nil, errInstanceRunning
+		}
+		return nil, &other, errInstanceRunning
+	}
+	return &instanceLock{f: f}, nil, nil
+}
+
+// publish records our PID, listen address, base path and shutdown key in
+// the lock file.
+func (l *instanceLock) publish(addr, basePath, shutdownKey string) error {
+	data, err := json.Marshal(instanceInfo{PID: os.Getpid(), Addr: addr, BasePath: basePath, ShutdownKey: shutdownKey, StartedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = l.f.WriteAt(data, 0)
+	return err
+}
+
+// instanceClient returns an HTTP client and base URL for an instance address
+// as written by publish ("host:port", ":port" or "unix:/path").
+func instanceClient(addr string) (*http.Client, string) {
+	if socketPath, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		}, "http://unix"
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return &http.Client{Timeout: 5 * time.Second}, "http://" + addr
+}
+
+// takeover asks the running instance to shut down and waits for its lock.
+func takeover(configPath string, other *instanceInfo) (*instanceLock, error) {
+	if other == nil || other.Addr == "" {
+		return nil, fmt.Errorf("running instance did not publish its address")
+	}
+	slog.Info("asking running instance to shut down", "pid", other.PID, "addr", other.Addr)
+	client, base := instanceClient(other.Addr)
+	req, err := http.NewRequest("POST", base+other.BasePath+"/api/shutdown", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Shutdown-Key", other.ShutdownKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request shutdown: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("request shutdown: %s", resp.Status)
+	}
+
+	deadline := time.Now().Add(takeoverTimeout)
+	for time.Now().Before(deadline) {
+		lock, _, err := acquireInstanceLock(configPath)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, errInstanceRunning) {
+			return nil, err
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("instance (pid %d) did not exit within %s", other.PID, takeoverTimeout)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// lockFile is a no-op where advisory file locks are unavailable; the lock
+// file still records the running instance's address.
+func lockFile(f *os.File) error {
+	return nil
+}
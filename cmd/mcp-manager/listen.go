@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// listenAddr is one address to serve on. proxyOnly listeners serve the MCP
+// proxy (/mcp) and nothing else, so the UI and API can stay on loopback
+// while clients on the LAN reach the proxy.
+type listenAddr struct {
+	addr      string
+	proxyOnly bool
+}
+
+func (l listenAddr) String() string {
+	if l.proxyOnly {
+		return "proxy=" + l.addr
+	}
+	return l.addr
+}
+
+// listenFlag collects repeated or comma-separated --listen addresses:
+// host:port, unix:/path, each optionally prefixed with proxy=
+type listenFlag []listenAddr
+
+func (f *listenFlag) String() string {
+	parts := make([]string, len(*f))
+	for i, l := range *f {
+		parts[i] = l.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *listenFlag) Set(v string) error {
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		addr, proxyOnly := strings.CutPrefix(item, "proxy=")
+		if addr == "" {
+			return fmt.Errorf("empty listen address in %q", v)
+		}
+		if !strings.HasPrefix(addr, "unix:") {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				return fmt.Errorf("listen address %q: %w", addr, err)
+			}
+		}
+		*f = append(*f, listenAddr{addr: addr, proxyOnly: proxyOnly})
+	}
+	return nil
+}
+
+// listenURL is how a listener is shown in logs.
+func listenURL(addr, basePath string) string {
+	if socketPath, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return "unix:" + socketPath
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port) + basePath + "/"
+}
@@ -1,48 +1,171 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/logsink"
 	"github.com/naukograd-software/mcp-catalog/internal/manager"
 	"github.com/naukograd-software/mcp-catalog/internal/server"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "service":
+			os.Exit(runService(os.Args[2:]))
+		case "add", "remove", "rename", "list", "enable", "disable", "check", "vendor", "trust", "status":
+			os.Exit(runCatalogCommand(os.Args[1], os.Args[2:]))
+		case "init":
+			os.Exit(runInit(os.Args[2:]))
+		case "import-desktop":
+			os.Exit(runImportDesktop(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "user":
+			os.Exit(runUser(os.Args[2:]))
+		}
+	}
+
+	// `chaos` is hidden: the usual manager with upstream faults injected
+	var chaosSettings func() (*server.Chaos, error)
+	if len(os.Args) > 1 && os.Args[1] == "chaos" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		chaosSettings = chaosFlags()
+	}
+
 	port := flag.Int("port", 9847, "HTTP port")
 	configPath := flag.String("config", "", "Config file path (default: ~/.config/mcp-manager/config.json)")
+	profile := flag.String("profile", "", "Config profile to run with (default: the one last switched to)")
+	storageKind := flag.String("storage", "", storageUsage)
 	mcpStdio := flag.Bool("mcp-stdio", false, "Run as MCP proxy over stdio")
+	endpoint := flag.String("endpoint", "", "Named proxy endpoint to serve with --mcp-stdio (default: all servers)")
+	bind := flag.String("bind", "127.0.0.1", "Interface to serve --port on; 0.0.0.0 for all interfaces")
+	var listens listenFlag
+	flag.Var(&listens, "listen", "Listen address: host:port or unix:/path/to.sock, proxy=ADDR to serve only the MCP proxy; repeatable or comma-separated (default: <bind>:<port>)")
+	basePath := flag.String("base-path", "", "Serve the UI, API and proxy under this path prefix (e.g. /mcp-manager) behind a reverse proxy")
+	takeoverFlag := flag.Bool("takeover", false, "Ask an instance already running on this config to shut down and replace it")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn, error")
+	logFormat := flag.String("log-format", "text", "Log format: text or json")
+	logFile := flag.String("log-file", "", "Write logs to this file instead of stderr")
 	flag.Parse()
 
+	logCloser, err := setupLogging(*logLevel, *logFormat, *logFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer logCloser.Close()
+
+	var chaos *server.Chaos
+	if chaosSettings != nil {
+		if chaos, err = chaosSettings(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		slog.Warn("chaos mode: upstream responses are delayed, dropped and corrupted",
+			"seed", chaos.Seed, "servers", chaos.Servers, "delay", chaos.DelayRate, "maxDelay", chaos.MaxDelay,
+			"drop", chaos.DropRate, "corrupt", chaos.CorruptRate)
+	}
+
 	if *configPath == "" {
-		home, _ := os.UserHomeDir()
-		*configPath = filepath.Join(home, ".config", "mcp-manager", "config.json")
+		*configPath = defaultConfigPath()
 	}
 
-	// Ensure config directory exists
-	os.MkdirAll(filepath.Dir(*configPath), 0755)
+	// Ensure config directory exists; the config holds API keys
+	os.MkdirAll(filepath.Dir(*configPath), 0700)
+	for _, issue := range config.PermissionIssues(*configPath, ownConfigDir(*configPath)) {
+		slog.Warn("config is accessible to other users; run `mcp-manager doctor --fix`", "path", issue.Path, "mode", fmt.Sprintf("%04o", issue.Mode))
+	}
+
+	// Only one panel may own a config file; stdio proxies are read-mostly
+	// and run one per client, so they don't take the lock.
+	var lock *instanceLock
+	if !*mcpStdio {
+		var other *instanceInfo
+		lock, other, err = acquireInstanceLock(*configPath)
+		if errors.Is(err, errInstanceRunning) {
+			if !*takeoverFlag {
+				if other != nil {
+					fmt.Fprintf(os.Stderr, "mcp-manager is already running for %s (pid %d, %s)\n", *configPath, other.PID, other.Addr)
+				} else {
+					fmt.Fprintf(os.Stderr, "mcp-manager is already running for %s\n", *configPath)
+				}
+				fmt.Fprintln(os.Stderr, "Use --takeover to replace it.")
+				os.Exit(1)
+			}
+			lock, err = takeover(*configPath, other)
+		}
+		if err != nil {
+			fatal("instance lock", "err", err)
+		}
+	}
 
 	// Initialize config store
-	store := config.NewStore(*configPath)
+	store, db, err := newStore(*configPath, *storageKind)
+	if err != nil {
+		fatal("failed to open storage", "err", err)
+	}
+	if db != nil {
+		defer db.Close()
+		slog.Info("catalog kept in SQLite", "path", db.Path())
+	}
+	if *profile != "" {
+		if err := store.UseProfile(*profile); err != nil {
+			fatal("invalid profile", "err", err)
+		}
+	}
 	if err := store.Load(); err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("failed to load config", "path", *configPath, "err", err)
+	}
+	slog.Info("config loaded", "path", *configPath, "profile", store.Profile())
+
+	// Messages follow the locale setting; extra bundles live next to the config
+	if err := i18n.LoadDir(filepath.Join(store.Dir(), "locales")); err != nil {
+		slog.Warn("locales", "err", err)
+	}
+	i18n.SetLocaleSource(store.GetLocale)
+
+	// Forward logs to external sinks (syslog, journald, Loki, file)
+	sinks, err := logsink.Open(store.GetLogSinks())
+	if err != nil {
+		slog.Warn("log sinks", "err", err)
+	}
+	if !sinks.Empty() {
+		slog.SetDefault(slog.New(logsink.NewHandler(slog.Default().Handler(), sinks)))
 	}
-	log.Printf("Config loaded from %s", *configPath)
 
 	// Initialize manager
 	mgr := manager.New(store)
+	if db != nil {
+		mgr.UseStores(db, db)
+	}
+	mgr.OnLog(func(name string, entry manager.LogEntry) {
+		sinks.Send(logsink.Record{Time: entry.Time, Level: logsink.ServerLevel(entry.Level), Server: name, Message: entry.Message})
+	})
 
 	if *mcpStdio {
-		log.Printf("Starting MCP proxy over stdio")
-		if err := server.RunMCPStdio(store); err != nil {
-			log.Fatalf("Stdio MCP server error: %v", err)
+		slog.Info("starting MCP proxy over stdio")
+		if err := server.RunMCPStdio(store, *endpoint, chaos); err != nil {
+			fatal("stdio MCP server error", "err", err)
 		}
 		return
 	}
@@ -53,23 +176,134 @@ func main() {
 	// Start periodic health check loop
 	go mgr.StartHealthLoop()
 
+	// Ping healthy remote servers between full checks
+	go mgr.StartPingLoop()
+
+	// Keep npx/uvx package caches warm when prefetching is enabled
+	go mgr.StartPrefetchLoop()
+
+	// Re-enable snoozed servers when their snooze ends
+	go mgr.StartSnoozeLoop()
+
+	// Rewrite tool configs after catalog edits when apply.autoApply is set
+	go mgr.StartAutoApply()
+
 	// Initialize HTTP server
 	srv := server.New(store, mgr)
+	if db != nil {
+		srv.SetAuditLog(db)
+	}
+	if chaos != nil {
+		srv.EnableChaos(*chaos)
+	}
+	srv.SetBasePath(*basePath)
+	go srv.StartDigestLoop()
+	go srv.StartSessionGC()
+	go srv.StartWarmStandby()
 
-	addr := fmt.Sprintf(":%d", *port)
-	log.Printf("MCP Manager UI: http://localhost%s", addr)
+	// Warn when tool configs were left behind by edits made without Apply
+	go srv.ReportToolSync()
+
+	if len(listens) == 0 {
+		listens = listenFlag{{addr: net.JoinHostPort(*bind, strconv.Itoa(*port))}}
+	}
+	// Open every listener before serving, so a taken port fails the start
+	listeners := make([]net.Listener, len(listens))
+	for i, l := range listens {
+		if listeners[i], err = listenOn(l.addr); err != nil {
+			fatal("listen error", "addr", l.addr, "err", err)
+		}
+	}
+	// --takeover reaches us through the first listener serving the API
+	shutdownKey := make([]byte, 16)
+	rand.Read(shutdownKey)
+	srv.SetShutdownKey(hex.EncodeToString(shutdownKey))
+	published := false
+	for _, l := range listens {
+		if !l.proxyOnly {
+			if err := lock.publish(l.addr, srv.BasePath(), hex.EncodeToString(shutdownKey)); err != nil {
+				slog.Warn("failed to record instance address", "err", err)
+			}
+			published = true
+			break
+		}
+	}
+	for _, l := range listens {
+		if !l.proxyOnly && !strings.HasPrefix(l.addr, "unix:") {
+			srv.SetDashboardURL(listenURL(l.addr, srv.BasePath()))
+			break
+		}
+	}
+	if !published {
+		slog.Warn("no listener serves the API; --takeover cannot reach this instance")
+	}
 
 	// Graceful shutdown
 	go func() {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-		<-sigCh
-		log.Println("Shutting down...")
+		select {
+		case <-sigCh:
+		case <-srv.ShutdownRequested():
+			slog.Info("shutdown requested by another instance")
+		}
+		slog.Info("shutting down")
 		mgr.StopHealthLoop()
+		srv.SaveSessions()
+		srv.StopWarmStandby()
+		sinks.Close()
+		for _, ln := range listeners {
+			ln.Close()
+		}
 		os.Exit(0)
 	}()
 
-	if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
-		log.Fatalf("Server error: %v", err)
+	handler, proxyHandler := srv.Handler(), srv.ProxyHandler()
+	errs := make(chan error, len(listeners))
+	for i, l := range listens {
+		h := handler
+		if l.proxyOnly {
+			h = proxyHandler
+			slog.Info("MCP proxy listening", "url", listenURL(l.addr, srv.BasePath()))
+		} else {
+			slog.Info("MCP Manager UI listening", "url", listenURL(l.addr, srv.BasePath()))
+		}
+		go func(ln net.Listener, h http.Handler) {
+			errs <- http.Serve(ln, h)
+		}(listeners[i], h)
+	}
+	if err := <-errs; err != nil && !errors.Is(err, net.ErrClosed) {
+		fatal("server error", "err", err)
+	}
+}
+
+func defaultConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "mcp-manager", "config.json")
+}
+
+// listenOn opens a TCP listener, or a unix socket for "unix:/path" addresses.
+// Sockets are restricted to the owner and group so filesystem permissions
+// control who may reach the API.
+func listenOn(addr string) (net.Listener, error) {
+	socketPath, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if socketPath == "" {
+		return nil, fmt.Errorf("empty unix socket path")
+	}
+	// Remove a stale socket left behind by a previous run
+	if fi, err := os.Lstat(socketPath); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(socketPath)
+	}
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socketPath, 0660); err != nil {
+		ln.Close()
+		return nil, err
 	}
+	return ln, nil
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+const (
+	systemdUnitName = "mcp-manager.service"
+	launchdLabel    = "com.naukograd-software.mcp-manager"
+)
+
+var systemdUnitTmpl = template.Must(template.New("unit").Parse(`[Unit]
+Description=MCP Manager - MCP server management panel
+After=network.target
+
+[Service]
+Type=simple
+ExecStart={{.Exec}}{{range .Args}} {{.}}{{end}}
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdPlistTmpl = template.Must(template.New("plist").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>{{.Label}}</string>
+  <key>ProgramArguments</key>
+  <array>
+    <string>{{.Exec}}</string>{{range .Args}}
+    <string>{{.}}</string>{{end}}
+  </array>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <true/>
+  <key>StandardErrorPath</key>
+  <string>{{.LogPath}}</string>
+</dict>
+</plist>
+`))
+
+// runService implements `mcp-manager service install|uninstall|status`.
+func runService(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: mcp-manager service install|uninstall|status [--port N] [--bind ADDR] [--config PATH] [--base-path /PREFIX]")
+		return 2
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("service", flag.ExitOnError)
+	port := fs.Int("port", 9847, "HTTP port for the installed service")
+	bind := fs.String("bind", "", "Interface the installed service listens on (default: loopback)")
+	configPath := fs.String("config", "", "Config file path for the installed service")
+	basePath := fs.String("base-path", "", "Path prefix the installed service is served under")
+	fs.Parse(args[1:])
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve executable: %v\n", err)
+		return 1
+	}
+	exe, _ = filepath.EvalSymlinks(exe)
+	svcArgs := []string{"--port", fmt.Sprint(*port)}
+	if *configPath != "" {
+		abs, _ := filepath.Abs(*configPath)
+		svcArgs = append(svcArgs, "--config", abs)
+	}
+	if *bind != "" {
+		svcArgs = append(svcArgs, "--bind", *bind)
+	}
+	if *basePath != "" {
+		svcArgs = append(svcArgs, "--base-path", *basePath)
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		err = systemdService(action, exe, svcArgs)
+	case "darwin":
+		err = launchdService(action, exe, svcArgs)
+	default:
+		err = fmt.Errorf("service management is not supported on %s", runtime.GOOS)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service %s: %v\n", action, err)
+		return 1
+	}
+	return 0
+}
+
+func systemdService(action, exe string, args []string) error {
+	home, _ := os.UserHomeDir()
+	unitPath := filepath.Join(home, ".config", "systemd", "user", systemdUnitName)
+
+	switch action {
+	case "install":
+		if err := os.MkdirAll(filepath.Dir(unitPath), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(unitPath)
+		if err != nil {
+			return err
+		}
+		err = systemdUnitTmpl.Execute(f, map[string]any{"Exec": exe, "Args": args})
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if err := runCmd("systemctl", "--user", "daemon-reload"); err != nil {
+			return err
+		}
+		if err := runCmd("systemctl", "--user", "enable", "--now", systemdUnitName); err != nil {
+			return err
+		}
+		fmt.Printf("Installed %s\n", unitPath)
+		return nil
+	case "uninstall":
+		runCmd("systemctl", "--user", "disable", "--now", systemdUnitName)
+		if err := os.Remove(unitPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		runCmd("systemctl", "--user", "daemon-reload")
+		fmt.Printf("Removed %s\n", unitPath)
+		return nil
+	case "status":
+		return runCmd("systemctl", "--user", "status", "--no-pager", systemdUnitName)
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func launchdService(action, exe string, args []string) error {
+	home, _ := os.UserHomeDir()
+	plistPath := filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+
+	switch action {
+	case "install":
+		if err := os.MkdirAll(filepath.Dir(plistPath), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(plistPath)
+		if err != nil {
+			return err
+		}
+		err = launchdPlistTmpl.Execute(f, map[string]any{
+			"Label":   launchdLabel,
+			"Exec":    exe,
+			"Args":    args,
+			"LogPath": filepath.Join(home, "Library", "Logs", "mcp-manager.log"),
+		})
+		f.Close()
+		if err != nil {
+			return err
+		}
+		runCmd("launchctl", "unload", plistPath)
+		if err := runCmd("launchctl", "load", "-w", plistPath); err != nil {
+			return err
+		}
+		fmt.Printf("Installed %s\n", plistPath)
+		return nil
+	case "uninstall":
+		runCmd("launchctl", "unload", "-w", plistPath)
+		if err := os.Remove(plistPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Printf("Removed %s\n", plistPath)
+		return nil
+	case "status":
+		return runCmd("launchctl", "list", launchdLabel)
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func runCmd(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/storage"
+)
+
+const storageUsage = "Where the catalog is kept: json or sqlite (default: sqlite if catalog.db exists next to the config, json otherwise)"
+
+// catalogDBPath is the SQLite database of --storage sqlite.
+func catalogDBPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "catalog.db")
+}
+
+// newStore creates the config store for configPath. With sqlite storage the
+// database is returned too, for logs, history and the audit trail; it is
+// nil for json.
+func newStore(configPath, kind string) (*config.Store, *storage.DB, error) {
+	store := config.NewStore(configPath)
+	dbPath := catalogDBPath(configPath)
+	if kind == "" {
+		kind = "json"
+		if _, err := os.Stat(dbPath); err == nil {
+			kind = "sqlite"
+		}
+	}
+	switch kind {
+	case "json":
+		return store, nil, nil
+	case "sqlite":
+		db, err := storage.Open(dbPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		st, err := db.ConfigStorage(configPath)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		store.SetStorage(st)
+		return store, db, nil
+	}
+	return nil, nil, fmt.Errorf("unknown storage %q: want json or sqlite", kind)
+}
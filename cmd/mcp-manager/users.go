@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// runUser implements `mcp-manager user add|remove|list|oidc`, which manages the
+// accounts of the UI and API in users.json next to the config. It works on
+// the file directly, so the first admin can be created before any login.
+func runUser(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: mcp-manager user add|remove|list|oidc [NAME] [--role admin|operator|viewer] [--config PATH]")
+		return 2
+	}
+	action := args[0]
+	if action == "oidc" {
+		return runUserOIDC(args[1:])
+	}
+	fs := flag.NewFlagSet("user", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file path (default: ~/.config/mcp-manager/config.json)")
+	role := fs.String("role", "", "Role of the user: admin, operator or viewer (add; default: viewer for new users)")
+	// Allow the user name before the flags: `user add alice --role admin`
+	var name string
+	rest := args[1:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		name, rest = rest[0], rest[1:]
+	}
+	fs.Parse(rest)
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+	path := *configPath
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	users := config.NewUsers(filepath.Dir(path))
+	if err := users.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch action {
+	case "list":
+		list := users.List()
+		names := make([]string, 0, len(list))
+		for n := range list {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Printf("%-20s %s\n", "NAME", "ROLE")
+		for _, n := range names {
+			fmt.Printf("%-20s %s\n", n, list[n].Role)
+		}
+		return 0
+	case "add":
+		if name == "" {
+			fmt.Fprintln(os.Stderr, "usage: mcp-manager user add NAME [--role ROLE]")
+			return 2
+		}
+		r := config.Role(*role)
+		existing, exists := users.Get(name)
+		switch {
+		case r == "" && exists:
+			r = existing.Role
+		case r == "":
+			r = config.RoleViewer
+		}
+		// The password is read from stdin, so it can be piped in scripts
+		fmt.Fprint(os.Stderr, "Password (empty keeps the current one): ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" && !exists {
+			fmt.Fprintln(os.Stderr, "\nread password:", err)
+			return 1
+		}
+		fmt.Fprintln(os.Stderr)
+		if err := users.Put(name, r, strings.TrimRight(line, "\r\n")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("%s: %s (%s)\n", name, r, users.Path())
+		return 0
+	case "remove":
+		if name == "" {
+			fmt.Fprintln(os.Stderr, "usage: mcp-manager user remove NAME")
+			return 2
+		}
+		if err := users.Remove(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("removed %s\n", name)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "unknown action %q: want add, remove, list or oidc\n", action)
+	return 2
+}
+
+// runUserOIDC implements `mcp-manager user oidc`: with --issuer it turns
+// single sign-on on, with --off it turns it off, and without flags it
+// shows the current settings. The client secret is read from
+// $MCP_MANAGER_OIDC_CLIENT_SECRET so it stays out of the shell history.
+func runUserOIDC(args []string) int {
+	fs := flag.NewFlagSet("user oidc", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file path (default: ~/.config/mcp-manager/config.json)")
+	issuer := fs.String("issuer", "", "OIDC provider URL, e.g. https://accounts.google.com")
+	clientID := fs.String("client-id", "", "OAuth client ID registered with the provider")
+	redirectURL := fs.String("redirect-url", "", "Callback URL registered with the provider (required unless the dashboard is used over loopback)")
+	groupsClaim := fs.String("groups-claim", "", "ID token claim with the user's groups (default: groups)")
+	defaultRole := fs.String("default-role", "", "Role of users in no mapped group; empty rejects them")
+	domains := fs.String("domains", "", "Comma-separated e-mail domains allowed to sign in")
+	off := fs.Bool("off", false, "Turn single sign-on off")
+	var roles roleMapFlag
+	fs.Var(&roles, "role", "GROUP=ROLE mapping of a provider group to a role; repeatable")
+	fs.Parse(args)
+	path := *configPath
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	users := config.NewUsers(filepath.Dir(path))
+	if err := users.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch {
+	case *off:
+		if err := users.SetOIDC(nil); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println("single sign-on off")
+		return 0
+	case *issuer == "":
+		cfg := users.OIDC()
+		if cfg == nil {
+			fmt.Println("single sign-on off")
+			return 0
+		}
+		cfg.ClientSecret = ""
+		data, _ := json.MarshalIndent(cfg, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+	cfg := &config.OIDCConfig{
+		Issuer:       *issuer,
+		ClientID:     *clientID,
+		ClientSecret: os.Getenv("MCP_MANAGER_OIDC_CLIENT_SECRET"),
+		RedirectURL:  *redirectURL,
+		GroupsClaim:  *groupsClaim,
+		Roles:        map[string]config.Role(roles),
+		DefaultRole:  config.Role(*defaultRole),
+	}
+	for _, d := range strings.Split(*domains, ",") {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			cfg.Domains = append(cfg.Domains, d)
+		}
+	}
+	if err := users.SetOIDC(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("single sign-on with %s (%s)\n", cfg.Issuer, users.Path())
+	return 0
+}
+
+// roleMapFlag collects repeated GROUP=ROLE flags.
+type roleMapFlag map[string]config.Role
+
+func (f *roleMapFlag) String() string {
+	parts := make([]string, 0, len(*f))
+	for group, role := range *f {
+		parts = append(parts, group+"="+string(role))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (f *roleMapFlag) Set(v string) error {
+	// Split at the last '=': group names may contain one, roles do not
+	i := strings.LastIndex(v, "=")
+	group, role := v[:max(i, 0)], v[i+1:]
+	if i <= 0 || !config.Role(role).Valid() {
+		return fmt.Errorf("want GROUP=admin|operator|viewer, got %q", v)
+	}
+	if *f == nil {
+		*f = make(roleMapFlag)
+	}
+	(*f)[group] = config.Role(role)
+	return nil
+}
@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+//go:embed templates.json
+var templatesJSON []byte
+
+// Param describes a value the user must (or may) supply when adding a template.
+// It is referenced as ${NAME} in the template's command, args, url or env.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`
+	// Path requires the value to name an existing file or directory
+	Path bool `json:"path,omitempty"`
+	// Pattern is a regular expression the whole value must match
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Template is a ready-made server definition from the curated catalog
+type Template struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Runtime     string           `json:"runtime,omitempty"`
+	Server      config.MCPServer `json:"server"`
+	Params      []Param          `json:"params,omitempty"`
+}
+
+var templates []Template
+
+func init() {
+	if err := json.Unmarshal(templatesJSON, &templates); err != nil {
+		panic(fmt.Sprintf("catalog: invalid templates.json: %v", err))
+	}
+}
+
+// List returns all curated templates.
+func List() []Template {
+	cp := make([]Template, len(templates))
+	copy(cp, templates)
+	return cp
+}
+
+// Find returns the template with the given id.
+func Find(id string) (*Template, bool) {
+	for i := range templates {
+		if templates[i].ID == id {
+			t := templates[i]
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+var placeholderRe = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// Render substitutes params into the template and returns a server config
+// carrying the rules its parameters must keep satisfying.
+func (t *Template) Render(values map[string]string) (*config.MCPServer, error) {
+	resolved := make(map[string]string)
+	var missing []string
+	for _, p := range t.Params {
+		v := strings.TrimSpace(values[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			missing = append(missing, p.Name)
+		}
+		resolved[p.Name] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required parameters: %s", strings.Join(missing, ", "))
+	}
+
+	subst := func(s string) string {
+		return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+			return resolved[placeholderRe.FindStringSubmatch(m)[1]]
+		})
+	}
+
+	srv := t.Server
+	srv.Command = subst(srv.Command)
+	srv.URL = subst(srv.URL)
+	srv.Args = make([]string, len(t.Server.Args))
+	for i, a := range t.Server.Args {
+		srv.Args[i] = subst(a)
+	}
+	if len(t.Server.Env) > 0 {
+		srv.Env = make(map[string]string, len(t.Server.Env))
+		for k, v := range t.Server.Env {
+			srv.Env[k] = subst(v)
+		}
+	}
+	srv.Enabled = true
+	srv.Template = t.ID
+	srv.ParamRules = t.paramRules()
+	if problems := srv.ParamProblems(); len(problems) > 0 {
+		return nil, fmt.Errorf("invalid parameters: %s", strings.Join(problems, "; "))
+	}
+	return &srv, nil
+}
+
+// paramRules locates each param that fills a whole env value or argument.
+// Params embedded in a longer string cannot be read back and get no rule.
+func (t *Template) paramRules() []config.ParamRule {
+	var rules []config.ParamRule
+	for _, p := range t.Params {
+		placeholder := "${" + p.Name + "}"
+		rule := config.ParamRule{Param: p.Name, Required: p.Required, Path: p.Path, Pattern: p.Pattern}
+		for _, key := range sortedKeys(t.Server.Env) {
+			if t.Server.Env[key] == placeholder {
+				r := rule
+				r.Env = key
+				rules = append(rules, r)
+			}
+		}
+		for i, a := range t.Server.Args {
+			if a == placeholder {
+				r := rule
+				r.Arg = i + 1
+				rules = append(rules, r)
+			}
+		}
+	}
+	return rules
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,178 @@
+package config
+
+import "encoding/json"
+
+// clone returns a copy of the config sharing no pointer, slice or map with
+// it, so the copies the store hands out can be changed freely.
+func (c *Config) clone() *Config {
+	cp := *c
+	cp.MCPServers = make(map[string]*MCPServer, len(c.MCPServers))
+	for name, srv := range c.MCPServers {
+		cp.MCPServers[name] = srv.clone()
+	}
+	cp.Tokens = clonePtr(c.Tokens)
+	cp.SecretScan = clonePtr(c.SecretScan)
+	if c.Egress != nil {
+		e := *c.Egress
+		e.AllowedHosts = cloneSlice(e.AllowedHosts)
+		cp.Egress = &e
+	}
+	cp.Digest = clonePtr(c.Digest)
+	cp.Prefetch = clonePtr(c.Prefetch)
+	if c.LogSinks != nil {
+		cp.LogSinks = make([]LogSinkConfig, len(c.LogSinks))
+		for i, sink := range c.LogSinks {
+			sink.Labels = cloneMap(sink.Labels)
+			cp.LogSinks[i] = sink
+		}
+	}
+	if c.Notifiers != nil {
+		cp.Notifiers = make([]Notifier, len(c.Notifiers))
+		for i, n := range c.Notifiers {
+			n.Events = cloneSlice(n.Events)
+			n.Servers = cloneSlice(n.Servers)
+			cp.Notifiers[i] = n
+		}
+	}
+	if c.Proxy != nil {
+		p := *c.Proxy
+		p.PreferServers = cloneSlice(p.PreferServers)
+		cp.Proxy = &p
+	}
+	if c.RateLimits != nil {
+		rl := *c.RateLimits
+		rl.Global = clonePtr(rl.Global)
+		rl.Session = clonePtr(rl.Session)
+		rl.Server = clonePtr(rl.Server)
+		cp.RateLimits = &rl
+	}
+	cp.CircuitBreaker = clonePtr(c.CircuitBreaker)
+	if c.Endpoints != nil {
+		cp.Endpoints = make(map[string]*ProxyEndpoint, len(c.Endpoints))
+		for name, ep := range c.Endpoints {
+			if ep != nil {
+				e := *ep
+				e.Servers = cloneSlice(e.Servers)
+				e.Tags = cloneSlice(e.Tags)
+				e.DisabledTools = cloneLists(e.DisabledTools)
+				ep = &e
+			}
+			cp.Endpoints[name] = ep
+		}
+	}
+	cp.DefaultEnv = cloneMap(c.DefaultEnv)
+	if c.Groups != nil {
+		cp.Groups = make(map[string]*ServerGroup, len(c.Groups))
+		for name, g := range c.Groups {
+			if g != nil {
+				g = &ServerGroup{Env: cloneMap(g.Env)}
+			}
+			cp.Groups[name] = g
+		}
+	}
+	if c.AccessTokens != nil {
+		cp.AccessTokens = make(map[string]*AccessToken, len(c.AccessTokens))
+		for name, tok := range c.AccessTokens {
+			if tok != nil {
+				t := *tok
+				t.RateLimit = clonePtr(t.RateLimit)
+				tok = &t
+			}
+			cp.AccessTokens[name] = tok
+		}
+	}
+	cp.Applied = cloneLists(c.Applied)
+	if c.Apply != nil {
+		a := *c.Apply
+		a.Targets = cloneSlice(a.Targets)
+		cp.Apply = &a
+	}
+	cp.Extra = cloneExtra(c.Extra)
+	return &cp
+}
+
+// clone returns a deep copy of the server.
+func (s *MCPServer) clone() *MCPServer {
+	cp := *s
+	cp.Args = cloneSlice(s.Args)
+	cp.Env = cloneMap(s.Env)
+	cp.Dialer = clonePtr(s.Dialer)
+	if s.HTTP != nil {
+		h := *s.HTTP
+		h.HTTP2 = clonePtr(h.HTTP2)
+		cp.HTTP = &h
+	}
+	if s.Vendored != nil {
+		v := *s.Vendored
+		v.Args = cloneSlice(v.Args)
+		cp.Vendored = &v
+	}
+	cp.LogFilters = cloneSlice(s.LogFilters)
+	cp.RateLimit = clonePtr(s.RateLimit)
+	cp.ToolAliases = cloneMap(s.ToolAliases)
+	if s.RetryQueue != nil {
+		rq := *s.RetryQueue
+		rq.Tools = cloneSlice(rq.Tools)
+		cp.RetryQueue = &rq
+	}
+	cp.DisabledTools = cloneSlice(s.DisabledTools)
+	cp.Tags = cloneSlice(s.Tags)
+	cp.ParamRules = cloneSlice(s.ParamRules)
+	if s.HealthProbe != nil {
+		hp := *s.HealthProbe
+		hp.Arguments = cloneSlice(hp.Arguments)
+		cp.HealthProbe = &hp
+	}
+	cp.SnoozeUntil = clonePtr(s.SnoozeUntil)
+	cp.inheritedEnv = cloneMap(s.inheritedEnv)
+	cp.Extra = cloneExtra(s.Extra)
+	return &cp
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	return &cp
+}
+
+func cloneSlice[S ~[]E, E any](s S) S {
+	if s == nil {
+		return nil
+	}
+	return append(S{}, s...)
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[K]V, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
+
+func cloneLists(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string][]string, len(m))
+	for k, v := range m {
+		cp[k] = cloneSlice(v)
+	}
+	return cp
+}
+
+func cloneExtra(m map[string]json.RawMessage) map[string]json.RawMessage {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		cp[k] = cloneSlice(v)
+	}
+	return cp
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fill sets every exported field reachable from v to a value derived from
+// seed, allocating nil pointers, slices and maps on the way. Existing
+// pointers, slice elements and map entries are written through, so filling
+// a copy that still shares any of them changes the original too.
+func fill(v reflect.Value, seed int) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("v" + string(rune('0'+seed)))
+	case reflect.Int, reflect.Int64:
+		v.SetInt(int64(seed))
+	case reflect.Uint8:
+		v.SetUint(uint64(seed))
+	case reflect.Float64:
+		v.SetFloat(float64(seed))
+	case reflect.Bool:
+		v.SetBool(seed%2 == 1)
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		fill(v.Elem(), seed)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Unix(int64(seed), 0)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				fill(v.Field(i), seed)
+			}
+		}
+	case reflect.Slice:
+		if v.Len() == 0 {
+			v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		}
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), seed)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf("k").Convert(v.Type().Key())
+		if e := v.MapIndex(key); e.IsValid() && (e.Kind() == reflect.Ptr || e.Kind() == reflect.Slice) && !e.IsNil() {
+			fill(e, seed)
+			return
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		fill(e, seed)
+		v.SetMapIndex(key, e)
+	default:
+		panic("fill: unhandled kind " + v.Kind().String())
+	}
+}
+
+func TestCloneSharesNothing(t *testing.T) {
+	var orig, want Config
+	fill(reflect.ValueOf(&orig).Elem(), 1)
+	fill(reflect.ValueOf(&want).Elem(), 1)
+
+	cp := orig.clone()
+	fill(reflect.ValueOf(cp).Elem(), 2)
+	if !reflect.DeepEqual(orig, want) {
+		t.Error("changing the clone changed the original")
+	}
+}
+
+func TestGetReturnsIndependentCopy(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "config.json"))
+	cfg := store.Get()
+	cfg.MCPServers["fs"] = &MCPServer{Command: "fs", Args: []string{"-v"}, Enabled: true}
+	cfg.Tokens = &TokenSettings{SessionBudget: 100}
+	cfg.SecretScan = &SecretScanSettings{Action: "redact"}
+	if err := store.Set(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := store.Get()
+	got.Tokens.SessionBudget = 1
+	got.SecretScan.Action = "block"
+	got.MCPServers["fs"].Args[0] = "-q"
+	if srv, _ := store.GetServer("fs"); srv.Args[0] != "-v" {
+		t.Errorf("server args changed to %v", srv.Args)
+	}
+	if budget := store.GetTokenSettings().SessionBudget; budget != 100 {
+		t.Errorf("session budget changed to %d", budget)
+	}
+	if action := store.GetSecretScanSettings().Action; action != "redact" {
+		t.Errorf("secret scan action changed to %q", action)
+	}
+}
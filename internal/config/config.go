@@ -1,12 +1,38 @@
 package config
 
 import (
+	"crypto/subtle"
 	"encoding/json"
-	"os"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
+// DialerOptions tunes how remote transports open connections
+type DialerOptions struct {
+	// IPVersion forces "4" or "6"; empty uses both
+	IPVersion string `json:"ipVersion,omitempty"`
+	// BindInterface is a local interface name (e.g. "tun0") or source IP
+	BindInterface    string `json:"bindInterface,omitempty"`
+	ConnectTimeoutMs int    `json:"connectTimeoutMs,omitempty"`
+}
+
+// HTTPOptions tunes the HTTP transport used for a remote server
+type HTTPOptions struct {
+	// KeepAliveSec is the TCP keep-alive period; negative disables probes
+	KeepAliveSec       int  `json:"keepAliveSec,omitempty"`
+	MaxIdleConns       int  `json:"maxIdleConns,omitempty"`
+	IdleConnTimeoutSec int  `json:"idleConnTimeoutSec,omitempty"`
+	DisableKeepAlives  bool `json:"disableKeepAlives,omitempty"`
+	// HTTP2 set to false forces HTTP/1.1; unset negotiates HTTP/2 when offered
+	HTTP2 *bool `json:"http2,omitempty"`
+}
+
 // MCPServer represents a single MCP server configuration
 // Compatible with Claude/Codex mcpServers format
 type MCPServer struct {
@@ -16,6 +42,221 @@ type MCPServer struct {
 	Args    []string          `json:"args,omitempty"`
 	Env     map[string]string `json:"env,omitempty"`
 	Enabled bool              `json:"enabled"`
+	Dialer  *DialerOptions    `json:"dialer,omitempty"`
+	// Proxy is an upstream proxy URL for remote transports (socks5://, http://)
+	Proxy string       `json:"proxy,omitempty"`
+	HTTP  *HTTPOptions `json:"http,omitempty"`
+	// Vendored is set when the package was installed into a manager-owned
+	// directory; it keeps the original launcher command for `vendor --undo`
+	Vendored *VendoredFrom `json:"vendored,omitempty"`
+	// Integrity pins the hash of the executed package ("sha256:...")
+	Integrity string `json:"integrity,omitempty"`
+	// LogFilters are regular expressions; matching stderr lines are dropped
+	LogFilters []string `json:"logFilters,omitempty"`
+	// RateLimit overrides the per-server limit from rateLimits.server
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+	// MaxConcurrent caps simultaneous proxied calls to this server (0 = unlimited);
+	// excess calls wait for a free slot unless RejectWhenBusy is set
+	MaxConcurrent  int  `json:"maxConcurrent,omitempty"`
+	RejectWhenBusy bool `json:"rejectWhenBusy,omitempty"`
+	// ToolAliases maps upstream tool names to the names the proxy exposes
+	// them under, overriding the generated server__tool name
+	ToolAliases map[string]string `json:"toolAliases,omitempty"`
+	// RetryQueue enables store-and-forward for idempotent tools of this server
+	RetryQueue *RetryQueueSettings `json:"retryQueue,omitempty"`
+	// Priority orders servers in aggregated lists and decides name and URI
+	// conflicts; higher goes first, ties are broken by name
+	Priority int `json:"priority,omitempty"`
+	// DisabledTools are hidden from the proxy and from generated CLI configs
+	DisabledTools []string `json:"disabledTools,omitempty"`
+	// Subject is the NATS subject the server answers requests on (type nats)
+	Subject string `json:"subject,omitempty"`
+	// Prewarm keeps one initialized stdio process idle for the next proxied
+	// call; a replacement is spawned as soon as it is taken
+	Prewarm bool `json:"prewarm,omitempty"`
+	// Group names an entry of the config's groups whose env the server shares
+	Group string `json:"group,omitempty"`
+	// Tags organize the catalog by purpose (db, browser, cloud…); lists and
+	// endpoints can be filtered by them
+	Tags []string `json:"tags,omitempty"`
+	// Description says what the server is for, DocsURL links to its
+	// documentation and Notes hold free-form remarks for whoever runs it
+	Description string `json:"description,omitempty"`
+	DocsURL     string `json:"docsUrl,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+	// DescribeTools appends Description and DocsURL to the descriptions of
+	// the tools the proxy exposes, giving agents context on the server
+	DescribeTools bool `json:"describeTools,omitempty"`
+	// Template is the catalog template the server was installed from, and
+	// ParamRules the checks its parameters must keep passing
+	Template   string      `json:"template,omitempty"`
+	ParamRules []ParamRule `json:"paramRules,omitempty"`
+	// HealthProbe is a tool call a check makes after tools/list; the server
+	// only counts as healthy when the call succeeds
+	HealthProbe *HealthProbe `json:"healthProbe,omitempty"`
+	// CheckStrategy is how deep a check goes: ping, initialize, tools or deep
+	// (default: deep with a healthProbe, tools otherwise)
+	CheckStrategy string `json:"checkStrategy,omitempty"`
+	// CheckInterval overrides healthCheckInterval for this server (seconds);
+	// CheckCron schedules its checks with a cron expression instead
+	CheckInterval int    `json:"checkInterval,omitempty"`
+	CheckCron     string `json:"checkCron,omitempty"`
+	// Capture records the proxy's JSON-RPC traffic with this server, secrets
+	// redacted, to <config dir>/captures/<name>.jsonl
+	Capture bool `json:"capture,omitempty"`
+	// DisabledReason and SnoozeUntil annotate a disabled server. At
+	// SnoozeUntil it is enabled again, or with SnoozeRemind only a reminder
+	// is logged. Enabling the server clears all three.
+	DisabledReason string     `json:"disabledReason,omitempty"`
+	SnoozeUntil    *time.Time `json:"snoozeUntil,omitempty"`
+	SnoozeRemind   bool       `json:"snoozeRemind,omitempty"`
+	// inheritedEnv is defaultEnv merged with the group's env, filled in on
+	// the copies the store hands out
+	inheritedEnv map[string]string `json:"-"`
+	// Extra holds fields this version does not model, written back unchanged
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// HasAnyTag reports whether the server carries one of tags.
+func (s *MCPServer) HasAnyTag(tags []string) bool {
+	for _, want := range tags {
+		for _, t := range s.Tags {
+			if t == NormalizeTag(want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NormalizeTag is the form tags are kept in: trimmed and lower case.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags drops empty and repeated tags and sorts the rest.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, t := range tags {
+		if t = NormalizeTag(t); t != "" && !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ToolEnabled reports whether tool is not switched off for this server.
+func (s *MCPServer) ToolEnabled(tool string) bool {
+	for _, t := range s.DisabledTools {
+		if t == tool {
+			return false
+		}
+	}
+	return true
+}
+
+// Check strategies, from the cheapest to the most thorough. Each runs the
+// steps of the ones before it, except that ping skips the handshake.
+const (
+	// CheckPing sends an MCP ping without initializing
+	CheckPing = "ping"
+	// CheckInitialize stops after the initialize handshake
+	CheckInitialize = "initialize"
+	// CheckTools also lists tools, prompts and resources
+	CheckTools = "tools"
+	// CheckDeep also makes the healthProbe call
+	CheckDeep = "deep"
+)
+
+// CheckDepth is the check strategy in effect for the server.
+func (s *MCPServer) CheckDepth() string {
+	switch strings.ToLower(strings.TrimSpace(s.CheckStrategy)) {
+	case CheckPing:
+		return CheckPing
+	case CheckInitialize:
+		return CheckInitialize
+	case CheckTools:
+		return CheckTools
+	}
+	// deep, unset or unknown: probe when there is something to call
+	if s.HealthProbe != nil {
+		return CheckDeep
+	}
+	return CheckTools
+}
+
+// ExecTransport is the type of a server reached through an external helper:
+// Command runs the helper, which speaks newline-delimited JSON-RPC on stdio
+// like any stdio server and carries the messages to URL over its own transport.
+const ExecTransport = "exec-transport"
+
+// IsExecTransport reports whether the server is reached through a helper.
+func (s *MCPServer) IsExecTransport() bool {
+	return strings.EqualFold(s.Type, ExecTransport)
+}
+
+// NATSTransport is the type of a server behind a NATS bus: every JSON-RPC
+// request is published to Subject on the URL's NATS server and answered by
+// the reply.
+const NATSTransport = "nats"
+
+// IsNATS reports whether the server is reached over NATS.
+func (s *MCPServer) IsNATS() bool {
+	return strings.EqualFold(s.Type, NATSTransport)
+}
+
+// ProcessEnv returns the variables set for the launched process on top of
+// the inherited environment: defaultEnv, the group's env and Env, later ones
+// winning, plus MCP_TRANSPORT_URL for exec-transport.
+func (s *MCPServer) ProcessEnv() map[string]string {
+	if len(s.inheritedEnv) == 0 && !s.IsExecTransport() {
+		return s.Env
+	}
+	env := make(map[string]string, len(s.inheritedEnv)+len(s.Env)+1)
+	for k, v := range s.inheritedEnv {
+		env[k] = v
+	}
+	for k, v := range s.Env {
+		env[k] = v
+	}
+	if s.IsExecTransport() {
+		env["MCP_TRANSPORT_URL"] = s.URL
+	}
+	return env
+}
+
+// ServerGroup holds settings shared by the servers naming it in "group"
+type ServerGroup struct {
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// RetryQueueSettings lists tools whose calls are queued and retried in the
+// background when the server is unreachable, instead of failing
+type RetryQueueSettings struct {
+	// Tools are upstream tool names; "*" matches every tool
+	Tools []string `json:"tools"`
+	// MaxAttempts before a queued call is given up (default 10)
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// RetryIntervalSec between attempts (default 30)
+	RetryIntervalSec int `json:"retryIntervalSec,omitempty"`
+}
+
+// HealthProbe is a canned tool call that proves a server actually works
+type HealthProbe struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	// Expect is a substring the call's result must contain (empty = any result)
+	Expect string `json:"expect,omitempty"`
+}
+
+// VendoredFrom records the npx/uvx invocation a vendored server replaced
+type VendoredFrom struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Dir     string   `json:"dir"`
 }
 
 func (s *MCPServer) UnmarshalJSON(data []byte) error {
@@ -34,20 +275,306 @@ func (s *MCPServer) UnmarshalJSON(data []byte) error {
 	} else {
 		s.Enabled = *aux.Enabled
 	}
+	extra, err := extraFields(data, reflect.TypeOf(MCPServer{}))
+	if err != nil {
+		return err
+	}
+	s.Extra = extra
 	return nil
 }
 
+// TokenSettings controls token estimation for proxied traffic
+type TokenSettings struct {
+	// CharsPerToken is the heuristic used to turn payload size into tokens (default 4)
+	CharsPerToken float64 `json:"charsPerToken,omitempty"`
+	// SessionBudget caps result tokens returned to one proxy session (0 = unlimited)
+	SessionBudget int `json:"sessionBudget,omitempty"`
+}
+
+// SecretScanSettings configures detection of secrets and PII in proxied tool results
+type SecretScanSettings struct {
+	// Action is one of "log", "redact" or "block"; empty disables scanning
+	Action string `json:"action,omitempty"`
+}
+
+// EgressSettings restricts which hosts remote (HTTP) transports may contact.
+// Hosts may be exact names, "*.example.com" wildcards, IPs or CIDR ranges.
+type EgressSettings struct {
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+	// BlockByDefault blocks every host not in AllowedHosts, even when the list is empty
+	BlockByDefault bool `json:"blockByDefault,omitempty"`
+}
+
+// DigestSettings configures the daily summary report
+type DigestSettings struct {
+	Enabled bool `json:"enabled"`
+	// Hour is the local hour (0-23) at which the digest is produced
+	Hour int `json:"hour,omitempty"`
+	// Dir receives digest-YYYY-MM-DD.md files (default: <config dir>/reports)
+	Dir string `json:"dir,omitempty"`
+}
+
+// PrefetchSettings controls pre-downloading packages of npx/uvx servers
+type PrefetchSettings struct {
+	Enabled bool `json:"enabled"`
+	// IntervalHours between refreshes of the package cache (default 24)
+	IntervalHours int `json:"intervalHours,omitempty"`
+}
+
+// RateLimit is a token bucket: RequestsPerSecond refills it up to Burst
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Burst is the bucket size (default: one second worth of requests, at least 1)
+	Burst int `json:"burst,omitempty"`
+}
+
+// RateLimitSettings limits proxied calls (tools/call, prompts/get,
+// resources/read) across all clients, per proxy session and per upstream server
+type RateLimitSettings struct {
+	Global  *RateLimit `json:"global,omitempty"`
+	Session *RateLimit `json:"session,omitempty"`
+	Server  *RateLimit `json:"server,omitempty"`
+}
+
+// ProxySettings tunes the MCP proxy
+type ProxySettings struct {
+	// MaxTimeoutMs caps the per-call timeout clients may request via
+	// _meta.timeoutMs on tools/call (default 600000, 10 minutes)
+	MaxTimeoutMs int `json:"maxTimeoutMs,omitempty"`
+	// ResourceConflicts decides what happens when several servers expose the
+	// same resource URI: "namespace" (default) lists each under its server,
+	// "prefer" lists only the first server in PreferServers order (then by
+	// priority and name), "error" fails resources/list naming the conflicting servers
+	ResourceConflicts string   `json:"resourceConflicts,omitempty"`
+	PreferServers     []string `json:"preferServers,omitempty"`
+	// MaxToolNameLength bounds exposed tool names; longer ones are shortened
+	// with a hash suffix (default 64)
+	MaxToolNameLength int `json:"maxToolNameLength,omitempty"`
+	// ToolSeparator joins server and tool names (default "__")
+	ToolSeparator string `json:"toolSeparator,omitempty"`
+	// ToolPrefix is "always" (default) or "on-conflict": expose tools under
+	// their own name and add the server prefix only when names collide
+	ToolPrefix string `json:"toolPrefix,omitempty"`
+	// SessionTTLSec expires HTTP proxy sessions idle this long (default 1800;
+	// -1 keeps them until the client deletes them)
+	SessionTTLSec int `json:"sessionTtlSec,omitempty"`
+	// PersistSessions saves proxy sessions and their route tables to
+	// sessions.json next to the config, so clients survive a restart
+	PersistSessions bool `json:"persistSessions,omitempty"`
+}
+
+// ProxyEndpoint is a named MCP surface served at /mcp/{name}, exposing a
+// subset of the catalog with its own policies
+type ProxyEndpoint struct {
+	// Servers exposed by the endpoint; with Tags empty too, every enabled
+	// server is
+	Servers []string `json:"servers,omitempty"`
+	// Tags expose the servers carrying any of them, in addition to Servers
+	Tags []string `json:"tags,omitempty"`
+	// DisabledTools hides upstream tools per server, on top of the server's
+	// own disabledTools
+	DisabledTools map[string][]string `json:"disabledTools,omitempty"`
+	// SessionBudget overrides tokens.sessionBudget for sessions of this endpoint
+	SessionBudget int `json:"sessionBudget,omitempty"`
+}
+
+// AccessToken is a named client credential for the MCP proxy. Only the
+// SHA-256 of the secret is kept; the secret itself is shown once on creation.
+type AccessToken struct {
+	Hash string `json:"hash"`
+	// Endpoint, when set, is the only named endpoint the token may use
+	Endpoint string `json:"endpoint,omitempty"`
+	// RateLimit applies to all sessions of the token together
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// renamed returns a copy of the endpoint referring to server oldName as
+// newName.
+func (e *ProxyEndpoint) renamed(oldName, newName string) *ProxyEndpoint {
+	cp := *e
+	cp.Servers = renamedIn(e.Servers, oldName, newName)
+	if tools, ok := e.DisabledTools[oldName]; ok {
+		cp.DisabledTools = make(map[string][]string, len(e.DisabledTools))
+		for name, t := range e.DisabledTools {
+			cp.DisabledTools[name] = t
+		}
+		delete(cp.DisabledTools, oldName)
+		cp.DisabledTools[newName] = tools
+	}
+	return &cp
+}
+
+// renamedIn returns names with oldName replaced by newName, in a new slice
+// when it changes.
+func renamedIn(names []string, oldName, newName string) []string {
+	if !contains(names, oldName) {
+		return names
+	}
+	out := make([]string, len(names))
+	for i, name := range names {
+		if name == oldName {
+			name = newName
+		}
+		out[i] = name
+	}
+	return out
+}
+
+// Includes reports whether the endpoint exposes the server; srv may be
+// nil when only the name is known.
+func (e *ProxyEndpoint) Includes(server string, srv *MCPServer) bool {
+	if len(e.Servers) == 0 && len(e.Tags) == 0 {
+		return true
+	}
+	for _, name := range e.Servers {
+		if name == server {
+			return true
+		}
+	}
+	return srv != nil && srv.HasAnyTag(e.Tags)
+}
+
+// ToolEnabled reports whether the endpoint does not hide the server's tool.
+func (e *ProxyEndpoint) ToolEnabled(server, tool string) bool {
+	for _, t := range e.DisabledTools[server] {
+		if t == tool {
+			return false
+		}
+	}
+	return true
+}
+
+// CircuitBreakerSettings controls how the proxy stops calling a failing
+// upstream server and when it tries again
+type CircuitBreakerSettings struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// FailureThreshold is the number of consecutive failures that opens the breaker (default 3)
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// OpenSeconds is how long calls fail fast before a probe is allowed (default 30)
+	OpenSeconds int `json:"openSeconds,omitempty"`
+}
+
+// LogSinkConfig forwards manager logs and captured server output to an
+// external destination
+type LogSinkConfig struct {
+	// Type is one of "syslog", "journald", "loki" or "file"
+	Type string `json:"type"`
+	// Address of a remote syslog ("udp://host:514", "tcp://host:601"); empty uses the local daemon
+	Address string `json:"address,omitempty"`
+	// URL is the Loki base URL (the push path is appended)
+	URL string `json:"url,omitempty"`
+	// Path of a JSON-lines file for the "file" sink
+	Path   string            `json:"path,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// MinLevel drops records below debug/info/warn/error (default info)
+	MinLevel string `json:"minLevel,omitempty"`
+}
+
+// Notifier is a webhook that receives server status changes and the daily
+// digest
+type Notifier struct {
+	URL string `json:"url"`
+	// Format of the payload: "json" (default), "slack" or "discord"
+	Format string `json:"format,omitempty"`
+	// Events limits what is sent to "status" and/or "digest" (default both)
+	Events []string `json:"events,omitempty"`
+	// Servers limits status events to these servers (default all)
+	Servers []string `json:"servers,omitempty"`
+}
+
+// Wants tells whether the notifier takes an event, about server for status
+// events.
+func (n *Notifier) Wants(event, server string) bool {
+	if len(n.Events) > 0 && !contains(n.Events, event) {
+		return false
+	}
+	return server == "" || len(n.Servers) == 0 || contains(n.Servers, server)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySettings controls how the catalog is written into CLI tool configs
+type ApplySettings struct {
+	// PruneOnRemove removes a deleted server's entries from every tool config
+	// it was applied to
+	PruneOnRemove bool `json:"pruneOnRemove,omitempty"`
+
+	// AutoApply rewrites the tool configs whenever the catalog changes
+	AutoApply bool `json:"autoApply,omitempty"`
+	// Targets are the tools auto-apply writes (default: those applied before)
+	Targets []string `json:"targets,omitempty"`
+	// DebounceSeconds lets a burst of edits settle before auto-applying
+	// (default 2)
+	DebounceSeconds int `json:"debounceSeconds,omitempty"`
+	// Backups is how many previous versions of each tool config auto-apply
+	// keeps (default 5)
+	Backups int `json:"backups,omitempty"`
+}
+
 // Config holds the full configuration
 type Config struct {
-	MCPServers          map[string]*MCPServer `json:"mcpServers"`
-	HealthCheckInterval int                   `json:"healthCheckInterval,omitempty"`
+	// Version is the schema version; older files are migrated on load
+	Version             int                     `json:"version"`
+	MCPServers          map[string]*MCPServer   `json:"mcpServers"`
+	HealthCheckInterval int                     `json:"healthCheckInterval,omitempty"`
+	Tokens              *TokenSettings          `json:"tokens,omitempty"`
+	SecretScan          *SecretScanSettings     `json:"secretScan,omitempty"`
+	Egress              *EgressSettings         `json:"egress,omitempty"`
+	Digest              *DigestSettings         `json:"digest,omitempty"`
+	Prefetch            *PrefetchSettings       `json:"prefetch,omitempty"`
+	LogSinks            []LogSinkConfig         `json:"logSinks,omitempty"`
+	Notifiers           []Notifier              `json:"notifiers,omitempty"`
+	Proxy               *ProxySettings          `json:"proxy,omitempty"`
+	RateLimits          *RateLimitSettings      `json:"rateLimits,omitempty"`
+	CircuitBreaker      *CircuitBreakerSettings `json:"circuitBreaker,omitempty"`
+	// HealthPingInterval is how often (seconds) healthy remote servers are
+	// pinged between full checks (0 = off)
+	HealthPingInterval int `json:"healthPingInterval,omitempty"`
+	// CheckConcurrency is how many servers a sweep checks at once (default 4)
+	CheckConcurrency int `json:"checkConcurrency,omitempty"`
+	// CheckJitterMs delays each check of a sweep by up to this many ms so
+	// npx/uvx servers are not all spawned together (default 500, -1 = none)
+	CheckJitterMs int `json:"checkJitterMs,omitempty"`
+	// Locale selects the language of log messages and API errors ("en",
+	// "ru" or a bundle in <config dir>/locales; default "en")
+	Locale string `json:"locale,omitempty"`
+	// Endpoints are named proxy endpoints served at /mcp/{name}
+	Endpoints map[string]*ProxyEndpoint `json:"endpoints,omitempty"`
+	// DefaultEnv is set for every launched server; group and server env
+	// override it
+	DefaultEnv map[string]string       `json:"defaultEnv,omitempty"`
+	Groups     map[string]*ServerGroup `json:"groups,omitempty"`
+	// AccessTokens are the proxy client credentials; when any exist, /mcp
+	// requires one
+	AccessTokens map[string]*AccessToken `json:"accessTokens,omitempty"`
+	// Applied records, per CLI tool, which server entries were written by us
+	Applied map[string][]string `json:"applied,omitempty"`
+	Apply   *ApplySettings      `json:"apply,omitempty"`
+	// Extra holds top-level fields this version does not model
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Store manages config persistence
 type Store struct {
-	mu     sync.RWMutex
-	path   string
-	config *Config
+	mu      sync.RWMutex
+	storage Storage
+	config  *Config
+
+	// base is the config file given to NewStore, which holds the default
+	// profile
+	base    string
+	profile string
+
+	// onChange hooks run after every save
+	onChange []func()
 }
 
 func normalizeServer(srv *MCPServer) {
@@ -57,15 +584,23 @@ func normalizeServer(srv *MCPServer) {
 	srv.Type = strings.TrimSpace(srv.Type)
 	srv.URL = strings.TrimSpace(srv.URL)
 	srv.Command = strings.TrimSpace(srv.Command)
+	srv.Proxy = strings.TrimSpace(srv.Proxy)
+	srv.Tags = normalizeTags(srv.Tags)
+	srv.Description = strings.TrimSpace(srv.Description)
+	srv.DocsURL = strings.TrimSpace(srv.DocsURL)
 	if srv.URL != "" && srv.Type == "" {
 		srv.Type = "streamableHttp"
 	}
+	if srv.Enabled {
+		srv.DisabledReason, srv.SnoozeUntil, srv.SnoozeRemind = "", nil, false
+	}
 }
 
 func normalizeConfig(cfg *Config) {
 	if cfg == nil {
 		return
 	}
+	cfg.Version = CurrentVersion
 	if cfg.MCPServers == nil {
 		cfg.MCPServers = make(map[string]*MCPServer)
 	}
@@ -76,32 +611,57 @@ func normalizeConfig(cfg *Config) {
 
 func NewStore(path string) *Store {
 	return &Store{
-		path: path,
+		storage: FileStorage(path),
+		base:    path,
 		config: &Config{
+			Version:    CurrentVersion,
 			MCPServers: make(map[string]*MCPServer),
 		},
 	}
 }
 
+// SetStorage replaces where the profiles are kept; call it before Load.
+func (s *Store) SetStorage(st Storage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.storage = st
+}
+
+// Dir returns the directory holding the config file.
+func (s *Store) Dir() string {
+	return filepath.Dir(s.base)
+}
+
+// Load reads the active profile: the one chosen with UseProfile, or else
+// the one last switched to.
 func (s *Store) Load() error {
+	if s.Profile() == "" {
+		s.UseProfile(s.savedProfile())
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	data, err := os.ReadFile(s.path)
+	data, err := s.storage.Load(s.profile)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return s.saveLocked()
-		}
 		return err
 	}
+	if data == nil {
+		return s.saveLocked()
+	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	cfg, version, err := parse(data)
+	if err != nil {
 		return err
 	}
-	normalizeConfig(&cfg)
-	s.config = &cfg
-	return nil
+	s.config = cfg
+	if version == CurrentVersion {
+		return nil
+	}
+	if err := s.storage.Backup(s.profile, data, version); err != nil {
+		return fmt.Errorf("back up config before migration: %w", err)
+	}
+	slog.Info("config migrated", "path", s.storage.Location(s.profile), "from", version, "to", CurrentVersion)
+	return s.saveLocked()
 }
 
 func (s *Store) Save() error {
@@ -111,21 +671,34 @@ func (s *Store) Save() error {
 }
 
 func (s *Store) saveLocked() error {
-	data, err := json.MarshalIndent(s.config, "", "  ")
-	if err != nil {
+	if err := s.storage.Save(s.profile, s.config); err != nil {
 		return err
 	}
-	return os.WriteFile(s.path, data, 0644)
+	s.notifyLocked()
+	return nil
+}
+
+// OnChange registers a hook called, in its own goroutine, whenever the
+// config is saved or another profile is switched to.
+func (s *Store) OnChange(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+func (s *Store) notifyLocked() {
+	for _, fn := range s.onChange {
+		go fn()
+	}
 }
 
 func (s *Store) Get() *Config {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	// Return a copy
-	cp := &Config{MCPServers: make(map[string]*MCPServer)}
-	for k, v := range s.config.MCPServers {
-		srv := *v
-		cp.MCPServers[k] = &srv
+	cp := s.config.clone()
+	for _, srv := range cp.MCPServers {
+		srv.inheritedEnv = s.inheritedEnvLocked(srv)
 	}
 	return cp
 }
@@ -134,6 +707,11 @@ func (s *Store) Set(cfg *Config) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	normalizeConfig(cfg)
+	for name, srv := range cfg.MCPServers {
+		if err := checkParams(name, srv); err != nil {
+			return err
+		}
+	}
 	s.config = cfg
 	return s.saveLocked()
 }
@@ -142,10 +720,126 @@ func (s *Store) AddServer(name string, srv *MCPServer) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	normalizeServer(srv)
+	// Clients that only edit modelled fields must not strip extensions,
+	// the template rules or why a still disabled server was disabled
+	if old, ok := s.config.MCPServers[name]; ok {
+		if srv.Extra == nil {
+			srv.Extra = old.Extra
+		}
+		if srv.Template == "" && len(srv.ParamRules) == 0 {
+			srv.Template, srv.ParamRules = old.Template, old.ParamRules
+		}
+		if !srv.Enabled && !old.Enabled && srv.DisabledReason == "" && srv.SnoozeUntil == nil {
+			srv.DisabledReason, srv.SnoozeUntil, srv.SnoozeRemind = old.DisabledReason, old.SnoozeUntil, old.SnoozeRemind
+		}
+	}
+	if err := checkParams(name, srv); err != nil {
+		return err
+	}
 	s.config.MCPServers[name] = srv
 	return s.saveLocked()
 }
 
+// MergeServers adds or replaces several servers in one write; nothing is
+// written when any of them is rejected.
+func (s *Store) MergeServers(servers map[string]*MCPServer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, srv := range servers {
+		normalizeServer(srv)
+		if err := checkParams(name, srv); err != nil {
+			return err
+		}
+	}
+	for name, srv := range servers {
+		s.config.MCPServers[name] = srv
+	}
+	return s.saveLocked()
+}
+
+// ToggleTool switches a single tool of a server on or off and reports
+// whether it is enabled afterwards.
+func (s *Store) ToggleTool(name, tool string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	srv, ok := s.config.MCPServers[name]
+	if !ok {
+		return false, fmt.Errorf("server %q not found", name)
+	}
+	enabled := !srv.ToolEnabled(tool)
+	var disabled []string
+	for _, t := range srv.DisabledTools {
+		if t != tool {
+			disabled = append(disabled, t)
+		}
+	}
+	if !enabled {
+		disabled = append(disabled, tool)
+		sort.Strings(disabled)
+	}
+	srv.DisabledTools = disabled
+	return enabled, s.saveLocked()
+}
+
+// Disable switches a server off, recording why and, with a non-nil until,
+// when its snooze ends.
+func (s *Store) Disable(name, reason string, until *time.Time, remind bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	srv, ok := s.config.MCPServers[name]
+	if !ok {
+		return fmt.Errorf("server %q not found", name)
+	}
+	srv.Enabled = false
+	srv.DisabledReason = reason
+	srv.SnoozeUntil = until
+	srv.SnoozeRemind = remind && until != nil
+	return s.saveLocked()
+}
+
+// Enable switches a server on and clears its disable reason and snooze.
+func (s *Store) Enable(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.config.MCPServers[name]
+	if !ok {
+		return fmt.Errorf("server %q not found", name)
+	}
+	srv := *old
+	srv.Enabled = true
+	normalizeServer(&srv)
+	if err := checkParams(name, &srv); err != nil {
+		return err
+	}
+	s.config.MCPServers[name] = &srv
+	return s.saveLocked()
+}
+
+// ClearSnooze drops the snooze of a server that stays disabled, keeping its
+// reason.
+func (s *Store) ClearSnooze(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	srv, ok := s.config.MCPServers[name]
+	if !ok {
+		return fmt.Errorf("server %q not found", name)
+	}
+	srv.SnoozeUntil, srv.SnoozeRemind = nil, false
+	return s.saveLocked()
+}
+
+// SetIntegrity pins (or with an empty hash, unpins) a server's package hash.
+func (s *Store) SetIntegrity(name, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	srv, ok := s.config.MCPServers[name]
+	if !ok {
+		return fmt.Errorf("server %q not found", name)
+	}
+	srv.Integrity = hash
+	return s.saveLocked()
+}
+
 func (s *Store) RemoveServer(name string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -153,6 +847,122 @@ func (s *Store) RemoveServer(name string) error {
 	return s.saveLocked()
 }
 
+// RenameServer moves a server to a new name in one write, along with the
+// references named endpoints keep to it.
+func (s *Store) RenameServer(oldName, newName string) error {
+	if newName == "" || strings.Contains(newName, "/") {
+		return fmt.Errorf("server name is required and must not contain '/'")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	srv, ok := s.config.MCPServers[oldName]
+	if !ok {
+		return fmt.Errorf("server %q not found", oldName)
+	}
+	if _, exists := s.config.MCPServers[newName]; exists {
+		return fmt.Errorf("server %q already exists", newName)
+	}
+	delete(s.config.MCPServers, oldName)
+	s.config.MCPServers[newName] = srv
+	if len(s.config.Endpoints) > 0 {
+		endpoints := make(map[string]*ProxyEndpoint, len(s.config.Endpoints))
+		for name, ep := range s.config.Endpoints {
+			if ep != nil {
+				ep = ep.renamed(oldName, newName)
+			}
+			endpoints[name] = ep
+		}
+		s.config.Endpoints = endpoints
+	}
+	// Notifiers, the resource preference and the names applied to tool
+	// configs refer to servers by name too; tool configs mark the entries
+	// they hold, so the old one is still cleaned up on the next apply
+	if len(s.config.Notifiers) > 0 {
+		notifiers := make([]Notifier, len(s.config.Notifiers))
+		for i, n := range s.config.Notifiers {
+			n.Servers = renamedIn(n.Servers, oldName, newName)
+			notifiers[i] = n
+		}
+		s.config.Notifiers = notifiers
+	}
+	if p := s.config.Proxy; p != nil && contains(p.PreferServers, oldName) {
+		cp := *p
+		cp.PreferServers = renamedIn(p.PreferServers, oldName, newName)
+		s.config.Proxy = &cp
+	}
+	if len(s.config.Applied) > 0 {
+		applied := make(map[string][]string, len(s.config.Applied))
+		for tool, names := range s.config.Applied {
+			applied[tool] = renamedIn(names, oldName, newName)
+		}
+		s.config.Applied = applied
+	}
+	return s.saveLocked()
+}
+
+// GetEndpoint returns a copy of the named proxy endpoint.
+func (s *Store) GetEndpoint(name string) (ProxyEndpoint, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ep, ok := s.config.Endpoints[name]
+	if !ok || ep == nil {
+		return ProxyEndpoint{}, false
+	}
+	return *ep, true
+}
+
+// GetAccessTokens returns the proxy access tokens by name.
+func (s *Store) GetAccessTokens() map[string]AccessToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tokens := make(map[string]AccessToken, len(s.config.AccessTokens))
+	for name, tok := range s.config.AccessTokens {
+		if tok != nil {
+			tokens[name] = *tok
+		}
+	}
+	return tokens
+}
+
+// HasAccessTokens reports whether the proxy requires an access token.
+func (s *Store) HasAccessTokens() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.config.AccessTokens) > 0
+}
+
+// MatchAccessToken returns the name of the token whose hash is hash.
+func (s *Store) MatchAccessToken(hash string) (string, AccessToken, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, tok := range s.config.AccessTokens {
+		if tok != nil && subtle.ConstantTimeCompare([]byte(tok.Hash), []byte(hash)) == 1 {
+			return name, *tok, true
+		}
+	}
+	return "", AccessToken{}, false
+}
+
+func (s *Store) SetAccessToken(name string, tok *AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.config.AccessTokens == nil {
+		s.config.AccessTokens = make(map[string]*AccessToken)
+	}
+	s.config.AccessTokens[name] = tok
+	return s.saveLocked()
+}
+
+func (s *Store) RemoveAccessToken(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.config.AccessTokens[name]; !ok {
+		return fmt.Errorf("token %q not found", name)
+	}
+	delete(s.config.AccessTokens, name)
+	return s.saveLocked()
+}
+
 func (s *Store) GetServer(name string) (*MCPServer, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -160,8 +970,28 @@ func (s *Store) GetServer(name string) (*MCPServer, bool) {
 	if !ok {
 		return nil, false
 	}
-	cp := *srv
-	return &cp, true
+	cp := srv.clone()
+	cp.inheritedEnv = s.inheritedEnvLocked(cp)
+	return cp, true
+}
+
+// inheritedEnvLocked merges defaultEnv with the env of the server's group.
+func (s *Store) inheritedEnvLocked(srv *MCPServer) map[string]string {
+	var groupEnv map[string]string
+	if g := s.config.Groups[srv.Group]; g != nil && srv.Group != "" {
+		groupEnv = g.Env
+	}
+	if len(s.config.DefaultEnv) == 0 && len(groupEnv) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(s.config.DefaultEnv)+len(groupEnv))
+	for k, v := range s.config.DefaultEnv {
+		env[k] = v
+	}
+	for k, v := range groupEnv {
+		env[k] = v
+	}
+	return env
 }
 
 func (s *Store) GetHealthCheckInterval() int {
@@ -170,6 +1000,50 @@ func (s *Store) GetHealthCheckInterval() int {
 	return s.config.HealthCheckInterval
 }
 
+// GetLocale returns the selected UI locale, "en" by default.
+func (s *Store) GetLocale() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.Locale == "" {
+		return "en"
+	}
+	return s.config.Locale
+}
+
+func (s *Store) SetLocale(locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.Locale = locale
+	return s.saveLocked()
+}
+
+func (s *Store) GetHealthPingInterval() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.HealthPingInterval
+}
+
+func (s *Store) GetCheckConcurrency() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.CheckConcurrency <= 0 {
+		return 4
+	}
+	return s.config.CheckConcurrency
+}
+
+func (s *Store) GetCheckJitter() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	switch {
+	case s.config.CheckJitterMs < 0:
+		return 0
+	case s.config.CheckJitterMs == 0:
+		return 500 * time.Millisecond
+	}
+	return time.Duration(s.config.CheckJitterMs) * time.Millisecond
+}
+
 func (s *Store) SetHealthCheckInterval(seconds int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -177,6 +1051,165 @@ func (s *Store) SetHealthCheckInterval(seconds int) error {
 	return s.saveLocked()
 }
 
+func (s *Store) GetTokenSettings() TokenSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ts := TokenSettings{CharsPerToken: 4}
+	if s.config.Tokens != nil {
+		ts = *s.config.Tokens
+		if ts.CharsPerToken <= 0 {
+			ts.CharsPerToken = 4
+		}
+	}
+	return ts
+}
+
+func (s *Store) GetSecretScanSettings() SecretScanSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.SecretScan == nil {
+		return SecretScanSettings{}
+	}
+	return *s.config.SecretScan
+}
+
+func (s *Store) GetEgressSettings() EgressSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.Egress == nil {
+		return EgressSettings{}
+	}
+	return *s.config.Egress
+}
+
+func (s *Store) GetDigestSettings() DigestSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ds := DigestSettings{}
+	if s.config.Digest != nil {
+		ds = *s.config.Digest
+	}
+	if ds.Dir == "" {
+		ds.Dir = filepath.Join(filepath.Dir(s.base), "reports")
+	}
+	return ds
+}
+
+func (s *Store) GetNotifiers() []Notifier {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Notifier(nil), s.config.Notifiers...)
+}
+
+func (s *Store) GetPrefetchSettings() PrefetchSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ps := PrefetchSettings{}
+	if s.config.Prefetch != nil {
+		ps = *s.config.Prefetch
+	}
+	if ps.IntervalHours <= 0 {
+		ps.IntervalHours = 24
+	}
+	return ps
+}
+
+func (s *Store) GetRateLimitSettings() RateLimitSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.RateLimits == nil {
+		return RateLimitSettings{}
+	}
+	return *s.config.RateLimits
+}
+
+func (s *Store) GetProxySettings() ProxySettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ps := ProxySettings{}
+	if s.config.Proxy != nil {
+		ps = *s.config.Proxy
+	}
+	if ps.MaxTimeoutMs <= 0 {
+		ps.MaxTimeoutMs = 600000
+	}
+	if ps.MaxToolNameLength < 16 {
+		ps.MaxToolNameLength = 64
+	}
+	if ps.ToolSeparator == "" {
+		ps.ToolSeparator = "__"
+	}
+	if ps.ToolPrefix == "" {
+		ps.ToolPrefix = "always"
+	}
+	if ps.SessionTTLSec == 0 {
+		ps.SessionTTLSec = 1800
+	}
+	return ps
+}
+
+func (s *Store) GetCircuitBreakerSettings() CircuitBreakerSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cb := CircuitBreakerSettings{}
+	if s.config.CircuitBreaker != nil {
+		cb = *s.config.CircuitBreaker
+	}
+	if cb.FailureThreshold <= 0 {
+		cb.FailureThreshold = 3
+	}
+	if cb.OpenSeconds <= 0 {
+		cb.OpenSeconds = 30
+	}
+	return cb
+}
+
+func (s *Store) GetLogSinks() []LogSinkConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]LogSinkConfig(nil), s.config.LogSinks...)
+}
+
+func (s *Store) GetApplySettings() ApplySettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.Apply == nil {
+		return ApplySettings{DebounceSeconds: 2, Backups: 5}
+	}
+	as := *s.config.Apply
+	if as.DebounceSeconds <= 0 {
+		as.DebounceSeconds = 2
+	}
+	if as.Backups <= 0 {
+		as.Backups = 5
+	}
+	return as
+}
+
+// GetApplied returns the server names last written into the given CLI tool config.
+func (s *Store) GetApplied(tool string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := s.config.Applied[tool]
+	cp := make([]string, len(names))
+	copy(cp, names)
+	return cp
+}
+
+func (s *Store) SetApplied(tool string, names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(names) == 0 {
+		delete(s.config.Applied, tool)
+	} else {
+		if s.config.Applied == nil {
+			s.config.Applied = make(map[string][]string)
+		}
+		s.config.Applied[tool] = names
+	}
+	return s.saveLocked()
+}
+
 func (s *Store) Export() ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
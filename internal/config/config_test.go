@@ -0,0 +1,61 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRenameServerReferences(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "config.json"))
+	cfg := store.Get()
+	cfg.MCPServers = map[string]*MCPServer{
+		"old":   {Command: "a", Enabled: true},
+		"other": {Command: "b", Enabled: true},
+	}
+	cfg.Endpoints = map[string]*ProxyEndpoint{"dev": {Servers: []string{"other", "old"}}}
+	cfg.Notifiers = []Notifier{
+		{URL: "https://hooks.example/1", Servers: []string{"old", "other"}},
+		{URL: "https://hooks.example/2"},
+	}
+	cfg.Proxy = &ProxySettings{PreferServers: []string{"other", "old"}}
+	cfg.Applied = map[string][]string{"claude": {"old", "other"}, "cursor": {"other"}}
+	if err := store.Set(cfg); err != nil {
+		t.Fatal(err)
+	}
+	before := store.Get()
+
+	if err := store.RenameServer("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	after := store.Get()
+	if _, ok := after.MCPServers["new"]; !ok {
+		t.Fatal("server not renamed")
+	}
+	if got := after.Endpoints["dev"].Servers; !reflect.DeepEqual(got, []string{"other", "new"}) {
+		t.Errorf("endpoint servers = %v", got)
+	}
+	if got := after.Notifiers[0].Servers; !reflect.DeepEqual(got, []string{"new", "other"}) {
+		t.Errorf("notifier servers = %v", got)
+	}
+	if got := after.Notifiers[1].Servers; got != nil {
+		t.Errorf("unscoped notifier got servers %v", got)
+	}
+	if got := after.Proxy.PreferServers; !reflect.DeepEqual(got, []string{"other", "new"}) {
+		t.Errorf("preferServers = %v", got)
+	}
+	if got := store.GetApplied("claude"); !reflect.DeepEqual(got, []string{"new", "other"}) {
+		t.Errorf("applied to claude = %v", got)
+	}
+	if got := store.GetApplied("cursor"); !reflect.DeepEqual(got, []string{"other"}) {
+		t.Errorf("applied to cursor = %v", got)
+	}
+
+	// A copy taken before the rename keeps the old names
+	if got := before.Notifiers[0].Servers[0]; got != "old" {
+		t.Errorf("earlier copy's notifier changed to %q", got)
+	}
+	if got := before.Proxy.PreferServers[1]; got != "old" {
+		t.Errorf("earlier copy's preferServers changed to %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// hiddenValue stands in for env values in a diff; they often hold secrets
+const hiddenValue = "[hidden]"
+
+// Server changes of a ServerDiff
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// FieldChange is one field that differs between two configs. Env values are
+// shown as "[hidden]", and Old or New is omitted when the field is unset on
+// that side.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// ServerDiff is how a server differs between two configs; an added or
+// removed server lists the fields it has.
+type ServerDiff struct {
+	Name   string        `json:"name"`
+	Change string        `json:"change"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// ConfigDiff is the structured difference between two configs.
+type ConfigDiff struct {
+	Servers []ServerDiff `json:"servers"`
+	// Settings are the differing top-level fields besides mcpServers
+	Settings []FieldChange `json:"settings"`
+}
+
+// Empty reports whether the configs are the same.
+func (d *ConfigDiff) Empty() bool {
+	return len(d.Servers) == 0 && len(d.Settings) == 0
+}
+
+// Diff compares two configs field by field, including unknown fields.
+func Diff(from, to *Config) (*ConfigDiff, error) {
+	a, err := fieldsOf(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fieldsOf(to)
+	if err != nil {
+		return nil, err
+	}
+	d := &ConfigDiff{Servers: []ServerDiff{}}
+	delete(a, "mcpServers")
+	delete(b, "mcpServers")
+	d.Settings = diffFields(a, b, nil)
+
+	names := make(map[string]bool)
+	for name := range from.MCPServers {
+		names[name] = true
+	}
+	for name := range to.MCPServers {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		before, after := from.MCPServers[name], to.MCPServers[name]
+		fields, err := DiffServer(before, after)
+		if err != nil {
+			return nil, err
+		}
+		sd := ServerDiff{Name: name, Fields: fields}
+		switch {
+		case before == nil:
+			sd.Change = DiffAdded
+		case after == nil:
+			sd.Change = DiffRemoved
+		case len(sd.Fields) > 0:
+			sd.Change = DiffChanged
+		default:
+			continue
+		}
+		d.Servers = append(d.Servers, sd)
+	}
+	return d, nil
+}
+
+// DiffServer lists the fields that differ between two versions of a server;
+// either may be nil.
+func DiffServer(before, after *MCPServer) ([]FieldChange, error) {
+	var a, b map[string]any
+	var err error
+	if before != nil {
+		if a, err = fieldsOf(before); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if b, err = fieldsOf(after); err != nil {
+			return nil, err
+		}
+	}
+	return diffFields(a, b, map[string]bool{"env": true}), nil
+}
+
+// fieldsOf returns the JSON fields of v.
+func fieldsOf(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// diffFields lists the differing fields of a and b, sorted by name. Fields
+// in secret are objects compared key by key ("env.KEY") with hidden values.
+func diffFields(a, b map[string]any, secret map[string]bool) []FieldChange {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	changes := []FieldChange{}
+	for k := range keys {
+		if secret[k] {
+			inner, _ := a[k].(map[string]any)
+			innerB, _ := b[k].(map[string]any)
+			for _, c := range diffFields(inner, innerB, nil) {
+				c.Field = k + "." + c.Field
+				if c.Old != nil {
+					c.Old = hiddenValue
+				}
+				if c.New != nil {
+					c.New = hiddenValue
+				}
+				changes = append(changes, c)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(a[k], b[k]) {
+			changes = append(changes, FieldChange{Field: k, Old: a[k], New: b[k]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fields other tools add to the shared config file are kept in Extra and
+// written back on save, so the catalog never strips extensions it does not
+// understand.
+
+var knownFieldsCache sync.Map // reflect.Type -> map[string]bool
+
+// knownFields returns the JSON keys modelled by struct type t.
+func knownFields(t reflect.Type) map[string]bool {
+	if known, ok := knownFieldsCache.Load(t); ok {
+		return known.(map[string]bool)
+	}
+	known := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = t.Field(i).Name
+		}
+		known[name] = true
+	}
+	knownFieldsCache.Store(t, known)
+	return known
+}
+
+// extraFields collects the keys of a JSON object that t does not model.
+func extraFields(data []byte, t reflect.Type) (map[string]json.RawMessage, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	known := knownFields(t)
+	var extra map[string]json.RawMessage
+	for key, value := range doc {
+		if known[key] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+	return extra, nil
+}
+
+// appendExtra adds extra keys, sorted, to the end of an encoded JSON object.
+func appendExtra(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	body := bytes.TrimSuffix(bytes.TrimSpace(data), []byte("}"))
+	buf.Write(body)
+	needComma := len(bytes.TrimSpace(body)) > 1
+	for _, key := range keys {
+		if needComma {
+			buf.WriteByte(',')
+		}
+		needComma = true
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(extra[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type Alias Config
+	if err := json.Unmarshal(data, (*Alias)(c)); err != nil {
+		return err
+	}
+	extra, err := extraFields(data, reflect.TypeOf(Config{}))
+	if err != nil {
+		return err
+	}
+	c.Extra = extra
+	return nil
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	type Alias Config
+	data, err := json.Marshal(Alias(c))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(data, c.Extra)
+}
+
+func (s MCPServer) MarshalJSON() ([]byte, error) {
+	type Alias MCPServer
+	data, err := json.Marshal(Alias(s))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtra(data, s.Extra)
+}
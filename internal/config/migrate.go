@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentVersion is the config schema version this build reads and writes
+const CurrentVersion = 1
+
+// migration upgrades a raw config document by one version. It works on the
+// raw JSON so it can rename or reshape fields the structs no longer model.
+type migration func(doc map[string]json.RawMessage) error
+
+// migrations[i] upgrades a document from version i to i+1
+var migrations = []migration{
+	migrateDisabledFlag,
+}
+
+// Parse decodes a config document of any supported version, migrating it to
+// CurrentVersion. Used for files and configs uploaded through the API.
+func Parse(data []byte) (*Config, error) {
+	cfg, _, err := parse(data)
+	return cfg, err
+}
+
+func parse(data []byte) (*Config, int, error) {
+	migrated, version, err := migrate(data)
+	if err != nil {
+		return nil, version, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(migrated, &cfg); err != nil {
+		return nil, version, err
+	}
+	normalizeConfig(&cfg)
+	return &cfg, version, nil
+}
+
+// migrate brings data up to CurrentVersion. It returns the upgraded document
+// and the version the file was written with.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, 0, fmt.Errorf("version: %w", err)
+		}
+	}
+	if version > CurrentVersion {
+		// Saving would silently drop whatever the newer schema added
+		return nil, version, fmt.Errorf("config version %d is newer than supported version %d; upgrade mcp-manager", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return data, version, nil
+	}
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, version, fmt.Errorf("migrate config from version %d: %w", v, err)
+		}
+	}
+	doc["version"], _ = json.Marshal(CurrentVersion)
+	out, err := json.Marshal(doc)
+	return out, version, err
+}
+
+// migrateDisabledFlag turns the `"disabled": true` flag used by some clients'
+// configs into the catalog's `enabled` field.
+func migrateDisabledFlag(doc map[string]json.RawMessage) error {
+	raw, ok := doc["mcpServers"]
+	if !ok {
+		return nil
+	}
+	var servers map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &servers); err != nil {
+		return fmt.Errorf("mcpServers: %w", err)
+	}
+	for name, srv := range servers {
+		flag, ok := srv["disabled"]
+		if !ok {
+			continue
+		}
+		var disabled bool
+		if err := json.Unmarshal(flag, &disabled); err != nil {
+			return fmt.Errorf("mcpServers.%s.disabled: %w", name, err)
+		}
+		delete(srv, "disabled")
+		if _, set := srv["enabled"]; !set {
+			srv["enabled"], _ = json.Marshal(!disabled)
+		}
+	}
+	doc["mcpServers"], _ = json.Marshal(servers)
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ParamRule re-validates a value a catalog template asked for when the server
+// was installed, so a later edit cannot silently break the server (say, by
+// clearing a required token).
+type ParamRule struct {
+	Param string `json:"param"`
+	// Env or Arg locates the value: an env key, or a 1-based index into the
+	// launcher args (the pre-vendoring ones for vendored servers)
+	Env      string `json:"env,omitempty"`
+	Arg      int    `json:"arg,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	// Path requires the value to name an existing file or directory
+	Path bool `json:"path,omitempty"`
+	// Pattern is a regular expression the whole value must match
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// ParamError lists the template rules a server config violates.
+type ParamError struct {
+	Server   string
+	Problems []string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("server %q: %s", e.Server, strings.Join(e.Problems, "; "))
+}
+
+// paramValue returns the value a rule points at and whether it is present.
+func (s *MCPServer) paramValue(rule ParamRule) (string, bool) {
+	if rule.Env != "" {
+		v, ok := s.Env[rule.Env]
+		return v, ok
+	}
+	args := s.Args
+	if s.Vendored != nil {
+		args = s.Vendored.Args
+	}
+	if rule.Arg < 1 || rule.Arg > len(args) {
+		return "", false
+	}
+	return args[rule.Arg-1], true
+}
+
+// ParamProblems checks the server against its template rules.
+func (s *MCPServer) ParamProblems() []string {
+	var problems []string
+	for _, rule := range s.ParamRules {
+		v, _ := s.paramValue(rule)
+		v = strings.TrimSpace(v)
+		where := "argument " + fmt.Sprint(rule.Arg)
+		if rule.Env != "" {
+			where = "env " + rule.Env
+		}
+		if v == "" {
+			if rule.Required {
+				problems = append(problems, fmt.Sprintf("%s (%s) is required", rule.Param, where))
+			}
+			continue
+		}
+		if rule.Pattern != "" {
+			if re, err := regexp.Compile(`^(?:` + rule.Pattern + `)$`); err == nil && !re.MatchString(v) {
+				problems = append(problems, fmt.Sprintf("%s (%s) does not match %s", rule.Param, where, rule.Pattern))
+			}
+		}
+		if rule.Path {
+			if _, err := os.Stat(v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s (%s): path %s does not exist", rule.Param, where, v))
+			}
+		}
+	}
+	return problems
+}
+
+// checkParams returns a ParamError if a server has an invalid check schedule
+// or, when enabled, breaks its template rules. Disabled servers are not
+// launched, so they may be saved (and so disabled in the first place) while
+// breaking their rules.
+func checkParams(name string, srv *MCPServer) error {
+	var problems []string
+	if srv.CheckInterval < 0 {
+		problems = append(problems, "checkInterval must not be negative")
+	}
+	if srv.CheckCron != "" {
+		if _, err := ParseCron(srv.CheckCron); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if srv.Enabled {
+		problems = append(problems, srv.ParamProblems()...)
+	}
+	if len(problems) > 0 {
+		return &ParamError{Server: name, Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// PermIssue is a config file or directory other users can access. The config
+// holds API keys in server env, so only its owner should.
+type PermIssue struct {
+	Path string
+	Mode os.FileMode
+	// Want is the mode FixPermissions sets
+	Want os.FileMode
+}
+
+func (p PermIssue) String() string {
+	return fmt.Sprintf("%s is %04o, accessible to group or others (want %04o)", p.Path, p.Mode, p.Want)
+}
+
+// PermissionIssues checks the config file at path and its directory. ownDir
+// tells whether the directory only holds the manager's files (the default
+// location); a shared one, like a project checkout, is only flagged when
+// others can write to it and so swap the config. Missing files are not
+// issues; nor is anything on Windows, which has no such modes.
+func PermissionIssues(path string, ownDir bool) []PermIssue {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	var issues []PermIssue
+	dirMask := os.FileMode(0022)
+	if ownDir {
+		dirMask = 0077
+	}
+	if st, err := os.Stat(filepath.Dir(path)); err == nil && st.Mode().Perm()&dirMask != 0 {
+		issues = append(issues, PermIssue{Path: filepath.Dir(path), Mode: st.Mode().Perm(), Want: st.Mode().Perm() &^ dirMask})
+	}
+	if st, err := os.Stat(path); err == nil && st.Mode().Perm()&0077 != 0 {
+		issues = append(issues, PermIssue{Path: path, Mode: st.Mode().Perm(), Want: st.Mode().Perm() &^ 0077})
+	}
+	return issues
+}
+
+// FixPermissions removes group and other access from the reported paths.
+func FixPermissions(issues []PermIssue) error {
+	for _, issue := range issues {
+		if err := os.Chmod(issue.Path, issue.Want); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import "sort"
+
+// OrderedNames returns server names by descending priority, then by name,
+// so aggregated lists and conflict winners do not depend on map order.
+func OrderedNames(servers map[string]*MCPServer) []string {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return ServerBefore(servers, names[i], names[j])
+	})
+	return names
+}
+
+// ServerBefore reports whether server a outranks server b.
+func ServerBefore(servers map[string]*MCPServer, a, b string) bool {
+	pa, pb := serverPriority(servers[a]), serverPriority(servers[b])
+	if pa != pb {
+		return pa > pb
+	}
+	return a < b
+}
+
+func serverPriority(srv *MCPServer) int {
+	if srv == nil {
+		return 0
+	}
+	return srv.Priority
+}
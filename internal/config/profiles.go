@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultProfile is the profile kept in the config file itself; the others
+// live in profiles/NAME.json next to it (or all in the SQLite database).
+const DefaultProfile = "default"
+
+// profileMarker names the file recording the active profile, so the panel,
+// the CLI and stdio proxies all open the same one
+const profileMarker = "profile"
+
+var (
+	ErrNoProfile     = errors.New("no such profile")
+	ErrProfileExists = errors.New("profile already exists")
+
+	profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+)
+
+// Profile describes a named config profile.
+type Profile struct {
+	Name     string    `json:"name"`
+	Active   bool      `json:"active"`
+	Servers  int       `json:"servers"`
+	Modified time.Time `json:"modified"`
+}
+
+// CheckProfileName rejects names that cannot be a profile file.
+func CheckProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// UseProfile selects the profile Load opens, instead of the one last
+// switched to. A profile that does not exist yet is created empty.
+func (s *Store) UseProfile(name string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profile = name
+	return nil
+}
+
+// savedProfile returns the profile last switched to.
+func (s *Store) savedProfile() string {
+	data, err := os.ReadFile(filepath.Join(s.Dir(), profileMarker))
+	if err != nil {
+		return DefaultProfile
+	}
+	name := strings.TrimSpace(string(data))
+	if CheckProfileName(name) != nil {
+		return DefaultProfile
+	}
+	if data, err := s.storage.Load(name); err != nil || data == nil {
+		return DefaultProfile
+	}
+	return name
+}
+
+// Profile returns the name of the active profile.
+func (s *Store) Profile() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+// Profiles lists the default profile and those under profiles/.
+func (s *Store) Profiles() ([]Profile, error) {
+	modified, err := s.storage.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultProfile}
+	for name := range modified {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+
+	s.mu.RLock()
+	active, activeServers := s.profile, len(s.config.MCPServers)
+	s.mu.RUnlock()
+	profiles := make([]Profile, 0, len(names))
+	for _, name := range names {
+		p := Profile{Name: name, Active: name == active, Modified: modified[name]}
+		if p.Active {
+			p.Servers = activeServers
+		} else if cfg, err := s.readProfile(name); err == nil {
+			p.Servers = len(cfg.MCPServers)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+func (s *Store) readProfile(name string) (*Config, error) {
+	data, err := s.storage.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoProfile, name)
+	}
+	return Parse(data)
+}
+
+// CreateProfile creates a profile, empty or as a copy of the profile from.
+func (s *Store) CreateProfile(name, from string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	if data, err := s.storage.Load(name); err != nil {
+		return err
+	} else if data != nil {
+		return fmt.Errorf("%w: %s", ErrProfileExists, name)
+	}
+	cfg := &Config{Version: CurrentVersion, MCPServers: make(map[string]*MCPServer)}
+	if from != "" {
+		if from == s.Profile() {
+			cfg = s.Get()
+		} else {
+			var err error
+			if cfg, err = s.readProfile(from); err != nil {
+				return err
+			}
+		}
+		// Tool configs were written from the source profile
+		cfg.Applied = nil
+	}
+	return s.storage.Save(name, cfg)
+}
+
+// SwitchProfile makes another profile active and remembers it.
+func (s *Store) SwitchProfile(name string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	cfg, err := s.readProfile(name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config, s.profile = cfg, name
+	s.notifyLocked()
+	return os.WriteFile(filepath.Join(s.Dir(), profileMarker), []byte(name+"\n"), 0600)
+}
+
+// DeleteProfile removes a profile other than the default or active one.
+func (s *Store) DeleteProfile(name string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		return fmt.Errorf("cannot delete the default profile")
+	}
+	if name == s.Profile() {
+		return fmt.Errorf("cannot delete the active profile %q", name)
+	}
+	return s.storage.Delete(name)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage persists the profiles of a Store. The default keeps each profile
+// in a JSON file; storage.DB keeps them in SQLite.
+type Storage interface {
+	// Load returns a profile as a JSON config document, or nil if there is
+	// no such profile yet
+	Load(profile string) ([]byte, error)
+	// Save writes a profile, creating it if needed
+	Save(profile string, cfg *Config) error
+	// Delete removes a profile; a missing one is ErrNoProfile
+	Delete(profile string) error
+	// Profiles lists the existing profiles with when each last changed
+	Profiles() (map[string]time.Time, error)
+	// Backup keeps the document a profile had before a migration upgraded it
+	Backup(profile string, data []byte, version int) error
+	// Location tells where a profile is kept, for logs
+	Location(profile string) string
+}
+
+// fileStorage keeps the default profile in the config file and the others
+// in profiles/NAME.json next to it.
+type fileStorage struct {
+	base string
+}
+
+// FileStorage keeps the profiles in JSON files, the default one at path.
+func FileStorage(path string) Storage {
+	return fileStorage{base: path}
+}
+
+func (fs fileStorage) path(profile string) string {
+	if profile == DefaultProfile {
+		return fs.base
+	}
+	return filepath.Join(filepath.Dir(fs.base), "profiles", profile+".json")
+}
+
+func (fs fileStorage) Location(profile string) string {
+	return fs.path(profile)
+}
+
+func (fs fileStorage) Load(profile string) ([]byte, error) {
+	data, err := os.ReadFile(fs.path(profile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (fs fileStorage) Save(profile string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := fs.path(profile)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// 0600: server env holds API keys. Existing files keep their mode;
+	// PermissionIssues reports loose ones.
+	return os.WriteFile(path, data, 0600)
+}
+
+func (fs fileStorage) Delete(profile string) error {
+	err := os.Remove(fs.path(profile))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNoProfile, profile)
+	}
+	return err
+}
+
+func (fs fileStorage) Profiles() (map[string]time.Time, error) {
+	profiles := make(map[string]time.Time)
+	if st, err := os.Stat(fs.base); err == nil {
+		profiles[DefaultProfile] = st.ModTime()
+	}
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(fs.base), "profiles", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".json")
+		if name == DefaultProfile || CheckProfileName(name) != nil {
+			continue
+		}
+		if st, err := os.Stat(f); err == nil {
+			profiles[name] = st.ModTime()
+		}
+	}
+	return profiles, nil
+}
+
+// Backup keeps the original next to the config as config.json.v<N>.bak.
+func (fs fileStorage) Backup(profile string, data []byte, version int) error {
+	return os.WriteFile(fmt.Sprintf("%s.v%d.bak", fs.path(profile), version), data, 0600)
+}
@@ -0,0 +1,485 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role is what a user or API token may do in the management UI and API.
+type Role string
+
+const (
+	// RoleViewer sees status, logs and history
+	RoleViewer Role = "viewer"
+	// RoleOperator also checks, enables and disables servers
+	RoleOperator Role = "operator"
+	// RoleAdmin also changes the config and applies it to tools
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	return r.rank() > 0
+}
+
+// Allows reports whether r may do everything other may.
+func (r Role) Allows(other Role) bool {
+	return r.Valid() && r.rank() >= other.rank()
+}
+
+// User is an account of the management UI and API.
+type User struct {
+	Role Role `json:"role"`
+	// PasswordHash is "pbkdf2-sha256$ITERATIONS$SALT$KEY" in hex
+	PasswordHash string    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// APIToken is a credential for scripts using the management API with a
+// fixed role. Only the SHA-256 of the secret is kept.
+type APIToken struct {
+	Hash      string    `json:"hash"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var ErrNoUser = errors.New("no such user")
+
+const (
+	passwordIterations = 210000
+	passwordKeyLen     = 32
+)
+
+// HashPassword derives a salted PBKDF2-SHA256 hash of password.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2SHA256([]byte(password), salt, passwordIterations, passwordKeyLen)
+	return fmt.Sprintf("pbkdf2-sha256$%d$%x$%x", passwordIterations, salt, key), nil
+}
+
+// CheckPassword reports whether password matches the user's hash.
+func (u User) CheckPassword(password string) bool {
+	parts := strings.Split(u.PasswordHash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return false
+	}
+	salt, err1 := hex.DecodeString(parts[2])
+	want, err2 := hex.DecodeString(parts[3])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	got := pbkdf2SHA256([]byte(password), salt, iter, len(want))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// pbkdf2SHA256 is PBKDF2 (RFC 8018) with HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// OIDCConfig signs UI users in with an OpenID Connect provider (Google
+// Workspace, Keycloak…) instead of local passwords.
+type OIDCConfig struct {
+	// Issuer is the provider URL serving /.well-known/openid-configuration
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	// RedirectURL defaults to <dashboard>/api/auth/oidc/callback, which is
+	// only used while the dashboard and the browser are on loopback
+	RedirectURL string `json:"redirectUrl,omitempty"`
+	// Scopes are requested in addition to openid (default: profile, email)
+	Scopes []string `json:"scopes,omitempty"`
+	// GroupsClaim is the ID token claim listing the user's groups
+	// (default: groups)
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+	// Roles maps groups to roles; a user gets the highest role mapped
+	Roles map[string]Role `json:"roles,omitempty"`
+	// DefaultRole is given to users in no mapped group; empty rejects them
+	DefaultRole Role `json:"defaultRole,omitempty"`
+	// Domains, when set, admit only e-mail addresses in these domains
+	Domains []string `json:"domains,omitempty"`
+}
+
+// Validate checks the required fields and the roles.
+func (c *OIDCConfig) Validate() error {
+	if c.Issuer == "" || c.ClientID == "" {
+		return fmt.Errorf("oidc: issuer and clientId are required")
+	}
+	for group, role := range c.Roles {
+		if !role.Valid() {
+			return fmt.Errorf("oidc: unknown role %q for group %q", role, group)
+		}
+	}
+	if c.DefaultRole != "" && !c.DefaultRole.Valid() {
+		return fmt.Errorf("oidc: unknown default role %q", c.DefaultRole)
+	}
+	return nil
+}
+
+// normalize lowercases the domains and drops blank ones, so they compare
+// with the domain part of an address as written in users.json.
+func (c *OIDCConfig) normalize() {
+	var domains []string
+	for _, d := range c.Domains {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	c.Domains = domains
+}
+
+// RoleFor is the highest role mapped from groups, or DefaultRole.
+func (c *OIDCConfig) RoleFor(groups []string) Role {
+	best := c.DefaultRole
+	for _, g := range groups {
+		if role := c.Roles[g]; role.Valid() && !best.Allows(role) {
+			best = role
+		}
+	}
+	return best
+}
+
+type usersFile struct {
+	Users  map[string]*User     `json:"users,omitempty"`
+	Tokens map[string]*APIToken `json:"tokens,omitempty"`
+	OIDC   *OIDCConfig          `json:"oidc,omitempty"`
+}
+
+// Users keeps the accounts and API tokens of the management UI and API in
+// users.json next to the config, shared by every profile. Until a user
+// exists the API stays open. Changes made by other processes (the CLI) are
+// picked up on the next call.
+type Users struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	data    usersFile
+	// err is why the file could not be read last time; logins stay
+	// required until it reads again
+	err error
+}
+
+func NewUsers(dir string) *Users {
+	return &Users{path: filepath.Join(dir, "users.json")}
+}
+
+// Path returns the users file.
+func (u *Users) Path() string {
+	return u.path
+}
+
+// refreshLocked rereads the file when it changed on disk.
+func (u *Users) refreshLocked() error {
+	u.err = u.readLocked()
+	return u.err
+}
+
+func (u *Users) readLocked() error {
+	st, err := os.Stat(u.path)
+	if errors.Is(err, os.ErrNotExist) {
+		u.data, u.modTime = usersFile{}, time.Time{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.err == nil && st.ModTime().Equal(u.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(u.path)
+	if err != nil {
+		return err
+	}
+	var f usersFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse %s: %w", u.path, err)
+	}
+	u.data, u.modTime = f, st.ModTime()
+	return nil
+}
+
+func (u *Users) saveLocked() error {
+	data, err := json.MarshalIndent(u.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(u.path), 0700); err != nil {
+		return err
+	}
+	// Replace the file in one step: the CLI and a running server both write
+	// it, and a reader must never see it half-written
+	tmp, err := os.CreateTemp(filepath.Dir(u.path), "users-*.json.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), u.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	u.err = nil
+	if st, err := os.Stat(u.path); err == nil {
+		u.modTime = st.ModTime()
+	}
+	return nil
+}
+
+// Load reads the users file; a missing one means no users.
+func (u *Users) Load() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.refreshLocked()
+}
+
+// Enabled reports whether the API requires a login: a user exists or
+// single sign-on is set up. An unreadable file counts too, so a broken
+// users.json never opens the API.
+func (u *Users) Enabled() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.refreshLocked()
+	return u.err != nil || len(u.data.Users) > 0 || u.data.OIDC != nil
+}
+
+// Err reports why the users file cannot be read, nil when it can.
+func (u *Users) Err() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.refreshLocked()
+}
+
+// HasPasswords reports whether local accounts exist.
+func (u *Users) HasPasswords() bool {
+	return len(u.List()) > 0
+}
+
+// OIDC returns the single sign-on settings, nil when it is off.
+func (u *Users) OIDC() *OIDCConfig {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.refreshLocked()
+	if u.data.OIDC == nil {
+		return nil
+	}
+	c := *u.data.OIDC
+	return &c
+}
+
+// SetOIDC turns single sign-on on with c, or off when c is nil.
+func (u *Users) SetOIDC(c *OIDCConfig) error {
+	if c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		c.normalize()
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.refreshLocked(); err != nil {
+		return err
+	}
+	u.data.OIDC = c
+	return u.saveLocked()
+}
+
+// List returns the users by name.
+func (u *Users) List() map[string]User {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.refreshLocked()
+	users := make(map[string]User, len(u.data.Users))
+	for name, user := range u.data.Users {
+		if user != nil {
+			users[name] = *user
+		}
+	}
+	return users
+}
+
+// Get returns a user.
+func (u *Users) Get(name string) (User, bool) {
+	user, ok := u.List()[name]
+	return user, ok
+}
+
+// Put creates a user or changes the role and, when password is not empty,
+// the password of an existing one.
+func (u *Users) Put(name string, role Role, password string) error {
+	if name == "" || strings.ContainsAny(name, "/ ") {
+		return fmt.Errorf("user name is required and must not contain '/' or spaces")
+	}
+	if !role.Valid() {
+		return fmt.Errorf("unknown role %q: want admin, operator or viewer", role)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.refreshLocked(); err != nil {
+		return err
+	}
+	user := u.data.Users[name]
+	if user == nil {
+		if password == "" {
+			return fmt.Errorf("a password is required for a new user")
+		}
+		user = &User{CreatedAt: time.Now().UTC()}
+	}
+	if password != "" {
+		hash, err := HashPassword(password)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = hash
+	}
+	if user.Role == RoleAdmin && role != RoleAdmin && u.adminsLocked() == 1 {
+		return fmt.Errorf("%q is the last admin", name)
+	}
+	user.Role = role
+	if u.data.Users == nil {
+		u.data.Users = make(map[string]*User)
+	}
+	u.data.Users[name] = user
+	return u.saveLocked()
+}
+
+func (u *Users) adminsLocked() int {
+	n := 0
+	for _, user := range u.data.Users {
+		if user != nil && user.Role == RoleAdmin {
+			n++
+		}
+	}
+	return n
+}
+
+// Remove deletes a user. The last admin can only go with the other users,
+// so the API is never left with accounts nobody can manage.
+func (u *Users) Remove(name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.refreshLocked(); err != nil {
+		return err
+	}
+	user, ok := u.data.Users[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoUser, name)
+	}
+	if user != nil && user.Role == RoleAdmin && u.adminsLocked() == 1 && len(u.data.Users) > 1 {
+		return fmt.Errorf("%q is the last admin", name)
+	}
+	delete(u.data.Users, name)
+	return u.saveLocked()
+}
+
+// Authenticate checks a password and returns the user's role.
+func (u *Users) Authenticate(name, password string) (Role, bool) {
+	user, ok := u.Get(name)
+	if !ok || !user.CheckPassword(password) {
+		return "", false
+	}
+	return user.Role, true
+}
+
+// Tokens returns the API tokens by name.
+func (u *Users) Tokens() map[string]APIToken {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.refreshLocked()
+	tokens := make(map[string]APIToken, len(u.data.Tokens))
+	for name, tok := range u.data.Tokens {
+		if tok != nil {
+			tokens[name] = *tok
+		}
+	}
+	return tokens
+}
+
+// MatchToken returns the API token whose hash is hash.
+func (u *Users) MatchToken(hash string) (string, APIToken, bool) {
+	for name, tok := range u.Tokens() {
+		if subtle.ConstantTimeCompare([]byte(tok.Hash), []byte(hash)) == 1 {
+			return name, tok, true
+		}
+	}
+	return "", APIToken{}, false
+}
+
+func (u *Users) SetToken(name string, tok *APIToken) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.refreshLocked(); err != nil {
+		return err
+	}
+	if u.data.Tokens == nil {
+		u.data.Tokens = make(map[string]*APIToken)
+	}
+	u.data.Tokens[name] = tok
+	return u.saveLocked()
+}
+
+func (u *Users) RemoveToken(name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.refreshLocked(); err != nil {
+		return err
+	}
+	if _, ok := u.data.Tokens[name]; !ok {
+		return fmt.Errorf("token %q not found", name)
+	}
+	delete(u.data.Tokens, name)
+	return u.saveLocked()
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// A users file that cannot be read keeps logins required instead of
+// opening the API.
+func TestUsersUnreadableFileFailsClosed(t *testing.T) {
+	dir := t.TempDir()
+	u := NewUsers(dir)
+	if u.Enabled() || u.Err() != nil {
+		t.Fatal("no users file should leave the API open")
+	}
+
+	os.WriteFile(u.Path(), []byte(`{"users": {"adm`), 0600)
+	if err := u.Load(); err == nil {
+		t.Error("Load accepted a truncated file")
+	}
+	if !u.Enabled() || u.Err() == nil {
+		t.Error("truncated file opened the API")
+	}
+
+	if err := u.Put("adm", RoleAdmin, "secret-password"); err == nil {
+		t.Error("Put overwrote an unreadable users file")
+	}
+	os.WriteFile(u.Path(), []byte(`{}`), 0600)
+	if u.Enabled() || u.Err() != nil {
+		t.Error("fixed file still reported as unreadable")
+	}
+}
+
+func TestUsersSaveReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	u := NewUsers(dir)
+	if err := u.Put("adm", RoleAdmin, "secret-password"); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "users.json" {
+		t.Errorf("dir holds %v, want only users.json", entries)
+	}
+	if st, _ := os.Stat(filepath.Join(dir, "users.json")); st.Mode().Perm() != 0600 {
+		t.Errorf("mode %v, want 0600", st.Mode().Perm())
+	}
+	if _, ok := NewUsers(dir).Get("adm"); !ok {
+		t.Error("saved user not read back")
+	}
+}
+
+func TestSetOIDCNormalizesDomains(t *testing.T) {
+	u := NewUsers(t.TempDir())
+	err := u.SetOIDC(&OIDCConfig{Issuer: "https://sso.example.com", ClientID: "mm", Domains: []string{" Corp.COM", "", "example.org"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.OIDC().Domains; !reflect.DeepEqual(got, []string{"corp.com", "example.org"}) {
+		t.Errorf("domains = %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+// Package i18n translates the user-facing strings generated in Go: server
+// log messages and error texts shown by the UI. The English text is the
+// message key, so untranslated messages read as before; bundles map it to
+// the text of another locale.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale of the message keys
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+var (
+	mu      sync.RWMutex
+	bundles = map[string]map[string]string{DefaultLocale: {}}
+	// locale returns the selected locale, read on every translation so a
+	// changed setting applies at once
+	locale = func() string { return DefaultLocale }
+)
+
+func init() {
+	entries, _ := builtin.ReadDir("locales")
+	for _, e := range entries {
+		data, err := builtin.ReadFile("locales/" + e.Name())
+		if err != nil {
+			continue
+		}
+		if err := loadBundle(strings.TrimSuffix(e.Name(), ".json"), data); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", e.Name(), err))
+		}
+	}
+}
+
+// Register adds messages to the bundle of a locale, replacing existing
+// translations of the same keys.
+func Register(loc string, messages map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	b := bundles[loc]
+	if b == nil {
+		b = make(map[string]string, len(messages))
+		bundles[loc] = b
+	}
+	for k, v := range messages {
+		b[k] = v
+	}
+}
+
+func loadBundle(loc string, data []byte) error {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return err
+	}
+	Register(loc, messages)
+	return nil
+}
+
+// LoadDir registers the bundles <dir>/<locale>.json, so translations can be
+// added or corrected without a rebuild. A missing dir is not an error.
+func LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := loadBundle(strings.TrimSuffix(filepath.Base(path), ".json"), data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// SetLocaleSource sets the func that returns the selected locale.
+func SetLocaleSource(f func() string) {
+	mu.Lock()
+	defer mu.Unlock()
+	locale = f
+}
+
+// Locales lists the locales that have a bundle.
+func Locales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	locs := make([]string, 0, len(bundles))
+	for loc := range bundles {
+		locs = append(locs, loc)
+	}
+	sort.Strings(locs)
+	return locs
+}
+
+// Known reports whether loc has a bundle.
+func Known(loc string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := bundles[loc]
+	return ok
+}
+
+// lookup returns the translation of key in the selected locale, or key.
+func lookup(key string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if text, ok := bundles[locale()][key]; ok && text != "" {
+		return text
+	}
+	return key
+}
+
+// T translates a message and formats it like fmt.Sprintf when args are
+// given.
+func T(key string, args ...any) string {
+	if len(args) == 0 {
+		return lookup(key)
+	}
+	return fmt.Sprintf(lookup(key), args...)
+}
+
+// Errorf is fmt.Errorf with a translated format; %w wraps as usual.
+func Errorf(key string, args ...any) error {
+	return fmt.Errorf(lookup(key), args...)
+}
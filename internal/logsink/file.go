@@ -0,0 +1,45 @@
+package logsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// fileSink appends records as JSON lines
+type fileSink struct {
+	f *os.File
+}
+
+func newFileSink(cfg config.LogSinkConfig) (Sink, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{f: f}, nil
+}
+
+func (s *fileSink) Write(rec Record) error {
+	line, err := json.Marshal(map[string]any{
+		"time":   rec.Time.Format(time.RFC3339Nano),
+		"level":  rec.Level.String(),
+		"server": rec.Server,
+		"msg":    rec.Message,
+		"attrs":  rec.Attrs,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.f.Write(append(line, '\n'))
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
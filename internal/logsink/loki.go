@@ -0,0 +1,162 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+const (
+	lokiBatchSize     = 100
+	lokiFlushInterval = 2 * time.Second
+)
+
+// lokiSink batches records and pushes them to Loki's HTTP push API. Records
+// are grouped into streams by level and server on top of the static labels.
+type lokiSink struct {
+	url    string
+	labels map[string]string
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []Record
+	stop    chan struct{}
+	done    chan struct{}
+	lastErr error
+}
+
+func newLokiSink(cfg config.LogSinkConfig) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	labels := map[string]string{"job": "mcp-manager"}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	s := &lokiSink{
+		url:    strings.TrimRight(cfg.URL, "/") + "/loki/api/v1/push",
+		labels: labels,
+		client: &http.Client{Timeout: 10 * time.Second},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.loop()
+	return s, nil
+}
+
+// Write buffers the record; delivery errors surface on a later Write.
+func (s *lokiSink) Write(rec Record) error {
+	s.mu.Lock()
+	s.pending = append(s.pending, rec)
+	full := len(s.pending) >= lokiBatchSize
+	err := s.lastErr
+	s.lastErr = nil
+	s.mu.Unlock()
+	if full {
+		s.flush()
+	}
+	return err
+}
+
+func (s *lokiSink) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(lokiFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *lokiSink) flush() {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	streams := make(map[string]*lokiStream)
+	for _, rec := range batch {
+		key := rec.Level.String() + "\x00" + rec.Server
+		st, ok := streams[key]
+		if !ok {
+			labels := make(map[string]string, len(s.labels)+2)
+			for k, v := range s.labels {
+				labels[k] = v
+			}
+			labels["level"] = strings.ToLower(rec.Level.String())
+			if rec.Server != "" {
+				labels["server"] = rec.Server
+			}
+			st = &lokiStream{Stream: labels}
+			streams[key] = st
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(rec.Time.UnixNano(), 10), lokiLine(rec)})
+	}
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, st := range streams {
+		body.Streams = append(body.Streams, st)
+	}
+	data, err := json.Marshal(body)
+	if err == nil {
+		var resp *http.Response
+		resp, err = s.client.Post(s.url, "application/json", bytes.NewReader(data))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				err = fmt.Errorf("push: %s", resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+	}
+}
+
+// lokiLine renders the message with attributes in logfmt style.
+func lokiLine(rec Record) string {
+	if len(rec.Attrs) == 0 {
+		return rec.Message
+	}
+	keys := make([]string, 0, len(rec.Attrs))
+	for k := range rec.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(rec.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%s", k, strconv.Quote(rec.Attrs[k]))
+	}
+	return sb.String()
+}
+
+func (s *lokiSink) Close() error {
+	close(s.stop)
+	<-s.done
+	return nil
+}
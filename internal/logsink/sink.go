@@ -0,0 +1,217 @@
+// Package logsink forwards manager logs and captured server output to
+// external log pipelines: syslog, journald, Loki or a JSON-lines file.
+package logsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+const queueSize = 1024
+
+// Record is one log line. Server is set for output captured from an MCP
+// server and empty for the manager's own logs.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Server  string
+	Message string
+	Attrs   map[string]string
+}
+
+// Sink is a log destination
+type Sink interface {
+	Write(rec Record) error
+	Close() error
+}
+
+type sinkEntry struct {
+	sink     Sink
+	name     string
+	minLevel slog.Level
+}
+
+// Dispatcher fans records out to sinks from a background goroutine so that
+// logging never blocks on a slow destination; records are dropped when the
+// queue is full.
+type Dispatcher struct {
+	sinks []sinkEntry
+	queue chan Record
+	done  chan struct{}
+	once  sync.Once
+}
+
+// Open creates the configured sinks. Sinks that fail to open are skipped and
+// reported in the returned error; the dispatcher still serves the others.
+func Open(cfgs []config.LogSinkConfig) (*Dispatcher, error) {
+	d := &Dispatcher{queue: make(chan Record, queueSize), done: make(chan struct{})}
+	var errs []error
+	for i, cfg := range cfgs {
+		sink, err := newSink(cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("log sink #%d (%s): %w", i+1, cfg.Type, err))
+			continue
+		}
+		lvl := slog.LevelInfo
+		if cfg.MinLevel != "" {
+			if err := lvl.UnmarshalText([]byte(cfg.MinLevel)); err != nil {
+				errs = append(errs, fmt.Errorf("log sink #%d (%s): invalid minLevel %q", i+1, cfg.Type, cfg.MinLevel))
+			}
+		}
+		d.sinks = append(d.sinks, sinkEntry{sink: sink, name: cfg.Type, minLevel: lvl})
+	}
+	go d.run()
+	return d, errors.Join(errs...)
+}
+
+func newSink(cfg config.LogSinkConfig) (Sink, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Type)) {
+	case "syslog":
+		return newSyslogSink(cfg)
+	case "journald":
+		return newJournaldSink(cfg)
+	case "loki":
+		return newLokiSink(cfg)
+	case "file":
+		return newFileSink(cfg)
+	}
+	return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+}
+
+// Empty reports whether no sinks are configured.
+func (d *Dispatcher) Empty() bool {
+	return len(d.sinks) == 0
+}
+
+// Send queues a record for delivery.
+func (d *Dispatcher) Send(rec Record) {
+	if len(d.sinks) == 0 {
+		return
+	}
+	select {
+	case d.queue <- rec:
+	default:
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	failing := make(map[string]bool)
+	for rec := range d.queue {
+		for _, e := range d.sinks {
+			if rec.Level < e.minLevel {
+				continue
+			}
+			// Report a failing sink once on stderr; logging through slog
+			// here would feed the record back into the queue.
+			if err := e.sink.Write(rec); err != nil && !failing[e.name] {
+				failing[e.name] = true
+				fmt.Fprintf(os.Stderr, "log sink %s: %v\n", e.name, err)
+			} else if err == nil {
+				failing[e.name] = false
+			}
+		}
+	}
+}
+
+// Close flushes queued records and closes all sinks.
+func (d *Dispatcher) Close() {
+	d.once.Do(func() {
+		close(d.queue)
+		<-d.done
+		for _, e := range d.sinks {
+			e.sink.Close()
+		}
+	})
+}
+
+// ServerLevel maps the level of a captured server log entry to slog.
+func ServerLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "stderr":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// Handler tees slog records to the dispatcher in addition to next.
+type Handler struct {
+	next  slog.Handler
+	d     *Dispatcher
+	attrs []slog.Attr
+	group string
+}
+
+func NewHandler(next slog.Handler, d *Dispatcher) *Handler {
+	return &Handler{next: next, d: d}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level) || !h.d.Empty()
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	rec := Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: make(map[string]string)}
+	mirrored := false
+	add := func(a slog.Attr) {
+		if a.Key == "severity" {
+			mirrored = true
+		}
+		key := a.Key
+		if h.group != "" {
+			key = h.group + "." + key
+		}
+		if key == "server" {
+			rec.Server = a.Value.String()
+			return
+		}
+		rec.Attrs[key] = a.Value.String()
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		add(a)
+		return true
+	})
+	// Server log entries mirrored to slog by the manager carry a "severity"
+	// attribute; they reach the sinks through Manager.OnLog with their real level.
+	if !mirrored {
+		h.d.Send(rec)
+	}
+	return err
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	cp := *h
+	cp.next = h.next.WithAttrs(attrs)
+	cp.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &cp
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	cp := *h
+	cp.next = h.next.WithGroup(name)
+	if cp.group != "" {
+		cp.group += "." + name
+	} else {
+		cp.group = name
+	}
+	return &cp
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package logsink
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+func newSyslogSink(cfg config.LogSinkConfig) (Sink, error) {
+	return nil, fmt.Errorf("syslog is not supported on %s", runtime.GOOS)
+}
+
+func newJournaldSink(cfg config.LogSinkConfig) (Sink, error) {
+	return nil, fmt.Errorf("journald is not supported on %s", runtime.GOOS)
+}
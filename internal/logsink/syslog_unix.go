@@ -0,0 +1,148 @@
+//go:build unix
+
+package logsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"log/syslog"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// syslogSink writes to the local syslog daemon or a remote one
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink(cfg config.LogSinkConfig) (Sink, error) {
+	network, addr := "", ""
+	if cfg.Address != "" {
+		u, err := url.Parse(cfg.Address)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid address %q: want udp://host:port or tcp://host:port", cfg.Address)
+		}
+		network, addr = u.Scheme, u.Host
+	}
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, "mcp-manager")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(rec Record) error {
+	msg := rec.Message
+	if rec.Server != "" {
+		msg = "[" + rec.Server + "] " + msg
+	}
+	msg += formatAttrs(rec.Attrs)
+	switch {
+	case rec.Level >= slog.LevelError:
+		return s.w.Err(msg)
+	case rec.Level >= slog.LevelWarn:
+		return s.w.Warning(msg)
+	case rec.Level >= slog.LevelInfo:
+		return s.w.Info(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
+
+// journaldSink speaks the journald native protocol, so the server name and
+// attributes become structured journal fields.
+type journaldSink struct {
+	conn *net.UnixConn
+}
+
+func newJournaldSink(cfg config.LogSinkConfig) (Sink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldSink{conn: conn}, nil
+}
+
+func (s *journaldSink) Write(rec Record) error {
+	var buf bytes.Buffer
+	field := func(key, value string) {
+		if strings.Contains(value, "\n") {
+			// Binary-safe form: KEY\n<little-endian uint64 length><value>\n
+			buf.WriteString(key)
+			buf.WriteByte('\n')
+			binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+			buf.WriteString(value)
+			buf.WriteByte('\n')
+			return
+		}
+		buf.WriteString(key + "=" + value + "\n")
+	}
+	field("MESSAGE", rec.Message)
+	field("PRIORITY", fmt.Sprint(journalPriority(rec.Level)))
+	field("SYSLOG_IDENTIFIER", "mcp-manager")
+	if rec.Server != "" {
+		field("MCP_SERVER", rec.Server)
+	}
+	for k, v := range rec.Attrs {
+		field("MCP_"+journalKey(k), v)
+	}
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	}
+	return 7
+}
+
+// journalKey upper-cases a key and replaces characters journald rejects.
+func journalKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, k)
+}
+
+func formatAttrs(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%q", k, attrs[k])
+	}
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// StartAutoApply keeps the tool configs in line with the catalog when
+// apply.autoApply is set: every change is followed, once edits settle for
+// apply.debounceSeconds, by writing the targets that went out of sync.
+func (m *Manager) StartAutoApply() {
+	changed := make(chan struct{}, 1)
+	m.store.OnChange(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	for range changed {
+		settings := m.store.GetApplySettings()
+		if !settings.AutoApply {
+			continue
+		}
+		debounce := time.Duration(settings.DebounceSeconds) * time.Second
+		quiet := time.NewTimer(debounce)
+		for waiting := true; waiting; {
+			select {
+			case <-changed:
+				if !quiet.Stop() {
+					<-quiet.C
+				}
+				quiet.Reset(debounce)
+			case <-quiet.C:
+				waiting = false
+			}
+		}
+		for _, res := range m.AutoApply() {
+			if res.OK {
+				slog.Info("auto-applied catalog", "tool", res.Tool)
+			} else {
+				slog.Warn("auto-apply failed", "tool", res.Tool, "err", res.Error)
+			}
+		}
+	}
+}
+
+// AutoApply writes the catalog to the auto-apply targets whose config no
+// longer matches it, backing up each config first. Targets in sync are
+// left untouched, so the writes it causes settle after one pass.
+func (m *Manager) AutoApply() []ApplyResult {
+	settings := m.store.GetApplySettings()
+	status := make(map[string]ToolSync)
+	for _, st := range m.ToolSyncStatus() {
+		status[st.Tool] = st
+	}
+	targets := settings.Targets
+	if len(targets) == 0 {
+		targets = sortedKeys(status)
+	}
+
+	var results []ApplyResult
+	for _, tool := range targets {
+		if st, applied := status[tool]; applied && (st.InSync || st.Error != "") {
+			continue
+		}
+		res := ApplyResult{Tool: tool}
+		diff, err := m.PreviewApply(tool)
+		if err == nil && diff.Current == diff.Proposed {
+			continue
+		}
+		if err == nil && diff.Current != "" {
+			err = m.backupToolConfig(tool, diff, settings.Backups)
+		}
+		if err == nil {
+			err = m.ApplyToTool(tool)
+		}
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.OK = true
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// backupToolConfig saves the current content of a tool config under
+// apply-backups/TOOL/ next to the catalog config, keeping the last keep.
+func (m *Manager) backupToolConfig(tool string, diff *DiffResult, keep int) error {
+	dir := filepath.Join(m.store.Dir(), "apply-backups", tool)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := time.Now().Format("20060102-150405.000") + "-" + filepath.Base(diff.ConfigPath)
+	// Tool configs may hold secrets in env
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(diff.Current), 0600); err != nil {
+		return fmt.Errorf("back up %s: %w", diff.ConfigPath, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	for len(names) > keep {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+	return nil
+}
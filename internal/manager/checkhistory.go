@@ -0,0 +1,235 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// checkHistoryRetention is how far back check results are kept
+	checkHistoryRetention = 7 * 24 * time.Hour
+	// checkHistoryCompact is how many expired records a server's file may
+	// hold before it is rewritten
+	checkHistoryCompact = 1000
+)
+
+// CheckRecord is the outcome of one finished check.
+type CheckRecord struct {
+	Time       time.Time    `json:"time"`
+	Status     ServerStatus `json:"status"`
+	DurationMs int64        `json:"durationMs"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// UptimeStats summarises the checks of one time window.
+type UptimeStats struct {
+	Checks    int     `json:"checks"`
+	Healthy   int     `json:"healthy"`
+	UptimePct float64 `json:"uptimePct"`
+	// AvgLatencyMs averages the duration of the passing checks
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+}
+
+// CheckHistory is the check history of one server with uptime per window.
+type CheckHistory struct {
+	Server  string                 `json:"server"`
+	Windows map[string]UptimeStats `json:"windows"`
+	Checks  []CheckRecord          `json:"checks"`
+}
+
+// HistoryStore persists check results; records older than a week may be
+// dropped.
+type HistoryStore interface {
+	AddCheck(name string, rec CheckRecord) error
+	// ChecksSince returns the records at or after t, oldest first
+	ChecksSince(name string, t time.Time) []CheckRecord
+	RemoveChecks(name string)
+	// RenameChecks moves the records of a renamed server to its new name
+	RenameChecks(oldName, newName string) error
+}
+
+// checkHistory keeps a week of check results per server in memory and as
+// JSON lines under <dir>/<server>.jsonl, so uptime survives restarts.
+type checkHistory struct {
+	mu      sync.Mutex
+	dir     string
+	records map[string][]CheckRecord
+	// expired counts records dropped from memory but still in the file
+	expired map[string]int
+}
+
+func newCheckHistory(dir string) *checkHistory {
+	return &checkHistory{dir: dir, records: make(map[string][]CheckRecord), expired: make(map[string]int)}
+}
+
+func (h *checkHistory) path(name string) string {
+	return filepath.Join(h.dir, strings.TrimSuffix(logFileName(name), ".log")+".jsonl")
+}
+
+// loadLocked reads a server's history from disk the first time it is needed.
+func (h *checkHistory) loadLocked(name string) []CheckRecord {
+	if recs, ok := h.records[name]; ok {
+		return recs
+	}
+	var recs []CheckRecord
+	if f, err := os.Open(h.path(name)); err == nil {
+		cutoff := time.Now().Add(-checkHistoryRetention)
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var rec CheckRecord
+			if json.Unmarshal(sc.Bytes(), &rec) != nil {
+				continue
+			}
+			if rec.Time.Before(cutoff) {
+				h.expired[name]++
+				continue
+			}
+			recs = append(recs, rec)
+		}
+		f.Close()
+	}
+	h.records[name] = recs
+	return recs
+}
+
+// AddCheck records a check result and drops those past the retention.
+func (h *checkHistory) AddCheck(name string, rec CheckRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	recs := append(h.loadLocked(name), rec)
+	cutoff := rec.Time.Add(-checkHistoryRetention)
+	i := 0
+	for i < len(recs) && recs[i].Time.Before(cutoff) {
+		i++
+	}
+	h.expired[name] += i
+	h.records[name] = recs[i:]
+
+	if h.expired[name] >= checkHistoryCompact {
+		return h.rewriteLocked(name)
+	}
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path(name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	line, _ := json.Marshal(rec)
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// rewriteLocked replaces a server's file with the records still kept.
+func (h *checkHistory) rewriteLocked(name string) error {
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, rec := range h.records[name] {
+		line, _ := json.Marshal(rec)
+		sb.Write(line)
+		sb.WriteByte('\n')
+	}
+	tmp := h.path(name) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	h.expired[name] = 0
+	return os.Rename(tmp, h.path(name))
+}
+
+func (h *checkHistory) ChecksSince(name string, t time.Time) []CheckRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	recs := h.loadLocked(name)
+	out := make([]CheckRecord, 0)
+	for _, rec := range recs {
+		if !rec.Time.Before(t) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+func (h *checkHistory) RemoveChecks(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.records, name)
+	delete(h.expired, name)
+	os.Remove(h.path(name))
+}
+
+func (h *checkHistory) RenameChecks(oldName, newName string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if recs, ok := h.records[oldName]; ok {
+		h.records[newName] = recs
+		delete(h.records, oldName)
+	}
+	if n, ok := h.expired[oldName]; ok {
+		h.expired[newName] = n
+		delete(h.expired, oldName)
+	}
+	if err := os.Rename(h.path(oldName), h.path(newName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func uptimeStats(recs []CheckRecord) UptimeStats {
+	var st UptimeStats
+	var latency int64
+	for _, rec := range recs {
+		st.Checks++
+		if rec.Status == StatusHealthy {
+			st.Healthy++
+			latency += rec.DurationMs
+		}
+	}
+	if st.Checks > 0 {
+		st.UptimePct = float64(st.Healthy) * 100 / float64(st.Checks)
+	}
+	if st.Healthy > 0 {
+		st.AvgLatencyMs = float64(latency) / float64(st.Healthy)
+	}
+	return st
+}
+
+// CheckHistory returns a server's check results since the given time (at
+// most limit of the newest, <= 0 for all kept) and its uptime over the last
+// 24 hours and 7 days.
+func (m *Manager) CheckHistory(name string, since time.Time, limit int) CheckHistory {
+	now := time.Now()
+	week := m.history.ChecksSince(name, now.Add(-checkHistoryRetention))
+	var day []CheckRecord
+	for i, rec := range week {
+		if !rec.Time.Before(now.Add(-24 * time.Hour)) {
+			day = week[i:]
+			break
+		}
+	}
+	hist := CheckHistory{
+		Server: name,
+		Windows: map[string]UptimeStats{
+			"24h": uptimeStats(day),
+			"7d":  uptimeStats(week),
+		},
+		Checks: make([]CheckRecord, 0),
+	}
+	for _, rec := range week {
+		if !rec.Time.Before(since) {
+			hist.Checks = append(hist.Checks, rec)
+		}
+	}
+	if limit > 0 && len(hist.Checks) > limit {
+		hist.Checks = hist.Checks[len(hist.Checks)-limit:]
+	}
+	return hist
+}
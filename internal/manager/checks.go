@@ -0,0 +1,216 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+type CheckState string
+
+const (
+	CheckPending CheckState = "pending"
+	CheckRunning CheckState = "running"
+)
+
+var errCheckCancelled = errors.New("check cancelled")
+
+// manualCheckDebounce is how long a finished manual check answers repeated
+// triggers for the same server instead of spawning it again
+const manualCheckDebounce = 5 * time.Second
+
+// manualCheck records the last manually triggered check of a server
+type manualCheck struct {
+	at         time.Time
+	finishedAt time.Time
+	err        error
+	// config is the server config that was checked; an edit is never debounced
+	config string
+}
+
+// CheckJob is a queued or running health check
+type CheckJob struct {
+	ID        string     `json:"id"`
+	Server    string     `json:"server"`
+	State     CheckState `json:"state"`
+	QueuedAt  time.Time  `json:"queuedAt"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+
+	cancel    context.CancelFunc
+	cancelled bool
+	done      chan struct{}
+	err       error
+}
+
+func (m *Manager) newJobLocked(name string) *CheckJob {
+	m.jobSeq++
+	job := &CheckJob{
+		ID:       fmt.Sprintf("chk-%d", m.jobSeq),
+		Server:   name,
+		State:    CheckPending,
+		QueuedAt: time.Now(),
+		done:     make(chan struct{}),
+	}
+	m.jobs[job.ID] = job
+	return job
+}
+
+// checkLock returns the mutex that keeps checks of one server from ever
+// overlapping, even when a cancelled check is still shutting down.
+func (m *Manager) checkLock(name string) *sync.Mutex {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	lock, ok := m.checkLocks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.checkLocks[name] = lock
+	}
+	return lock
+}
+
+// runJob executes a queued check unless it was cancelled while pending.
+func (m *Manager) runJob(job *CheckJob) error {
+	lock := m.checkLock(job.Server)
+	lock.Lock()
+	defer lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	m.jobsMu.Lock()
+	if job.cancelled {
+		delete(m.jobs, job.ID)
+		job.err = errCheckCancelled
+		close(job.done)
+		m.jobsMu.Unlock()
+		return errCheckCancelled
+	}
+	now := time.Now()
+	job.State = CheckRunning
+	job.StartedAt = &now
+	job.cancel = cancel
+	m.jobsMu.Unlock()
+
+	err := m.check(ctx, job.Server)
+	m.jobsMu.Lock()
+	delete(m.jobs, job.ID)
+	job.err = err
+	close(job.done)
+	m.jobsMu.Unlock()
+	return err
+}
+
+// activeJobLocked returns the pending or running check of a server; jobsMu
+// must be held.
+func (m *Manager) activeJobLocked(name string) *CheckJob {
+	for _, job := range m.jobs {
+		if job.Server == name && !job.cancelled {
+			return job
+		}
+	}
+	return nil
+}
+
+// manualJob returns the check a manual trigger of name should use. A check
+// already in flight is shared (owner false); otherwise a new one is queued
+// for the caller to run. When the same config was checked manually moments
+// ago, job is nil and last holds its result.
+func (m *Manager) manualJob(name string, srv *config.MCPServer) (job *CheckJob, owner bool, last *manualCheck) {
+	fingerprint, _ := json.Marshal(srv)
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	if job := m.activeJobLocked(name); job != nil {
+		return job, false, nil
+	}
+	if prev, ok := m.manual[name]; ok && prev.config == string(fingerprint) &&
+		!prev.finishedAt.IsZero() && time.Since(prev.finishedAt) < manualCheckDebounce {
+		return nil, false, prev
+	}
+	m.manual[name] = &manualCheck{at: time.Now(), config: string(fingerprint)}
+	return m.newJobLocked(name), true, nil
+}
+
+// sweepJob queues a check for a sweep, or returns nil when the server
+// already has a check in flight or was checked manually after since.
+func (m *Manager) sweepJob(name string, since time.Time) *CheckJob {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	if m.activeJobLocked(name) != nil {
+		return nil
+	}
+	if last, ok := m.manual[name]; ok && last.at.After(since) {
+		return nil
+	}
+	return m.newJobLocked(name)
+}
+
+// ActiveCheck returns the pending or running check of a server, if any.
+func (m *Manager) ActiveCheck(name string) (CheckJob, bool) {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	job := m.activeJobLocked(name)
+	if job == nil {
+		return CheckJob{}, false
+	}
+	cp := *job
+	cp.cancel = nil
+	return cp, true
+}
+
+// Checks lists pending and running checks, oldest first.
+func (m *Manager) Checks() []CheckJob {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	result := make([]CheckJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		cp := *job
+		cp.cancel = nil
+		result = append(result, cp)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].QueuedAt.Before(result[j].QueuedAt)
+	})
+	return result
+}
+
+// CancelCheck cancels a pending or running check by ID.
+func (m *Manager) CancelCheck(id string) error {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return fmt.Errorf("check %q not found", id)
+	}
+	job.cancelLocked()
+	return nil
+}
+
+// CancelServerCheck cancels the pending or running check of a server and
+// returns it; ok is false when none is in flight.
+func (m *Manager) CancelServerCheck(name string) (CheckJob, bool) {
+	m.jobsMu.Lock()
+	defer m.jobsMu.Unlock()
+	job := m.activeJobLocked(name)
+	if job == nil {
+		return CheckJob{}, false
+	}
+	job.cancelLocked()
+	cp := *job
+	cp.cancel = nil
+	return cp, true
+}
+
+// cancelLocked stops a running check, or keeps a pending one from starting;
+// jobsMu must be held.
+func (job *CheckJob) cancelLocked() {
+	job.cancelled = true
+	if job.cancel != nil {
+		job.cancel()
+	}
+}
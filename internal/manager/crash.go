@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+const (
+	crashStderrLines = 50
+	maxCrashReports  = 10
+	// crashGrace is how long a stdio server that stopped talking may take to exit
+	crashGrace = 2 * time.Second
+)
+
+// CrashReport describes a stdio server process that exited on its own with
+// a failure during a check or a proxied call.
+type CrashReport struct {
+	Time     time.Time `json:"time"`
+	Phase    string    `json:"phase"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args,omitempty"`
+	ExitCode int       `json:"exitCode"`
+	Signal   string    `json:"signal,omitempty"`
+	Stderr   []string  `json:"stderr,omitempty"`
+}
+
+// StderrTail keeps the last stderr lines of a process for crash reports.
+type StderrTail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (t *StderrTail) Add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > crashStderrLines {
+		t.lines = t.lines[len(t.lines)-crashStderrLines:]
+	}
+}
+
+func (t *StderrTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.lines...)
+}
+
+// NewCrashReport builds a report for a finished process, or returns nil if
+// it exited successfully.
+func NewCrashReport(phase string, srv *config.MCPServer, state *os.ProcessState, stderr []string) *CrashReport {
+	if state == nil || state.Success() {
+		return nil
+	}
+	report := &CrashReport{
+		Time:     time.Now(),
+		Phase:    phase,
+		Command:  srv.Command,
+		Args:     srv.Args,
+		ExitCode: state.ExitCode(),
+		Stderr:   stderr,
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		report.Signal = ws.Signal().String()
+	}
+	return report
+}
+
+// ReapExited waits up to crashGrace for a process whose stdio stopped
+// responding to exit by itself, then kills it if needed. It returns the
+// crash report when the process exited on its own with a failure.
+func ReapExited(phase string, srv *config.MCPServer, cmd *exec.Cmd, cancel context.CancelFunc, stderr *StderrTail, stderrDone <-chan struct{}) *CrashReport {
+	deadline := time.After(crashGrace)
+	exited := true
+	select {
+	case <-stderrDone:
+	case <-deadline:
+		exited = false
+	}
+	waited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(waited)
+	}()
+	if exited {
+		select {
+		case <-waited:
+		case <-deadline:
+			exited = false
+		}
+	}
+	if !exited {
+		cancel()
+		<-waited
+	}
+	<-stderrDone
+	if !exited {
+		return nil
+	}
+	return NewCrashReport(phase, srv, cmd.ProcessState, stderr.Lines())
+}
+
+// RecordCrash attaches a crash report to the server and keeps it in history.
+func (m *Manager) RecordCrash(name string, report *CrashReport) {
+	info := m.getOrCreateInfo(name)
+	if info == nil {
+		return
+	}
+	m.mu.Lock()
+	info.LastCrash = report
+	m.crashes[name] = append(m.crashes[name], report)
+	if len(m.crashes[name]) > maxCrashReports {
+		m.crashes[name] = m.crashes[name][len(m.crashes[name])-maxCrashReports:]
+	}
+	m.mu.Unlock()
+
+	msg := i18n.T("Process crashed during %s: exit code %d", report.Phase, report.ExitCode)
+	if report.Signal != "" {
+		msg = i18n.T("Process crashed during %s: exit code %d, signal %s", report.Phase, report.ExitCode, report.Signal)
+	}
+	m.addLog(info, "error", msg)
+	m.notify(name, info)
+}
+
+// Crashes returns the recent crash reports of a server, newest last.
+func (m *Manager) Crashes(name string) []*CrashReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]*CrashReport{}, m.crashes[name]...)
+}
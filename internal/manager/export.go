@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// ExportFormat is a client config the catalog can be exported as.
+type ExportFormat struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Filename    string `json:"filename"`
+}
+
+// ExportFormats lists the client formats of ExportServers; the catalog's
+// own format is exported by the config store.
+var ExportFormats = []ExportFormat{
+	{"claude-desktop", "Claude Desktop", "claude_desktop_config.json"},
+	{"vscode", "VS Code", "mcp.json"},
+	{"mcp-json", "Project .mcp.json", ".mcp.json"},
+	{"codex", "Codex", "config.toml"},
+	{"opencode", "OpenCode", "opencode.json"},
+}
+
+// FindExportFormat returns the client format called name.
+func FindExportFormat(name string) (ExportFormat, bool) {
+	for _, f := range ExportFormats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return ExportFormat{}, false
+}
+
+// ExportServers renders the enabled servers as a ready-to-use config of a
+// client format. Unlike ApplyToTool it starts from an empty file and leaves
+// out the markers of applied entries.
+func (m *Manager) ExportServers(format string) ([]byte, error) {
+	servers := m.store.Get().MCPServers
+	var doc map[string]any
+	switch format {
+	case "claude-desktop":
+		// Claude Desktop launches local servers only
+		entries := enabledServersClean(servers, "")
+		for name, entry := range entries {
+			if _, ok := entry.(map[string]any)["command"]; !ok {
+				delete(entries, name)
+			}
+		}
+		doc = map[string]any{"mcpServers": entries}
+	case "vscode":
+		doc = map[string]any{"servers": vscodeEntries(servers)}
+	case "mcp-json":
+		doc = map[string]any{"mcpServers": enabledServersClean(servers, "")}
+	case "opencode":
+		doc = map[string]any{
+			"$schema": "https://opencode.ai/config.json",
+			"mcp":     openCodeEntries(servers),
+		}
+	case "codex":
+		var sb strings.Builder
+		writeCodexSections(&sb, codexServers(servers), servers, "")
+		out := strings.TrimRight(sb.String(), "\n")
+		if out == "" {
+			return nil, nil
+		}
+		return []byte(out + "\n"), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// vscodeEntries returns the "servers" entries of a VS Code mcp.json, which
+// names the transport of every server
+func vscodeEntries(servers map[string]*config.MCPServer) map[string]any {
+	entries := make(map[string]any)
+	for name, srv := range servers {
+		if !srv.Enabled || srv.IsNATS() {
+			continue
+		}
+		entry := make(map[string]any)
+		switch {
+		case isStreamableHTTPServer(srv) && srv.URL != "":
+			entry["type"] = "http"
+			entry["url"] = srv.URL
+		case srv.Command != "":
+			entry["type"] = "stdio"
+			entry["command"] = srv.Command
+			if len(srv.Args) > 0 {
+				entry["args"] = srv.Args
+			}
+			if env := srv.ProcessEnv(); len(env) > 0 {
+				entry["env"] = env
+			}
+		default:
+			continue
+		}
+		entries[name] = entry
+	}
+	return entries
+}
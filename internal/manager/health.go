@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"sort"
+	"time"
+)
+
+// HealthStatus describes the periodic health loop, so it is visible why a
+// server has not been checked recently.
+type HealthStatus struct {
+	Running             bool                `json:"running"`
+	Interval            int                 `json:"interval"`
+	Sweeping            bool                `json:"sweeping"`
+	LastSweepAt         *time.Time          `json:"lastSweepAt,omitempty"`
+	LastSweepDurationMs int64               `json:"lastSweepDurationMs,omitempty"`
+	NextRunAt           *time.Time          `json:"nextRunAt,omitempty"`
+	Servers             []ServerHealthStats `json:"servers"`
+}
+
+// ServerHealthStats is the schedule of one server within the health loop.
+type ServerHealthStats struct {
+	Name        string     `json:"name"`
+	LastCheck   *time.Time `json:"lastCheck,omitempty"`
+	NextCheckAt *time.Time `json:"nextCheckAt,omitempty"`
+	// Schedule is "every Ns" or "cron <expr>", empty when not checked periodically
+	Schedule string `json:"schedule,omitempty"`
+	// Reason explains a missing or postponed next check
+	Reason string `json:"reason,omitempty"`
+}
+
+// HealthStatus reports the health loop state and estimates when each server
+// is checked next.
+func (m *Manager) HealthStatus() HealthStatus {
+	m.healthMu.RLock()
+	status := HealthStatus{
+		Running:  m.healthRunning,
+		Interval: m.healthInterval,
+	}
+	nextRun := m.healthNextRun
+	scheduled := make(map[string]scheduledServer, len(m.schedule))
+	for name, st := range m.schedule {
+		scheduled[name] = *st
+	}
+	m.healthMu.RUnlock()
+	if status.Running && !nextRun.IsZero() {
+		status.NextRunAt = &nextRun
+	}
+
+	m.jobsMu.Lock()
+	lastSweep := m.lastSweep
+	status.Sweeping = m.sweeping > 0
+	if !lastSweep.IsZero() {
+		status.LastSweepAt = &lastSweep
+		if m.sweeping == 0 {
+			status.LastSweepDurationMs = m.sweepDuration.Milliseconds()
+		}
+	}
+	active := make(map[string]bool)
+	manual := make(map[string]time.Time)
+	for _, job := range m.jobs {
+		if !job.cancelled {
+			active[job.Server] = true
+		}
+	}
+	for name, last := range m.manual {
+		manual[name] = last.at
+	}
+	m.jobsMu.Unlock()
+
+	cfg := m.store.Get()
+	status.Servers = make([]ServerHealthStats, 0, len(cfg.MCPServers))
+	for name, srv := range cfg.MCPServers {
+		entry := ServerHealthStats{Name: name}
+		if info, ok := m.GetInfo(name); ok {
+			entry.LastCheck = info.LastCheck
+		}
+		if sched, ok := serverSchedule(srv, status.Interval); ok {
+			entry.Schedule = sched.key
+		}
+		st, ok := scheduled[name]
+		switch {
+		case !srv.Enabled:
+			entry.Reason = "server disabled"
+		case entry.Schedule == "":
+			entry.Reason = "health checks disabled"
+		case !status.Running:
+			entry.Reason = "health loop not running"
+		case active[name]:
+			entry.Reason = "check in progress"
+		case !ok || st.sched.key != entry.Schedule:
+			entry.Reason = "schedule not picked up yet"
+		case st.next.IsZero():
+			entry.Reason = "cron expression never matches"
+		case manual[name].After(st.prev):
+			// A sweep skips a server checked manually since it was last due
+			next := st.sched.after(st.next)
+			entry.NextCheckAt = &next
+			entry.Reason = "checked manually, skipped in next sweep"
+		default:
+			next := st.next
+			entry.NextCheckAt = &next
+		}
+		status.Servers = append(status.Servers, entry)
+	}
+	sort.Slice(status.Servers, func(i, j int) bool {
+		return status.Servers[i].Name < status.Servers[j].Name
+	})
+	return status
+}
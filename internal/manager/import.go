@@ -0,0 +1,233 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// Import actions of an ImportChange
+const (
+	ImportAdd       = "add"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+	// ImportSkip is a server that differs from the catalog's and is kept as
+	// is under the skip strategy
+	ImportSkip = "skip"
+	// ImportRename is a differing server added under another name (As)
+	ImportRename = "rename"
+)
+
+// Strategies for imported servers that differ from the catalog's
+const (
+	StrategySkip      = "skip"
+	StrategyOverwrite = "overwrite"
+	StrategyRename    = "rename"
+)
+
+// ImportOptions selects what an import takes and how it merges.
+type ImportOptions struct {
+	// Strategy for servers the catalog has with other settings (default skip)
+	Strategy string
+	// Servers limits the import to these names (empty = all)
+	Servers []string
+	// Merge keeps the catalog-only settings (checks, limits, disabled
+	// tools...) of overwritten servers, for sources that only know how to
+	// launch a server, like Claude Desktop
+	Merge bool
+}
+
+// ImportChange is what an import does to one server.
+type ImportChange struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// As is the name a renamed server is added under
+	As string `json:"as,omitempty"`
+	// Fields lists what differs from the catalog, env as env.KEY (values
+	// are not shown, they often hold secrets)
+	Fields   []string          `json:"fields,omitempty"`
+	Current  *config.MCPServer `json:"current,omitempty"`
+	Proposed *config.MCPServer `json:"proposed,omitempty"`
+}
+
+// ImportPlan is the outcome of an import, or its preview in a dry run.
+type ImportPlan struct {
+	Source   string         `json:"source"`
+	Strategy string         `json:"strategy"`
+	DryRun   bool           `json:"dryRun"`
+	Changes  []ImportChange `json:"changes"`
+}
+
+// Imported returns the catalog name of every server the plan adds or
+// changes.
+func (p *ImportPlan) Imported() []string {
+	var names []string
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ImportAdd, ImportUpdate:
+			names = append(names, c.Name)
+		case ImportRename:
+			names = append(names, c.As)
+		}
+	}
+	return names
+}
+
+// ClaudeDesktopConfigPath returns where Claude Desktop keeps its config:
+// ~/Library/Application Support/Claude on macOS, %APPDATA%\Claude on
+// Windows and ~/.config/Claude on Linux.
+func ClaudeDesktopConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "Claude", "claude_desktop_config.json")
+}
+
+// ReadClaudeDesktop reads the mcpServers of a claude_desktop_config.json;
+// an empty path means the platform's default location.
+func ReadClaudeDesktop(path string) (string, map[string]*config.MCPServer, error) {
+	if path == "" {
+		path = ClaudeDesktopConfigPath()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path, nil, err
+	}
+	servers, err := parseJSONMcpServers(data)
+	if err != nil {
+		return path, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return path, servers, nil
+}
+
+// Validate checks the strategy and that the selected servers are in
+// incoming.
+func (o ImportOptions) Validate(incoming map[string]*config.MCPServer) error {
+	switch o.Strategy {
+	case "", StrategySkip, StrategyOverwrite, StrategyRename:
+	default:
+		return i18n.Errorf("unknown import strategy %q (want skip, overwrite or rename)", o.Strategy)
+	}
+	for _, name := range o.Servers {
+		if _, ok := incoming[name]; !ok {
+			return i18n.Errorf("server %q is not in the import", name)
+		}
+	}
+	return nil
+}
+
+// PlanImport compares imported servers with the catalog's and decides
+// what happens to each under opts.
+func PlanImport(current, incoming map[string]*config.MCPServer, opts ImportOptions) ([]ImportChange, error) {
+	if err := opts.Validate(incoming); err != nil {
+		return nil, err
+	}
+	names := sortedKeys(incoming)
+	if len(opts.Servers) > 0 {
+		names = append([]string(nil), opts.Servers...)
+		sort.Strings(names)
+	}
+	// taken are the names in use after the import, for renames
+	taken := make(map[string]bool, len(current)+len(names))
+	for name := range current {
+		taken[name] = true
+	}
+	for _, name := range names {
+		taken[name] = true
+	}
+	changes := make([]ImportChange, 0, len(names))
+	for _, name := range names {
+		in := incoming[name]
+		cur, ok := current[name]
+		if !ok {
+			changes = append(changes, ImportChange{Name: name, Action: ImportAdd, Proposed: in})
+			continue
+		}
+		proposed := in
+		if opts.Merge {
+			proposed = mergeImported(cur, in)
+		}
+		fields, err := config.DiffServer(cur, proposed)
+		if err != nil {
+			return nil, err
+		}
+		change := ImportChange{Name: name, Current: cur, Proposed: proposed}
+		for _, f := range fields {
+			change.Fields = append(change.Fields, f.Field)
+		}
+		switch {
+		case len(fields) == 0:
+			change.Action = ImportUnchanged
+			change.Proposed = nil
+		case opts.Strategy == StrategyOverwrite:
+			change.Action = ImportUpdate
+		case opts.Strategy == StrategyRename:
+			change.Action = ImportRename
+			change.Proposed = in
+			change.As = freeName(name, taken)
+			taken[change.As] = true
+		default:
+			change.Action = ImportSkip
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// freeName returns the first of name-2, name-3... that is not taken.
+func freeName(name string, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		if candidate := fmt.Sprintf("%s-%d", name, i); !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// mergeImported returns cur with the launch settings of in.
+func mergeImported(cur, in *config.MCPServer) *config.MCPServer {
+	merged := *cur
+	merged.Command, merged.Args, merged.URL, merged.Env = in.Command, in.Args, in.URL, in.Env
+	if in.Type != "" {
+		merged.Type = in.Type
+	}
+	return &merged
+}
+
+// ImportServers merges servers read from source into the catalog: new ones
+// are added, differing ones handled by opts.Strategy. A dry run only
+// returns the plan.
+func (m *Manager) ImportServers(source string, incoming map[string]*config.MCPServer, opts ImportOptions, dryRun bool) (*ImportPlan, error) {
+	changes, err := PlanImport(m.store.Get().MCPServers, incoming, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ImportPlan{Source: source, Strategy: opts.Strategy, DryRun: dryRun, Changes: changes}
+	if plan.Strategy == "" {
+		plan.Strategy = StrategySkip
+	}
+	if dryRun {
+		return plan, nil
+	}
+	apply := make(map[string]*config.MCPServer)
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case ImportAdd, ImportUpdate:
+			apply[c.Name] = c.Proposed
+		case ImportRename:
+			apply[c.As] = c.Proposed
+		}
+	}
+	if len(apply) == 0 {
+		return plan, nil
+	}
+	if err := m.store.MergeServers(apply); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
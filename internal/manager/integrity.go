@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// ErrIntegrityMismatch means a pinned server package changed on disk
+var ErrIntegrityMismatch = errors.New("integrity mismatch")
+
+// ComputeIntegrity hashes what a stdio server will execute: the whole vendored
+// directory for vendored servers, otherwise the resolved command binary.
+// Unvendored npx/uvx servers resolve their package at run time and scripts
+// run by a shared interpreter say nothing about the server, so ok is false
+// for them and for remote servers.
+func ComputeIntegrity(srv *config.MCPServer) (hash string, ok bool, err error) {
+	if srv.Vendored != nil {
+		h, err := hashTree(srv.Vendored.Dir)
+		return h, err == nil, err
+	}
+	if isStreamableHTTPServer(srv) || srv.Command == "" || launcherKind(srv.Command) != "" || isInterpreter(srv.Command) {
+		return "", false, nil
+	}
+	path, err := exec.LookPath(srv.Command)
+	if err != nil {
+		return "", false, err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", false, err
+	}
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil)), true, nil
+}
+
+// isInterpreter reports runtimes whose binary is shared by many servers
+func isInterpreter(command string) bool {
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(command)), ".exe")
+	switch base {
+	case "node", "python", "python3", "deno", "bun", "docker", "podman", "java", "sh", "bash", "uv", "npm", "pipx":
+		return true
+	}
+	return false
+}
+
+// hashTree hashes file names, contents and symlink targets under dir in a
+// stable order. Python bytecode caches are skipped since they are rewritten
+// on import.
+func hashTree(dir string) (string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "__pycache__" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && !strings.HasSuffix(path, ".pyc") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	sum := sha256.New()
+	for _, path := range paths {
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(sum, "%s\x00", filepath.ToSlash(rel))
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(sum, "->%s\x00", target)
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(sum, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		sum.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// VerifyIntegrity refuses to run a pinned server whose package changed.
+func VerifyIntegrity(srv *config.MCPServer) error {
+	if srv.Integrity == "" {
+		return nil
+	}
+	hash, ok, err := ComputeIntegrity(srv)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIntegrityMismatch, err)
+	}
+	if ok && hash != srv.Integrity {
+		return i18n.Errorf("%w: expected %s, got %s (run `mcp-manager trust` to accept the change)", ErrIntegrityMismatch, srv.Integrity, hash)
+	}
+	return nil
+}
+
+// Trust pins the server's current package hash, accepting any change.
+func (m *Manager) Trust(name string) (string, error) {
+	srv, ok := m.store.GetServer(name)
+	if !ok {
+		return "", fmt.Errorf("server %q not found", name)
+	}
+	hash, ok, err := ComputeIntegrity(srv)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("server %q cannot be pinned; vendor it first", name)
+	}
+	return hash, m.store.SetIntegrity(name, hash)
+}
+
+// verifyOrPin checks a pinned server before start and pins it on first run.
+func (m *Manager) verifyOrPin(name string, srv *config.MCPServer, info *ServerInfo) error {
+	if srv.Integrity != "" {
+		if err := VerifyIntegrity(srv); err != nil {
+			m.addLog(info, "error", err.Error())
+			return err
+		}
+		return nil
+	}
+	hash, ok, err := ComputeIntegrity(srv)
+	if err != nil || !ok {
+		return nil
+	}
+	if err := m.store.SetIntegrity(name, hash); err != nil {
+		m.addLog(info, "warn", i18n.T("Failed to record integrity: %v", err))
+		return nil
+	}
+	m.addLog(info, "info", i18n.T("Pinned package integrity %s", hash))
+	return nil
+}
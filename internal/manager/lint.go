@@ -0,0 +1,193 @@
+package manager
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// Lint rule identifiers
+const (
+	LintUnreachable   = "unreachable-command"
+	LintDuplicateTool = "duplicate-tool"
+	LintDuplicate     = "duplicate-server"
+	LintUnpinned      = "unpinned-version"
+	LintSecret        = "plaintext-secret"
+	LintNeverChecked  = "never-checked"
+	LintTemplateParam = "template-param"
+	LintCheckStrategy = "check-strategy"
+)
+
+// LintIssue is one problem found in the catalog
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	Server  string `json:"server"`
+	Message string `json:"message"`
+}
+
+var (
+	secretKeyRe = regexp.MustCompile(`(?i)(token|secret|passw(or)?d|api[_-]?key|private[_-]?key|credential|auth)`)
+	// secretValueRe matches well-known credential formats regardless of key name
+	secretValueRe = regexp.MustCompile(`\b(?:(?:AKIA|ASIA)[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abposr]-[A-Za-z0-9-]{10,}|sk-[A-Za-z0-9_-]{20,}|glpat-[A-Za-z0-9_-]{20,})\b`)
+	// envRefRe matches values that point at the environment instead of holding a secret
+	envRefRe = regexp.MustCompile(`^\$(\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)$`)
+)
+
+// Lint checks the catalog for common problems. infos supplies check results
+// and tool lists; when nil, rules that need them are skipped.
+func Lint(servers map[string]*config.MCPServer, infos map[string]*ServerInfo) []LintIssue {
+	var issues []LintIssue
+	add := func(rule, server, format string, args ...any) {
+		issues = append(issues, LintIssue{Rule: rule, Server: server, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]string)
+	for _, name := range sortedKeys(servers) {
+		srv := servers[name]
+
+		if srv.IsNATS() {
+			if u, err := url.Parse(srv.URL); err != nil || u.Host == "" {
+				add(LintUnreachable, name, "invalid NATS URL %q", srv.URL)
+			} else if srv.Subject == "" {
+				add(LintUnreachable, name, "NATS server has no subject")
+			}
+		} else if isStreamableHTTPServer(srv) {
+			if u, err := url.Parse(srv.URL); err != nil || u.Host == "" {
+				add(LintUnreachable, name, "invalid URL %q", srv.URL)
+			}
+		} else if srv.Command == "" {
+			add(LintUnreachable, name, "no command or URL configured")
+		} else if srv.IsExecTransport() && srv.URL == "" {
+			add(LintUnreachable, name, "%s server has no URL for its helper", config.ExecTransport)
+		} else if _, err := exec.LookPath(srv.Command); err != nil {
+			add(LintUnreachable, name, "command %q not found in PATH", srv.Command)
+		}
+
+		key := srv.URL
+		if srv.IsNATS() {
+			key += "\x00" + srv.Subject
+		}
+		if srv.Command != "" {
+			key = srv.Command + "\x00" + strings.Join(srv.Args, "\x00")
+			if srv.IsExecTransport() {
+				key += "\x00" + srv.URL
+			}
+		}
+		if other, ok := seen[key]; ok {
+			add(LintDuplicate, name, "same command as %q", other)
+		} else {
+			seen[key] = name
+		}
+
+		switch strings.ToLower(strings.TrimSpace(srv.CheckStrategy)) {
+		case "", config.CheckPing, config.CheckInitialize, config.CheckTools:
+		case config.CheckDeep:
+			if srv.HealthProbe == nil {
+				add(LintCheckStrategy, name, "deep check without a healthProbe runs as %s", config.CheckTools)
+			}
+		default:
+			add(LintCheckStrategy, name, "unknown check strategy %q", srv.CheckStrategy)
+		}
+
+		for _, problem := range srv.ParamProblems() {
+			add(LintTemplateParam, name, "%s", problem)
+		}
+
+		if pkg, ok := unpinnedPackage(srv); ok {
+			add(LintUnpinned, name, "package %q has no pinned version", pkg)
+		}
+
+		for _, k := range sortedKeys(srv.Env) {
+			if looksLikeSecret(k, srv.Env[k]) {
+				add(LintSecret, name, "env %s holds a plaintext secret", k)
+			}
+		}
+		for i, arg := range srv.Args {
+			if secretValueRe.MatchString(arg) {
+				add(LintSecret, name, "argument %d contains a credential", i+1)
+			}
+		}
+		if u, err := url.Parse(srv.URL); err == nil && u.User != nil {
+			if _, hasPass := u.User.Password(); hasPass {
+				add(LintSecret, name, "URL embeds a password")
+			}
+		}
+	}
+
+	if infos == nil {
+		return issues
+	}
+
+	providers := make(map[string][]string)
+	for _, name := range sortedKeys(servers) {
+		srv := servers[name]
+		info := infos[name]
+		if !srv.Enabled {
+			continue
+		}
+		if info == nil || info.Status != StatusHealthy {
+			msg := "enabled but never checked successfully"
+			if info != nil && info.Error != "" {
+				msg += ": " + info.Error
+			}
+			add(LintNeverChecked, name, "%s", msg)
+			continue
+		}
+		for _, t := range info.Tools {
+			providers[t.Name] = append(providers[t.Name], name)
+		}
+	}
+	for _, tool := range sortedKeys(providers) {
+		names := providers[tool]
+		if len(names) > 1 {
+			add(LintDuplicateTool, names[0], "tool %q is also provided by %s", tool, strings.Join(names[1:], ", "))
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Server < issues[j].Server })
+	return issues
+}
+
+// unpinnedPackage reports the npx/uvx package of a server when it floats
+// to whatever version is latest.
+func unpinnedPackage(srv *config.MCPServer) (string, bool) {
+	switch launcherKind(srv.Command) {
+	case "npx":
+		pkg, _, _ := launcherPackage(srv.Args, npxPackageFlags, nil)
+		if pkg == "" {
+			return "", false
+		}
+		// The version separator is the last '@' that is not the scope prefix
+		at := strings.LastIndex(pkg, "@")
+		if at <= 0 {
+			return pkg, true
+		}
+		version := pkg[at+1:]
+		return pkg, version == "" || version == "latest" || version == "next"
+	case "uvx":
+		pkg, _, _ := launcherPackage(srv.Args, uvxPackageFlags, uvxValueFlags)
+		if pkg == "" {
+			return "", false
+		}
+		if _, version, ok := strings.Cut(pkg, "@"); ok {
+			return pkg, version == "" || version == "latest"
+		}
+		return pkg, !strings.Contains(pkg, "==")
+	}
+	return "", false
+}
+
+func looksLikeSecret(key, value string) bool {
+	if value == "" || envRefRe.MatchString(value) {
+		return false
+	}
+	if secretValueRe.MatchString(value) {
+		return true
+	}
+	return secretKeyRe.MatchString(key) && len(value) >= 8
+}
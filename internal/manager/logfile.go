@@ -0,0 +1,230 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxLogFileSize  = 5 << 20
+	maxLogFileCount = 3
+)
+
+// LogStore persists the log entries of servers across restarts.
+type LogStore interface {
+	AppendLog(name string, entry LogEntry) error
+	// ReadLogs returns up to limit most recent entries at or after since,
+	// oldest first; limit <= 0 returns all kept
+	ReadLogs(name string, since time.Time, limit int) ([]LogEntry, error)
+	RemoveLogs(name string)
+	// RenameLogs moves the entries of a renamed server to its new name
+	RenameLogs(oldName, newName string) error
+}
+
+// logFiles persists server log entries as JSON lines under <dir>/<server>.log,
+// rotating to .log.1 ... .log.N once a file exceeds maxLogFileSize.
+type logFiles struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]*os.File
+}
+
+func newLogFiles(dir string) *logFiles {
+	return &logFiles{dir: dir, files: make(map[string]*os.File)}
+}
+
+// logFileName maps a server name to a safe file name.
+func logFileName(name string) string {
+	r := strings.NewReplacer("/", "_", "\\", "_", "..", "_")
+	return r.Replace(name) + ".log"
+}
+
+func (l *logFiles) path(name string) string {
+	return filepath.Join(l.dir, logFileName(name))
+}
+
+func (l *logFiles) AppendLog(name string, entry LogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.open(name)
+	if err != nil {
+		return err
+	}
+	if fi, err := f.Stat(); err == nil && fi.Size()+int64(len(line)) > maxLogFileSize {
+		if err := l.rotate(name); err != nil {
+			return err
+		}
+		if f, err = l.open(name); err != nil {
+			return err
+		}
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (l *logFiles) open(name string) (*os.File, error) {
+	if f, ok := l.files[name]; ok {
+		return f, nil
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(l.path(name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l.files[name] = f
+	return f, nil
+}
+
+// rotate shifts name.log -> name.log.1 -> ... dropping the oldest file.
+func (l *logFiles) rotate(name string) error {
+	if f, ok := l.files[name]; ok {
+		f.Close()
+		delete(l.files, name)
+	}
+	base := l.path(name)
+	os.Remove(fmt.Sprintf("%s.%d", base, maxLogFileCount))
+	for i := maxLogFileCount - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", base, i), fmt.Sprintf("%s.%d", base, i+1))
+	}
+	return os.Rename(base, base+".1")
+}
+
+func (l *logFiles) ReadLogs(name string, since time.Time, limit int) ([]LogEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	base := l.path(name)
+	paths := make([]string, 0, maxLogFileCount+1)
+	for i := maxLogFileCount; i >= 1; i-- {
+		paths = append(paths, fmt.Sprintf("%s.%d", base, i))
+	}
+	paths = append(paths, base)
+
+	entries := make([]LogEntry, 0)
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			var e LogEntry
+			if json.Unmarshal(sc.Bytes(), &e) != nil || e.Time.Before(since) {
+				continue
+			}
+			entries = append(entries, e)
+			if limit > 0 && len(entries) > 2*limit {
+				entries = append(entries[:0], entries[len(entries)-limit:]...)
+			}
+		}
+		f.Close()
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// RemoveLogs closes and deletes all log files of a server.
+func (l *logFiles) RemoveLogs(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.files[name]; ok {
+		f.Close()
+		delete(l.files, name)
+	}
+	base := l.path(name)
+	os.Remove(base)
+	for i := 1; i <= maxLogFileCount; i++ {
+		os.Remove(fmt.Sprintf("%s.%d", base, i))
+	}
+}
+
+// RenameLogs closes the log files of a server and moves them, rotated ones
+// included, to the new name.
+func (l *logFiles) RenameLogs(oldName, newName string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.files[oldName]; ok {
+		f.Close()
+		delete(l.files, oldName)
+	}
+	from, to := l.path(oldName), l.path(newName)
+	var firstErr error
+	move := func(from, to string) {
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	move(from, to)
+	for i := 1; i <= maxLogFileCount; i++ {
+		move(fmt.Sprintf("%s.%d", from, i), fmt.Sprintf("%s.%d", to, i))
+	}
+	return firstErr
+}
+
+// History returns persisted log entries of a server, including entries from
+// previous runs. limit <= 0 returns everything kept on disk.
+func (m *Manager) History(name string, since time.Time, limit int) ([]LogEntry, error) {
+	return m.logs.ReadLogs(name, since, limit)
+}
+
+// AppendLog adds an entry to a server's log from outside a check, e.g. the
+// stderr of a process started by the MCP proxy.
+func (m *Manager) AppendLog(name, level, msg string) {
+	if info := m.getOrCreateInfo(name); info != nil {
+		m.addLog(info, level, msg)
+	}
+}
+
+// logSubscriber receives live log entries of one server ("" = all servers)
+type logSubscriber struct {
+	server string
+	ch     chan LogEntry
+}
+
+// SubscribeLogs streams new log entries of a server as they are added.
+// Slow readers miss entries rather than block checks. Call the returned
+// function to unsubscribe.
+func (m *Manager) SubscribeLogs(name string) (<-chan LogEntry, func()) {
+	sub := &logSubscriber{server: name, ch: make(chan LogEntry, 256)}
+	m.logSubsMu.Lock()
+	m.logSubs[sub] = struct{}{}
+	m.logSubsMu.Unlock()
+	return sub.ch, func() {
+		m.logSubsMu.Lock()
+		delete(m.logSubs, sub)
+		m.logSubsMu.Unlock()
+	}
+}
+
+func (m *Manager) publishLog(name string, entry LogEntry) {
+	m.logSubsMu.Lock()
+	defer m.logSubsMu.Unlock()
+	for sub := range m.logSubs {
+		if sub.server != "" && sub.server != name {
+			continue
+		}
+		select {
+		case sub.ch <- entry:
+		default:
+		}
+	}
+}
@@ -5,15 +5,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand"
 	"net/http"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/transport"
 )
 
 type ServerStatus string
@@ -40,17 +47,42 @@ type ServerInfo struct {
 	Tools           []MCPTool        `json:"tools"`
 	Prompts         []MCPPrompt      `json:"prompts"`
 	Resources       []MCPResource    `json:"resources"`
+	Counts          CapabilityCounts `json:"counts"`
 	LastCheck       *time.Time       `json:"lastCheck,omitempty"`
 	ServerName      string           `json:"serverName,omitempty"`
 	ServerVersion   string           `json:"serverVersion,omitempty"`
 	ProtocolVersion string           `json:"protocolVersion,omitempty"`
 	CheckDuration   int64            `json:"checkDuration,omitempty"`
+	Timings         *CheckTimings    `json:"timings,omitempty"`
+	PrefetchedAt    *time.Time       `json:"prefetchedAt,omitempty"`
+	LastCrash       *CrashReport     `json:"lastCrash,omitempty"`
+}
+
+// CapabilityCounts summarises what a server offers, so clients can badge
+// servers without walking the lists
+type CapabilityCounts struct {
+	Tools         int `json:"tools"`
+	DisabledTools int `json:"disabledTools"`
+	Prompts       int `json:"prompts"`
+	Resources     int `json:"resources"`
+}
+
+func countCapabilities(info *ServerInfo) CapabilityCounts {
+	counts := CapabilityCounts{Tools: len(info.Tools), Prompts: len(info.Prompts), Resources: len(info.Resources)}
+	for _, t := range info.Tools {
+		if t.Disabled || !info.Config.ToolEnabled(t.Name) {
+			counts.DisabledTools++
+		}
+	}
+	return counts
 }
 
 type MCPTool struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description,omitempty"`
 	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+	// Disabled is set from the server's disabledTools, not by the server itself
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 type mcpToolsResult struct {
@@ -106,18 +138,46 @@ type Manager struct {
 	store          *config.Store
 	servers        map[string]*ServerInfo
 	mu             sync.RWMutex
+	logs           LogStore
+	history        HistoryStore
+	crashes        map[string][]*CrashReport
+	logSubs        map[*logSubscriber]struct{}
+	logSubsMu      sync.Mutex
+	jobs           map[string]*CheckJob
+	jobSeq         int64
+	jobsMu         sync.Mutex
+	manual         map[string]*manualCheck
+	checkLocks     map[string]*sync.Mutex
+	lastSweep      time.Time
+	sweepDuration  time.Duration
+	sweeping       int
 	listeners      []func(name string, info *ServerInfo)
+	logHooks       []func(name string, entry LogEntry)
 	listMu         sync.RWMutex
 	healthInterval int
 	healthMu       sync.RWMutex
-	stopHealth     chan struct{}
+	healthRunning  bool
+	healthNextRun  time.Time
+	schedule       map[string]*scheduledServer
+	// badCron holds the invalid checkCron already reported per server
+	badCron    map[string]string
+	stopHealth chan struct{}
 }
 
 func New(store *config.Store) *Manager {
 	return &Manager{
 		store:          store,
 		servers:        make(map[string]*ServerInfo),
+		logs:           newLogFiles(filepath.Join(store.Dir(), "logs")),
+		history:        newCheckHistory(filepath.Join(store.Dir(), "history")),
+		crashes:        make(map[string][]*CrashReport),
+		logSubs:        make(map[*logSubscriber]struct{}),
+		jobs:           make(map[string]*CheckJob),
+		manual:         make(map[string]*manualCheck),
+		checkLocks:     make(map[string]*sync.Mutex),
 		healthInterval: store.GetHealthCheckInterval(),
+		schedule:       make(map[string]*scheduledServer),
+		badCron:        make(map[string]string),
 		stopHealth:     make(chan struct{}),
 	}
 }
@@ -140,14 +200,42 @@ func (m *Manager) OnChange(fn func(name string, info *ServerInfo)) {
 	m.listeners = append(m.listeners, fn)
 }
 
+// OnLog registers a hook called synchronously for every server log entry;
+// it must not block.
+func (m *Manager) OnLog(fn func(name string, entry LogEntry)) {
+	m.listMu.Lock()
+	defer m.listMu.Unlock()
+	m.logHooks = append(m.logHooks, fn)
+}
+
+// notify hands listeners a snapshot: info keeps changing while a check runs.
 func (m *Manager) notify(name string, info *ServerInfo) {
+	m.mu.RLock()
+	snap := copyInfo(info)
+	m.mu.RUnlock()
 	m.listMu.RLock()
 	defer m.listMu.RUnlock()
 	for _, fn := range m.listeners {
-		go fn(name, info)
+		go fn(name, snap)
 	}
 }
 
+// setCheckDuration records how long the check started at start has taken.
+func (m *Manager) setCheckDuration(info *ServerInfo, start time.Time) {
+	m.mu.Lock()
+	info.CheckDuration = time.Since(start).Milliseconds()
+	m.mu.Unlock()
+}
+
+// setServerIdentity records what the server reported in initialize.
+func (m *Manager) setServerIdentity(info *ServerInfo, res mcpInitResult) {
+	m.mu.Lock()
+	info.ServerName = res.ServerInfo.Name
+	info.ServerVersion = res.ServerInfo.Version
+	info.ProtocolVersion = res.ProtocolVersion
+	m.mu.Unlock()
+}
+
 func (m *Manager) getOrCreateInfo(name string) *ServerInfo {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -180,18 +268,55 @@ func (m *Manager) addLog(info *ServerInfo, level, msg string) {
 		Level:   level,
 		Message: msg,
 	}
+	slog.Debug(msg, "server", info.Name, "severity", level)
+	if err := m.logs.AppendLog(info.Name, entry); err != nil {
+		slog.Warn("failed to persist server log", "server", info.Name, "err", err)
+	}
+	m.publishLog(info.Name, entry)
+	m.listMu.RLock()
+	for _, fn := range m.logHooks {
+		fn(info.Name, entry)
+	}
+	m.listMu.RUnlock()
+	m.mu.Lock()
 	info.Logs = append(info.Logs, entry)
 	if len(info.Logs) > maxLogEntries {
 		info.Logs = info.Logs[len(info.Logs)-maxLogEntries:]
 	}
+	m.mu.Unlock()
 }
 
 // Check starts the server temporarily, verifies MCP initialize works, discovers tools, then stops it.
+// Concurrent triggers share one check, a repeat within a few seconds of an
+// unchanged server returns the previous result, and the next periodic sweep
+// skips the server.
 func (m *Manager) Check(name string) error {
 	srv, ok := m.store.GetServer(name)
 	if !ok {
 		return fmt.Errorf("server %q not found", name)
 	}
+	job, owner, last := m.manualJob(name, srv)
+	switch {
+	case job == nil:
+		return last.err
+	case !owner:
+		<-job.done
+		return job.err
+	}
+	err := m.runJob(job)
+	m.jobsMu.Lock()
+	if mc, ok := m.manual[name]; ok {
+		mc.finishedAt, mc.err = time.Now(), err
+	}
+	m.jobsMu.Unlock()
+	return err
+}
+
+func (m *Manager) check(ctx context.Context, name string) error {
+	srv, ok := m.store.GetServer(name)
+	if !ok {
+		return fmt.Errorf("server %q not found", name)
+	}
 
 	info := m.getOrCreateInfo(name)
 	if info == nil {
@@ -207,51 +332,85 @@ func (m *Manager) Check(name string) error {
 	target := strings.TrimSpace(strings.Join(append([]string{srv.Command}, srv.Args...), " "))
 	if isStreamableHTTPServer(srv) {
 		target = fmt.Sprintf("streamableHttp %s", srv.URL)
+	} else if srv.IsExecTransport() {
+		target = fmt.Sprintf("%s %s via %s", config.ExecTransport, srv.URL, target)
 	}
 	if target == "" {
 		target = "(invalid config: no command/url)"
 	}
-	m.addLog(info, "info", fmt.Sprintf("Checking: %s", target))
+	m.addLog(info, "info", i18n.T("Checking: %s", target))
 	m.notify(name, info)
 
 	// Run the actual check
-	err := m.doCheck(name, srv, info)
+	err := m.doCheck(ctx, name, srv, info)
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		m.mu.RLock()
+		removed := m.servers[name] != info
+		m.mu.RUnlock()
+		if removed {
+			// Deleted mid-check: nothing left to report to
+			return errCheckCancelled
+		}
+		err = errCheckCancelled
+		m.addLog(info, "warn", i18n.T("Check cancelled"))
+	}
 
 	now := time.Now()
 	m.mu.Lock()
 	info.LastCheck = &now
-	if err != nil {
+	if err == errCheckCancelled {
+		info.Status = StatusError
+		info.Error = i18n.T(err.Error())
+	} else if err != nil {
 		info.Status = StatusError
 		info.Error = err.Error()
 	} else {
 		info.Status = StatusHealthy
 		info.Error = ""
 	}
+	info.Counts = countCapabilities(info)
+	duration := info.CheckDuration
 	m.mu.Unlock()
 	m.notify(name, info)
 
+	if err != errCheckCancelled {
+		rec := CheckRecord{Time: now, Status: StatusHealthy, DurationMs: duration}
+		if err != nil {
+			rec.Status, rec.Error = StatusError, err.Error()
+		}
+		if herr := m.history.AddCheck(name, rec); herr != nil {
+			slog.Warn("record check history", "server", name, "err", herr)
+		}
+	}
 	return err
 }
 
-func (m *Manager) doCheck(name string, srv *config.MCPServer, info *ServerInfo) error {
+func (m *Manager) doCheck(ctx context.Context, name string, srv *config.MCPServer, info *ServerInfo) error {
 	_ = name
+	if srv.IsNATS() {
+		return m.doCheckNATS(ctx, srv, info)
+	}
 	if isStreamableHTTPServer(srv) {
-		return m.doCheckStreamableHTTP(srv, info)
+		return m.doCheckStreamableHTTP(ctx, srv, info)
 	}
 	if srv.Command == "" {
-		err := fmt.Errorf("missing command for stdio server")
+		err := i18n.Errorf("missing command for stdio server")
 		m.addLog(info, "error", err.Error())
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	if err := m.verifyOrPin(name, srv, info); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, srv.Command, srv.Args...)
 
-	if len(srv.Env) > 0 {
+	if procEnv := srv.ProcessEnv(); len(procEnv) > 0 {
 		env := cmd.Environ()
-		for k, v := range srv.Env {
+		for k, v := range procEnv {
 			env = append(env, fmt.Sprintf("%s=%s", k, v))
 		}
 		cmd.Env = env
@@ -259,145 +418,222 @@ func (m *Manager) doCheck(name string, srv *config.MCPServer, info *ServerInfo)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		m.addLog(info, "error", fmt.Sprintf("stdin pipe: %v", err))
+		m.addLog(info, "error", i18n.T("stdin pipe: %v", err))
 		return fmt.Errorf("stdin pipe: %w", err)
 	}
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		m.addLog(info, "error", fmt.Sprintf("stdout pipe: %v", err))
+		m.addLog(info, "error", i18n.T("stdout pipe: %v", err))
 		return fmt.Errorf("stdout pipe: %w", err)
 	}
 
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
-		m.addLog(info, "error", fmt.Sprintf("stderr pipe: %v", err))
+		m.addLog(info, "error", i18n.T("stderr pipe: %v", err))
 		return fmt.Errorf("stderr pipe: %w", err)
 	}
 
 	startTime := time.Now()
+	timer := newPhaseTimer(startTime)
+	defer m.setTimings(info, timer)
 
 	if err := cmd.Start(); err != nil {
-		info.CheckDuration = time.Since(startTime).Milliseconds()
-		m.addLog(info, "error", fmt.Sprintf("Failed to start: %v", err))
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "error", i18n.T("Failed to start: %v", err))
 		return fmt.Errorf("start: %w", err)
 	}
-	m.addLog(info, "info", fmt.Sprintf("Started with PID %d", cmd.Process.Pid))
+	timer.spawned = time.Now()
+	m.addLog(info, "info", i18n.T("Started with PID %d", cmd.Process.Pid))
 
 	// Collect stderr in background
+	filter, filterErrs := newStderrFilter(srv)
+	for _, err := range filterErrs {
+		m.addLog(info, "warn", i18n.T("Invalid log filter: %v", err))
+	}
 	stderrDone := make(chan struct{})
+	tail := &StderrTail{}
 	go func() {
 		defer close(stderrDone)
-		scanner := bufio.NewScanner(stderrPipe)
+		scanner := bufio.NewScanner(timer.watch(stderrPipe))
 		scanner.Buffer(make([]byte, 64*1024), 64*1024)
 		for scanner.Scan() {
-			m.addLog(info, "stderr", scanner.Text())
+			line := scanner.Text()
+			if filter.suppressed(line) {
+				continue
+			}
+			tail.Add(line)
+			m.addLog(info, classifyStderr(line), line)
 		}
 	}()
+	// reap collects a process that stopped responding and reports a crash
+	reap := func() {
+		if report := ReapExited("check", srv, cmd, cancel, tail, stderrDone); report != nil {
+			m.RecordCrash(name, report)
+		}
+	}
 
-	stdout := bufio.NewReader(stdoutPipe)
+	stdout := bufio.NewReader(timer.watch(stdoutPipe))
+	// finish stops the process once the check has what it needs
+	finish := func() {
+		cancel()
+		cmd.Wait()
+		<-stderrDone
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "info", i18n.T("Check completed in %dms, process stopped", info.CheckDuration))
+	}
+	depth := srv.CheckDepth()
+
+	if depth == config.CheckPing {
+		timer.initSent = time.Now()
+		if _, err := stdin.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n")); err != nil {
+			reap()
+			m.addLog(info, "error", i18n.T("Failed to send ping: %v", err))
+			return fmt.Errorf("send ping: %w", err)
+		}
+		line, err := stdout.ReadString('\n')
+		timer.initDone = time.Now()
+		if err != nil {
+			reap()
+			m.addLog(info, "error", i18n.T("Failed to read ping response: %v", err))
+			return fmt.Errorf("read ping response: %w", err)
+		}
+		var resp mcpResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			cancel()
+			m.addLog(info, "error", i18n.T("Invalid ping response: %v", err))
+			return fmt.Errorf("parse ping response: %w", err)
+		}
+		if err := m.checkPing(info, &resp); err != nil {
+			cancel()
+			return err
+		}
+		finish()
+		return nil
+	}
 
 	// Send MCP initialize
 	initReq := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"mcp-manager","version":"1.0.0"}}}` + "\n"
+	timer.initSent = time.Now()
 	if _, err := stdin.Write([]byte(initReq)); err != nil {
-		cancel()
-		m.addLog(info, "error", fmt.Sprintf("Failed to send initialize: %v", err))
+		reap()
+		m.addLog(info, "error", i18n.T("Failed to send initialize: %v", err))
 		return fmt.Errorf("send initialize: %w", err)
 	}
 
 	// Read initialize response
 	line, err := stdout.ReadString('\n')
+	timer.initDone = time.Now()
 	if err != nil {
-		cancel()
-		m.addLog(info, "error", fmt.Sprintf("Failed to read initialize response: %v", err))
+		reap()
+		m.addLog(info, "error", i18n.T("Failed to read initialize response: %v", err))
 		return fmt.Errorf("read initialize response: %w", err)
 	}
 
 	var initResp mcpResponse
 	if err := json.Unmarshal([]byte(line), &initResp); err != nil {
 		cancel()
-		m.addLog(info, "error", fmt.Sprintf("Invalid initialize response: %v", err))
+		m.addLog(info, "error", i18n.T("Invalid initialize response: %v", err))
 		return fmt.Errorf("parse initialize response: %w", err)
 	}
 
 	if initResp.Error != nil {
 		cancel()
-		info.CheckDuration = time.Since(startTime).Milliseconds()
-		m.addLog(info, "error", fmt.Sprintf("Initialize error: %s", initResp.Error.Message))
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "error", i18n.T("Initialize error: %s", initResp.Error.Message))
 		return fmt.Errorf("initialize: %s", initResp.Error.Message)
 	}
 
 	// Extract server info from initialize result
 	var initResult mcpInitResult
 	if err := json.Unmarshal(initResp.Result, &initResult); err == nil {
-		info.ServerName = initResult.ServerInfo.Name
-		info.ServerVersion = initResult.ServerInfo.Version
-		info.ProtocolVersion = initResult.ProtocolVersion
+		m.setServerIdentity(info, initResult)
 	}
 
-	m.addLog(info, "info", fmt.Sprintf("MCP initialized: %s %s (protocol %s)",
+	m.addLog(info, "info", i18n.T("MCP initialized: %s %s (protocol %s)",
 		info.ServerName, info.ServerVersion, info.ProtocolVersion))
 
 	// Send initialized notification
 	notif := `{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"
 	stdin.Write([]byte(notif))
+	if depth == config.CheckInitialize {
+		finish()
+		return nil
+	}
 
 	// List tools
 	toolsReq := `{"jsonrpc":"2.0","id":2,"method":"tools/list","params":{}}` + "\n"
+	timer.toolsSent = time.Now()
 	if _, err := stdin.Write([]byte(toolsReq)); err != nil {
-		cancel()
-		m.addLog(info, "warn", fmt.Sprintf("Failed to send tools/list: %v", err))
+		reap()
+		m.addLog(info, "warn", i18n.T("Failed to send tools/list: %v", err))
 		// Not a fatal error — initialize succeeded
 		return nil
 	}
 
 	line, err = stdout.ReadString('\n')
+	timer.toolsDone = time.Now()
 	if err != nil {
-		cancel()
-		m.addLog(info, "warn", fmt.Sprintf("Failed to read tools/list response: %v", err))
+		reap()
+		m.addLog(info, "warn", i18n.T("Failed to read tools/list response: %v", err))
 		return nil
 	}
 
 	var toolsResp mcpResponse
 	if err := json.Unmarshal([]byte(line), &toolsResp); err != nil {
-		m.addLog(info, "warn", fmt.Sprintf("Invalid tools/list response: %v", err))
+		m.addLog(info, "warn", i18n.T("Invalid tools/list response: %v", err))
 	} else if toolsResp.Error != nil {
-		m.addLog(info, "warn", fmt.Sprintf("tools/list error: %s", toolsResp.Error.Message))
+		m.addLog(info, "warn", i18n.T("tools/list error: %s", toolsResp.Error.Message))
 	} else {
 		var result mcpToolsResult
 		if err := json.Unmarshal(toolsResp.Result, &result); err != nil {
-			m.addLog(info, "warn", fmt.Sprintf("Failed to parse tools: %v", err))
+			m.addLog(info, "warn", i18n.T("Failed to parse tools: %v", err))
 		} else {
 			m.mu.Lock()
 			info.Tools = result.Tools
 			m.mu.Unlock()
-			m.addLog(info, "info", fmt.Sprintf("Discovered %d tools", len(result.Tools)))
+			m.addLog(info, "info", i18n.T("Discovered %d tools", len(result.Tools)))
+		}
+	}
+
+	// Health probe
+	var probeErr error
+	if p := srv.HealthProbe; p != nil && depth == config.CheckDeep {
+		var resp mcpResponse
+		req, _ := json.Marshal(probeRequest(p))
+		_, err := stdin.Write(append(req, '\n'))
+		if err == nil {
+			line, err = stdout.ReadString('\n')
+		}
+		if err == nil {
+			err = json.Unmarshal([]byte(line), &resp)
 		}
+		probeErr = m.checkProbe(info, p, &resp, err)
 	}
 
 	// List prompts
 	promptsReq := `{"jsonrpc":"2.0","id":3,"method":"prompts/list","params":{}}` + "\n"
 	if _, err := stdin.Write([]byte(promptsReq)); err != nil {
-		m.addLog(info, "warn", fmt.Sprintf("Failed to send prompts/list: %v", err))
+		m.addLog(info, "warn", i18n.T("Failed to send prompts/list: %v", err))
 	} else {
 		line, err = stdout.ReadString('\n')
 		if err != nil {
-			m.addLog(info, "warn", fmt.Sprintf("Failed to read prompts/list response: %v", err))
+			m.addLog(info, "warn", i18n.T("Failed to read prompts/list response: %v", err))
 		} else {
 			var promptsResp mcpResponse
 			if err := json.Unmarshal([]byte(line), &promptsResp); err != nil {
-				m.addLog(info, "warn", fmt.Sprintf("Invalid prompts/list response: %v", err))
+				m.addLog(info, "warn", i18n.T("Invalid prompts/list response: %v", err))
 			} else if promptsResp.Error != nil {
-				m.addLog(info, "warn", fmt.Sprintf("prompts/list error: %s", promptsResp.Error.Message))
+				m.addLog(info, "warn", i18n.T("prompts/list error: %s", promptsResp.Error.Message))
 			} else {
 				var result mcpPromptsResult
 				if err := json.Unmarshal(promptsResp.Result, &result); err != nil {
-					m.addLog(info, "warn", fmt.Sprintf("Failed to parse prompts: %v", err))
+					m.addLog(info, "warn", i18n.T("Failed to parse prompts: %v", err))
 				} else {
 					m.mu.Lock()
 					info.Prompts = result.Prompts
 					m.mu.Unlock()
-					m.addLog(info, "info", fmt.Sprintf("Discovered %d prompts", len(result.Prompts)))
+					m.addLog(info, "info", i18n.T("Discovered %d prompts", len(result.Prompts)))
 				}
 			}
 		}
@@ -406,44 +642,37 @@ func (m *Manager) doCheck(name string, srv *config.MCPServer, info *ServerInfo)
 	// List resources
 	resourcesReq := `{"jsonrpc":"2.0","id":4,"method":"resources/list","params":{}}` + "\n"
 	if _, err := stdin.Write([]byte(resourcesReq)); err != nil {
-		m.addLog(info, "warn", fmt.Sprintf("Failed to send resources/list: %v", err))
+		m.addLog(info, "warn", i18n.T("Failed to send resources/list: %v", err))
 	} else {
 		line, err = stdout.ReadString('\n')
 		if err != nil {
-			m.addLog(info, "warn", fmt.Sprintf("Failed to read resources/list response: %v", err))
+			m.addLog(info, "warn", i18n.T("Failed to read resources/list response: %v", err))
 		} else {
 			var resourcesResp mcpResponse
 			if err := json.Unmarshal([]byte(line), &resourcesResp); err != nil {
-				m.addLog(info, "warn", fmt.Sprintf("Invalid resources/list response: %v", err))
+				m.addLog(info, "warn", i18n.T("Invalid resources/list response: %v", err))
 			} else if resourcesResp.Error != nil {
-				m.addLog(info, "warn", fmt.Sprintf("resources/list error: %s", resourcesResp.Error.Message))
+				m.addLog(info, "warn", i18n.T("resources/list error: %s", resourcesResp.Error.Message))
 			} else {
 				var result mcpResourcesResult
 				if err := json.Unmarshal(resourcesResp.Result, &result); err != nil {
-					m.addLog(info, "warn", fmt.Sprintf("Failed to parse resources: %v", err))
+					m.addLog(info, "warn", i18n.T("Failed to parse resources: %v", err))
 				} else {
 					m.mu.Lock()
 					info.Resources = result.Resources
 					m.mu.Unlock()
-					m.addLog(info, "info", fmt.Sprintf("Discovered %d resources", len(result.Resources)))
+					m.addLog(info, "info", i18n.T("Discovered %d resources", len(result.Resources)))
 				}
 			}
 		}
 	}
 
-	// Kill the process
-	cancel()
-	cmd.Wait()
-	<-stderrDone
-
-	info.CheckDuration = time.Since(startTime).Milliseconds()
-	m.addLog(info, "info", fmt.Sprintf("Check completed in %dms, process stopped", info.CheckDuration))
-
-	return nil
+	finish()
+	return probeErr
 }
 
 func isStreamableHTTPServer(srv *config.MCPServer) bool {
-	if srv == nil {
+	if srv == nil || srv.IsNATS() {
 		return false
 	}
 	if strings.EqualFold(strings.TrimSpace(srv.Type), "streamableHttp") {
@@ -452,21 +681,27 @@ func isStreamableHTTPServer(srv *config.MCPServer) bool {
 	return strings.TrimSpace(srv.URL) != "" && strings.TrimSpace(srv.Command) == ""
 }
 
-func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo) error {
+func (m *Manager) doCheckStreamableHTTP(ctx context.Context, srv *config.MCPServer, info *ServerInfo) error {
 	if srv.URL == "" {
-		err := fmt.Errorf("missing url for streamableHttp server")
+		err := i18n.Errorf("missing url for streamableHttp server")
 		m.addLog(info, "error", err.Error())
 		return err
 	}
 
 	startTime := time.Now()
-	m.addLog(info, "info", fmt.Sprintf("Connecting via streamable HTTP: %s", srv.URL))
-	client := &http.Client{Timeout: checkTimeout}
+	timer := newPhaseTimer(startTime)
+	defer m.setTimings(info, timer)
+	m.addLog(info, "info", i18n.T("Connecting via streamable HTTP: %s", srv.URL))
+	client, err := transport.NewHTTPClient(srv, m.store.GetEgressSettings(), checkTimeout)
+	if err != nil {
+		m.addLog(info, "error", err.Error())
+		return err
+	}
 	sessionID := ""
 	defer func() {
 		if sessionID != "" {
 			if err := closeStreamableHTTPSession(client, srv.URL, sessionID); err != nil {
-				m.addLog(info, "warn", fmt.Sprintf("Failed to close HTTP MCP session %q: %v", sessionID, err))
+				m.addLog(info, "warn", i18n.T("Failed to close HTTP MCP session %q: %v", sessionID, err))
 			}
 		}
 	}()
@@ -477,7 +712,7 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 			return nil, fmt.Errorf("encode request: %w", err)
 		}
 
-		req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+		req, err := http.NewRequestWithContext(timer.trace(ctx), http.MethodPost, srv.URL, bytes.NewReader(body))
 		if err != nil {
 			return nil, fmt.Errorf("create request: %w", err)
 		}
@@ -518,6 +753,39 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 		return parsed, nil
 	}
 
+	return m.checkRemote(srv, info, timer, startTime, send, func(err error) error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("initialize request: %w", err)
+		}
+		return m.diagnoseHTTPFailure(ctx, srv, info, fmt.Errorf("initialize request: %w", err))
+	})
+}
+
+// rpcSend delivers one JSON-RPC message of a remote check and, when
+// expectResponse is set, returns the response with expectedID.
+type rpcSend func(payload map[string]any, expectResponse bool, expectedID int) (*mcpResponse, error)
+
+// checkRemote runs the MCP handshake and list requests of a remote check over
+// send. initFailed turns a failed initialize request into the check error; a
+// failed health probe of srv is returned once the lists are fetched.
+func (m *Manager) checkRemote(srv *config.MCPServer, info *ServerInfo, timer *phaseTimer, startTime time.Time, send rpcSend, initFailed func(error) error) error {
+	depth := srv.CheckDepth()
+	if depth == config.CheckPing {
+		timer.initSent = time.Now()
+		resp, err := send(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "ping"}, true, 1)
+		timer.initDone = time.Now()
+		m.setCheckDuration(info, startTime)
+		if err != nil {
+			m.addLog(info, "error", i18n.T("Ping request failed: %v", err))
+			return initFailed(err)
+		}
+		if err := m.checkPing(info, resp); err != nil {
+			return err
+		}
+		m.addLog(info, "info", i18n.T("Check completed in %dms", info.CheckDuration))
+		return nil
+	}
+
 	initReq := map[string]any{
 		"jsonrpc": "2.0",
 		"id":      1,
@@ -532,26 +800,26 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 		},
 	}
 
+	timer.initSent = time.Now()
 	initResp, err := send(initReq, true, 1)
+	timer.initDone = time.Now()
 	if err != nil {
-		info.CheckDuration = time.Since(startTime).Milliseconds()
-		m.addLog(info, "error", fmt.Sprintf("Initialize request failed: %v", err))
-		return fmt.Errorf("initialize request: %w", err)
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "error", i18n.T("Initialize request failed: %v", err))
+		return initFailed(err)
 	}
 
 	if initResp.Error != nil {
-		info.CheckDuration = time.Since(startTime).Milliseconds()
-		m.addLog(info, "error", fmt.Sprintf("Initialize error: %s", initResp.Error.Message))
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "error", i18n.T("Initialize error: %s", initResp.Error.Message))
 		return fmt.Errorf("initialize: %s", initResp.Error.Message)
 	}
 
 	var initResult mcpInitResult
 	if err := json.Unmarshal(initResp.Result, &initResult); err == nil {
-		info.ServerName = initResult.ServerInfo.Name
-		info.ServerVersion = initResult.ServerInfo.Version
-		info.ProtocolVersion = initResult.ProtocolVersion
+		m.setServerIdentity(info, initResult)
 	}
-	m.addLog(info, "info", fmt.Sprintf("MCP initialized: %s %s (protocol %s)",
+	m.addLog(info, "info", i18n.T("MCP initialized: %s %s (protocol %s)",
 		info.ServerName, info.ServerVersion, info.ProtocolVersion))
 
 	notif := map[string]any{
@@ -559,7 +827,12 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 		"method":  "notifications/initialized",
 	}
 	if _, err := send(notif, false, 0); err != nil {
-		m.addLog(info, "warn", fmt.Sprintf("Failed to send initialized notification: %v", err))
+		m.addLog(info, "warn", i18n.T("Failed to send initialized notification: %v", err))
+	}
+	if depth == config.CheckInitialize {
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "info", i18n.T("Check completed in %dms", info.CheckDuration))
+		return nil
 	}
 
 	toolsReq := map[string]any{
@@ -568,27 +841,35 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 		"method":  "tools/list",
 		"params":  map[string]any{},
 	}
+	timer.toolsSent = time.Now()
 	toolsResp, err := send(toolsReq, true, 2)
+	timer.toolsDone = time.Now()
 	if err != nil {
-		info.CheckDuration = time.Since(startTime).Milliseconds()
-		m.addLog(info, "warn", fmt.Sprintf("tools/list request failed: %v", err))
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "warn", i18n.T("tools/list request failed: %v", err))
 		return nil
 	}
 
 	if toolsResp.Error != nil {
-		m.addLog(info, "warn", fmt.Sprintf("tools/list error: %s", toolsResp.Error.Message))
+		m.addLog(info, "warn", i18n.T("tools/list error: %s", toolsResp.Error.Message))
 	} else {
 		var result mcpToolsResult
 		if err := json.Unmarshal(toolsResp.Result, &result); err != nil {
-			m.addLog(info, "warn", fmt.Sprintf("Failed to parse tools: %v", err))
+			m.addLog(info, "warn", i18n.T("Failed to parse tools: %v", err))
 		} else {
 			m.mu.Lock()
 			info.Tools = result.Tools
 			m.mu.Unlock()
-			m.addLog(info, "info", fmt.Sprintf("Discovered %d tools", len(result.Tools)))
+			m.addLog(info, "info", i18n.T("Discovered %d tools", len(result.Tools)))
 		}
 	}
 
+	var probeErr error
+	if p := srv.HealthProbe; p != nil && depth == config.CheckDeep {
+		resp, err := send(probeRequest(p), true, probeID)
+		probeErr = m.checkProbe(info, p, resp, err)
+	}
+
 	promptsReq := map[string]any{
 		"jsonrpc": "2.0",
 		"id":      3,
@@ -597,18 +878,18 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 	}
 	promptsResp, err := send(promptsReq, true, 3)
 	if err != nil {
-		m.addLog(info, "warn", fmt.Sprintf("prompts/list request failed: %v", err))
+		m.addLog(info, "warn", i18n.T("prompts/list request failed: %v", err))
 	} else if promptsResp.Error != nil {
-		m.addLog(info, "warn", fmt.Sprintf("prompts/list error: %s", promptsResp.Error.Message))
+		m.addLog(info, "warn", i18n.T("prompts/list error: %s", promptsResp.Error.Message))
 	} else {
 		var result mcpPromptsResult
 		if err := json.Unmarshal(promptsResp.Result, &result); err != nil {
-			m.addLog(info, "warn", fmt.Sprintf("Failed to parse prompts: %v", err))
+			m.addLog(info, "warn", i18n.T("Failed to parse prompts: %v", err))
 		} else {
 			m.mu.Lock()
 			info.Prompts = result.Prompts
 			m.mu.Unlock()
-			m.addLog(info, "info", fmt.Sprintf("Discovered %d prompts", len(result.Prompts)))
+			m.addLog(info, "info", i18n.T("Discovered %d prompts", len(result.Prompts)))
 		}
 	}
 
@@ -620,24 +901,41 @@ func (m *Manager) doCheckStreamableHTTP(srv *config.MCPServer, info *ServerInfo)
 	}
 	resourcesResp, err := send(resourcesReq, true, 4)
 	if err != nil {
-		m.addLog(info, "warn", fmt.Sprintf("resources/list request failed: %v", err))
+		m.addLog(info, "warn", i18n.T("resources/list request failed: %v", err))
 	} else if resourcesResp.Error != nil {
-		m.addLog(info, "warn", fmt.Sprintf("resources/list error: %s", resourcesResp.Error.Message))
+		m.addLog(info, "warn", i18n.T("resources/list error: %s", resourcesResp.Error.Message))
 	} else {
 		var result mcpResourcesResult
 		if err := json.Unmarshal(resourcesResp.Result, &result); err != nil {
-			m.addLog(info, "warn", fmt.Sprintf("Failed to parse resources: %v", err))
+			m.addLog(info, "warn", i18n.T("Failed to parse resources: %v", err))
 		} else {
 			m.mu.Lock()
 			info.Resources = result.Resources
 			m.mu.Unlock()
-			m.addLog(info, "info", fmt.Sprintf("Discovered %d resources", len(result.Resources)))
+			m.addLog(info, "info", i18n.T("Discovered %d resources", len(result.Resources)))
 		}
 	}
 
-	info.CheckDuration = time.Since(startTime).Milliseconds()
-	m.addLog(info, "info", fmt.Sprintf("Check completed in %dms", info.CheckDuration))
-	return nil
+	m.setCheckDuration(info, startTime)
+	m.addLog(info, "info", i18n.T("Check completed in %dms", info.CheckDuration))
+	return probeErr
+}
+
+// diagnoseHTTPFailure runs layered connectivity diagnostics after a failed
+// request and reports the first failing layer instead of the raw error.
+func (m *Manager) diagnoseHTTPFailure(ctx context.Context, srv *config.MCPServer, info *ServerInfo, cause error) error {
+	d := transport.Diagnose(ctx, srv, m.store.GetEgressSettings())
+	for _, st := range d.Steps {
+		level := "info"
+		if !st.OK {
+			level = "error"
+		}
+		m.addLog(info, level, i18n.T("Diagnostics [%s] %s (%dms)", st.Layer, st.Detail, st.DurationMs))
+	}
+	if d.FailedLayer != "" {
+		return fmt.Errorf("%s: %v", d.FailedLayer, d.Err)
+	}
+	return fmt.Errorf("http: %w", cause)
 }
 
 func decodeHTTPMCPResponse(raw []byte, expectedID int) (*mcpResponse, error) {
@@ -728,49 +1026,115 @@ func closeStreamableHTTPSession(client *http.Client, url, sessionID string) erro
 // CheckAll checks all enabled servers.
 func (m *Manager) CheckAll() {
 	cfg := m.store.Get()
+	m.jobsMu.Lock()
+	lastSweep := m.lastSweep
+	m.jobsMu.Unlock()
+	var due []dueCheck
 	for name, srv := range cfg.MCPServers {
 		if srv.Enabled {
-			m.Check(name)
+			due = append(due, dueCheck{name: name, since: lastSweep})
 		}
 	}
+	m.sweep(due)
 }
 
-// StartHealthLoop runs periodic health checks in background.
+// sweep checks servers, checkConcurrency at a time with each start delayed
+// by a random jitter. All checks are queued up front so they show as pending
+// (and can be cancelled) while earlier ones run.
+func (m *Manager) sweep(due []dueCheck) {
+	m.jobsMu.Lock()
+	start := time.Now()
+	m.lastSweep = start
+	m.sweeping++
+	m.jobsMu.Unlock()
+	defer func() {
+		m.jobsMu.Lock()
+		m.sweeping--
+		m.sweepDuration = time.Since(start)
+		m.jobsMu.Unlock()
+	}()
+	var jobs []*CheckJob
+	for _, d := range due {
+		if job := m.sweepJob(d.name, d.since); job != nil {
+			jobs = append(jobs, job)
+		}
+	}
+	jitter := m.store.GetCheckJitter()
+	slots := make(chan struct{}, m.store.GetCheckConcurrency())
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(job *CheckJob) {
+			defer func() { <-slots; wg.Done() }()
+			if jitter > 0 {
+				time.Sleep(time.Duration(rand.Int63n(int64(jitter))))
+			}
+			m.runJob(job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// StartHealthLoop runs periodic health checks in background, each server on
+// its own schedule (see serverSchedule). Servers due together are checked in
+// one sweep; a slow sweep does not hold back the next.
 func (m *Manager) StartHealthLoop() {
+	defer m.setHealthSchedule(false, time.Time{})
 	for {
-		m.healthMu.RLock()
-		interval := m.healthInterval
-		m.healthMu.RUnlock()
-
-		if interval <= 0 {
-			// Disabled, poll every 5s to see if it gets enabled
-			select {
-			case <-m.stopHealth:
-				return
-			case <-time.After(5 * time.Second):
-				continue
-			}
+		due, next := m.dueChecks(time.Now())
+		if len(due) > 0 {
+			go m.sweep(due)
+		}
+		m.setHealthSchedule(true, next)
+		wait := healthTick
+		if !next.IsZero() && time.Until(next) < wait {
+			wait = time.Until(next)
 		}
-
 		select {
 		case <-m.stopHealth:
 			return
-		case <-time.After(time.Duration(interval) * time.Second):
-			m.CheckAll()
+		case <-time.After(wait):
 		}
 	}
 }
 
+// setHealthSchedule records whether the health loop runs and when its next
+// sweep is due; a zero next means none is scheduled.
+func (m *Manager) setHealthSchedule(running bool, next time.Time) {
+	m.healthMu.Lock()
+	m.healthRunning = running
+	m.healthNextRun = next
+	m.healthMu.Unlock()
+}
+
 // StopHealthLoop stops the background health loop.
 func (m *Manager) StopHealthLoop() {
 	close(m.stopHealth)
 }
 
-// RemoveServer removes cached info for a deleted server.
+// RemoveServer removes cached info for a deleted server and cancels its
+// check, if one is in flight.
 func (m *Manager) RemoveServer(name string) {
 	m.mu.Lock()
 	delete(m.servers, name)
 	m.mu.Unlock()
+	m.jobsMu.Lock()
+	delete(m.manual, name)
+	for _, job := range m.jobs {
+		if job.Server == name {
+			job.cancelLocked()
+		}
+	}
+	m.jobsMu.Unlock()
+	m.logs.RemoveLogs(name)
+	m.history.RemoveChecks(name)
+}
+
+// UseStores keeps server logs and check history in the given stores instead
+// of the files under the config directory. Call it before checks start.
+func (m *Manager) UseStores(logs LogStore, history HistoryStore) {
+	m.logs, m.history = logs, history
 }
 
 func (m *Manager) GetInfo(name string) (*ServerInfo, bool) {
@@ -795,7 +1159,19 @@ func (m *Manager) GetInfo(name string) (*ServerInfo, bool) {
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	// Return a copy
+	cp := copyInfo(info)
+	if srv, ok := m.store.GetServer(name); ok {
+		cp.Config = *srv
+		for i := range cp.Tools {
+			cp.Tools[i].Disabled = !srv.ToolEnabled(cp.Tools[i].Name)
+		}
+	}
+	cp.Counts = countCapabilities(cp)
+	return cp, true
+}
+
+// copyInfo copies info and its lists; the caller holds m.mu.
+func copyInfo(info *ServerInfo) *ServerInfo {
 	cp := *info
 	cp.Logs = make([]LogEntry, len(info.Logs))
 	copy(cp.Logs, info.Logs)
@@ -805,7 +1181,71 @@ func (m *Manager) GetInfo(name string) (*ServerInfo, bool) {
 	copy(cp.Prompts, info.Prompts)
 	cp.Resources = make([]MCPResource, len(info.Resources))
 	copy(cp.Resources, info.Resources)
-	return &cp, true
+	return &cp
+}
+
+// ToolMatch is a discovered tool found by SearchTools
+type ToolMatch struct {
+	Server      string `json:"server"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// SearchTools finds tools from the last checks whose name, description or
+// server contains every word of query (case-insensitive). Name matches come
+// first.
+func (m *Manager) SearchTools(query string) []ToolMatch {
+	terms := strings.Fields(strings.ToLower(query))
+	type hit struct {
+		match  ToolMatch
+		inName bool
+	}
+	var hits []hit
+	for server, info := range m.GetAllInfo() {
+		for _, t := range info.Tools {
+			haystack := strings.ToLower(t.Name + "\n" + t.Description + "\n" + server)
+			found, inName := true, len(terms) > 0
+			for _, term := range terms {
+				if !strings.Contains(haystack, term) {
+					found = false
+					break
+				}
+				inName = inName && strings.Contains(strings.ToLower(t.Name), term)
+			}
+			if found {
+				hits = append(hits, hit{ToolMatch{Server: server, Name: t.Name, Description: t.Description, Disabled: t.Disabled}, inName})
+			}
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.inName != b.inName {
+			return a.inName
+		}
+		if a.match.Server != b.match.Server {
+			return a.match.Server < b.match.Server
+		}
+		return a.match.Name < b.match.Name
+	})
+	out := make([]ToolMatch, len(hits))
+	for i, h := range hits {
+		out[i] = h.match
+	}
+	return out
+}
+
+// ToggleTool switches one tool of a server on or off and reports whether it
+// is enabled afterwards.
+func (m *Manager) ToggleTool(name, tool string) (bool, error) {
+	enabled, err := m.store.ToggleTool(name, tool)
+	if err != nil {
+		return false, err
+	}
+	if info, ok := m.GetInfo(name); ok {
+		m.notify(name, info)
+	}
+	return enabled, nil
 }
 
 func (m *Manager) GetAllInfo() map[string]*ServerInfo {
@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/transport"
+)
+
+func (m *Manager) doCheckNATS(ctx context.Context, srv *config.MCPServer, info *ServerInfo) error {
+	if srv.URL == "" || srv.Subject == "" {
+		err := i18n.Errorf("nats server needs both url and subject")
+		m.addLog(info, "error", err.Error())
+		return err
+	}
+
+	startTime := time.Now()
+	timer := newPhaseTimer(startTime)
+	defer m.setTimings(info, timer)
+	m.addLog(info, "info", i18n.T("Connecting via NATS: %s, subject %s", srv.URL, srv.Subject))
+	conn, err := transport.DialNATS(ctx, srv, m.store.GetEgressSettings())
+	if err != nil {
+		m.setCheckDuration(info, startTime)
+		m.addLog(info, "error", i18n.T("NATS connect failed: %v", err))
+		return err
+	}
+	defer conn.Close()
+
+	send := func(payload map[string]any, expectResponse bool, expectedID int) (*mcpResponse, error) {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		if !expectResponse {
+			return nil, conn.Publish(srv.Subject, body)
+		}
+		var resp mcpResponse
+		_, err = conn.Request(srv.Subject, body, func(msg []byte) bool {
+			timer.markFirstByte()
+			var r mcpResponse
+			if json.Unmarshal(msg, &r) != nil || r.ID != expectedID || (r.Result == nil && r.Error == nil) {
+				return false
+			}
+			resp = r
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("nats request: %w", err)
+		}
+		return &resp, nil
+	}
+
+	return m.checkRemote(srv, info, timer, startTime, send, func(err error) error {
+		return fmt.Errorf("initialize request: %w", err)
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package manager
+
+import "os"
+
+// keepOwner is a no-op where files carry no Unix owner.
+func keepOwner(f *os.File, st os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package manager
+
+import (
+	"os"
+	"syscall"
+)
+
+// keepOwner gives f the owner and group of the file described by st.
+func keepOwner(f *os.File, st os.FileInfo) error {
+	sys, ok := st.Sys().(*syscall.Stat_t)
+	if !ok || int(sys.Uid) == os.Geteuid() && int(sys.Gid) == os.Getegid() {
+		return nil
+	}
+	return f.Chown(int(sys.Uid), int(sys.Gid))
+}
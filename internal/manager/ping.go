@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/transport"
+)
+
+const pingTimeout = 10 * time.Second
+
+var pingRequest = []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+
+// pingUpstream sends an MCP ping to a remote server. It only fails when the
+// server cannot be reached or breaks (5xx): servers that insist on a session
+// reject a sessionless ping, but still show they are up by answering.
+func (m *Manager) pingUpstream(ctx context.Context, srv *config.MCPServer) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if srv.IsNATS() {
+		conn, err := transport.DialNATS(ctx, srv, m.store.GetEgressSettings())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Request(srv.Subject, pingRequest, func([]byte) bool { return true })
+		return err
+	}
+	client, err := transport.NewHTTPClient(srv, m.store.GetEgressSettings(), pingTimeout)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewReader(pingRequest))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pingHealthy pings the enabled remote servers whose last check passed and
+// runs a full check on those that no longer answer, so an outage shows up
+// before the next sweep. Stdio servers are skipped: a ping would have to
+// spawn them.
+func (m *Manager) pingHealthy() {
+	for name, srv := range m.store.Get().MCPServers {
+		if !srv.Enabled || !(srv.IsNATS() || isStreamableHTTPServer(srv)) {
+			continue
+		}
+		info, ok := m.GetInfo(name)
+		if !ok || info.Status != StatusHealthy {
+			continue
+		}
+		if err := m.pingUpstream(context.Background(), srv); err != nil {
+			m.addLog(m.getOrCreateInfo(name), "warn", i18n.T("Ping failed: %v; running a full check", err))
+			go m.Check(name)
+		}
+	}
+}
+
+// StartPingLoop pings healthy remote servers every healthPingInterval seconds
+// while the interval is set.
+func (m *Manager) StartPingLoop() {
+	for {
+		wait := 5 * time.Second
+		if interval := m.store.GetHealthPingInterval(); interval > 0 {
+			m.pingHealthy()
+			wait = time.Duration(interval) * time.Second
+		}
+		select {
+		case <-m.stopHealth:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+const prefetchTimeout = 10 * time.Minute
+
+var (
+	npxPackageFlags = []string{"-p", "--package"}
+	uvxPackageFlags = []string{"--from"}
+	uvxValueFlags   = []string{"--python", "--with", "--index-url", "--extra-index-url"}
+)
+
+// prefetchCommand returns the package manager invocation that downloads the
+// server's package into the npx/uvx cache without starting the server.
+// ok is false for servers that are not launched through npx or uvx.
+func prefetchCommand(srv *config.MCPServer) (name string, args []string, ok bool) {
+	switch launcherKind(srv.Command) {
+	case "npx":
+		pkg, _, _ := launcherPackage(srv.Args, npxPackageFlags, nil)
+		if pkg == "" {
+			return "", nil, false
+		}
+		return "npm", []string{"exec", "--yes", "--package=" + pkg, "--", "node", "--version"}, true
+	case "uvx":
+		pkg, _, _ := launcherPackage(srv.Args, uvxPackageFlags, uvxValueFlags)
+		if pkg == "" {
+			return "", nil, false
+		}
+		return "uvx", []string{"--from", pkg, "python", "--version"}, true
+	}
+	return "", nil, false
+}
+
+// launcherPackage finds the package spec in npx/uvx arguments: the value of
+// an explicit package flag, or else the first positional argument. rest holds
+// the arguments after it; with an explicit flag rest starts with the command
+// to run from the package.
+func launcherPackage(args, pkgFlags, valueFlags []string) (pkg string, rest []string, explicit bool) {
+	takesValue := func(flag string, set []string) bool {
+		for _, f := range set {
+			if flag == f {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if flag, val, ok := strings.Cut(arg, "="); ok && takesValue(flag, pkgFlags) {
+			return val, trimDashDash(args[i+1:]), true
+		}
+		if takesValue(arg, pkgFlags) && i+1 < len(args) {
+			return args[i+1], trimDashDash(args[i+2:]), true
+		}
+		if takesValue(arg, valueFlags) {
+			i++
+			continue
+		}
+		if arg == "--" {
+			if i+1 < len(args) {
+				return args[i+1], args[i+2:], false
+			}
+			return "", nil, false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return arg, args[i+1:], false
+		}
+	}
+	return "", nil, false
+}
+
+func trimDashDash(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
+}
+
+// launcherKind reports "npx" or "uvx" for servers started through those
+// launchers, or "" otherwise.
+func launcherKind(command string) string {
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(command)), ".cmd")
+	base = strings.TrimSuffix(base, ".exe")
+	if base == "npx" || base == "uvx" {
+		return base
+	}
+	return ""
+}
+
+// Prefetch downloads the package of an npx/uvx server so that the first
+// real start does not block on installation. Other servers are skipped.
+func (m *Manager) Prefetch(name string) error {
+	srv, ok := m.store.GetServer(name)
+	if !ok {
+		return fmt.Errorf("server %q not found", name)
+	}
+	bin, args, ok := prefetchCommand(srv)
+	if !ok {
+		return nil
+	}
+	info := m.getOrCreateInfo(name)
+	m.addLog(info, "info", i18n.T("Prefetching package: %s %s", bin, strings.Join(args, " ")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), prefetchTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, args...)
+	if procEnv := srv.ProcessEnv(); len(procEnv) > 0 {
+		env := cmd.Environ()
+		for k, v := range procEnv {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+		cmd.Env = env
+	}
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		m.addLog(info, "warn", i18n.T("Prefetch failed: %v: %s", err, strings.TrimSpace(string(out))))
+		return fmt.Errorf("prefetch %s: %w", name, err)
+	}
+	now := time.Now()
+	m.mu.Lock()
+	info.PrefetchedAt = &now
+	m.mu.Unlock()
+	m.addLog(info, "info", i18n.T("Package cached in %dms", now.Sub(start).Milliseconds()))
+	return nil
+}
+
+// PrefetchAll refreshes the package cache for every enabled server.
+func (m *Manager) PrefetchAll() {
+	for name, srv := range m.store.Get().MCPServers {
+		if srv.Enabled {
+			m.Prefetch(name)
+		}
+	}
+}
+
+// StartPrefetchLoop refreshes cached packages at the configured interval
+// while prefetching is enabled.
+func (m *Manager) StartPrefetchLoop() {
+	var last time.Time
+	for {
+		ps := m.store.GetPrefetchSettings()
+		if ps.Enabled && time.Since(last) >= time.Duration(ps.IntervalHours)*time.Hour {
+			m.PrefetchAll()
+			last = time.Now()
+		}
+		select {
+		case <-m.stopHealth:
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// probeID is the JSON-RPC id of the health probe call; 1-4 are taken by the
+// handshake and list requests of a check.
+const probeID = 5
+
+func probeRequest(p *config.HealthProbe) map[string]any {
+	args := p.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	return map[string]any{
+		"jsonrpc": "2.0",
+		"id":      probeID,
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      p.Tool,
+			"arguments": args,
+		},
+	}
+}
+
+// probeText is what Expect is matched against: the text content of a tool
+// result, or the raw result when it has none.
+func probeText(raw json.RawMessage) (string, bool) {
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return string(raw), false
+	}
+	var parts []string
+	for _, c := range result.Content {
+		if c.Type == "text" {
+			parts = append(parts, c.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return string(raw), result.IsError
+	}
+	return strings.Join(parts, "\n"), result.IsError
+}
+
+// checkPing reports a failed reply to the ping of a ping-only check.
+func (m *Manager) checkPing(info *ServerInfo, resp *mcpResponse) error {
+	if resp.Error != nil {
+		m.addLog(info, "error", i18n.T("Ping error: %s", resp.Error.Message))
+		return fmt.Errorf("ping: %s", resp.Error.Message)
+	}
+	m.addLog(info, "info", i18n.T("Ping answered"))
+	return nil
+}
+
+// checkProbe turns the outcome of the health probe call into the check error,
+// nil when the probe passed.
+func (m *Manager) checkProbe(info *ServerInfo, p *config.HealthProbe, resp *mcpResponse, err error) error {
+	if err == nil && resp.Error != nil {
+		err = fmt.Errorf("%s", resp.Error.Message)
+	}
+	if err != nil {
+		m.addLog(info, "error", i18n.T("Health probe %s failed: %v", p.Tool, err))
+		return i18n.Errorf("health probe %s: %w", p.Tool, err)
+	}
+	text, isError := probeText(resp.Result)
+	if isError {
+		m.addLog(info, "error", i18n.T("Health probe %s returned an error: %s", p.Tool, truncate(text, 200)))
+		return i18n.Errorf("health probe %s: tool returned an error", p.Tool)
+	}
+	if p.Expect != "" && !strings.Contains(text, p.Expect) {
+		m.addLog(info, "error", i18n.T("Health probe %s: result does not contain %q: %s", p.Tool, p.Expect, truncate(text, 200)))
+		return i18n.Errorf("health probe %s: result does not contain %q", p.Tool, p.Expect)
+	}
+	m.addLog(info, "info", i18n.T("Health probe %s passed", p.Tool))
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
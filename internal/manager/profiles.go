@@ -0,0 +1,24 @@
+package manager
+
+// SwitchProfile activates another config profile. Checks in flight and the
+// server states of the previous profile are dropped, and the enabled servers
+// of the new one are checked again.
+func (m *Manager) SwitchProfile(name string) error {
+	if err := m.store.SwitchProfile(name); err != nil {
+		return err
+	}
+	m.jobsMu.Lock()
+	for _, job := range m.jobs {
+		job.cancelLocked()
+	}
+	m.manual = make(map[string]*manualCheck)
+	m.jobsMu.Unlock()
+
+	m.mu.Lock()
+	m.servers = make(map[string]*ServerInfo)
+	m.mu.Unlock()
+	m.SetHealthInterval(m.store.GetHealthCheckInterval())
+
+	go m.CheckAll()
+	return nil
+}
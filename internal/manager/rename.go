@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// RenameServer renames a server in the config and carries its status,
+// crash reports, logs and check history over to the new name, so a rename
+// is not a delete and re-add. A server being checked cannot be renamed.
+func (m *Manager) RenameServer(oldName, newName string) error {
+	if _, ok := m.ActiveCheck(oldName); ok {
+		return fmt.Errorf("server %q is being checked", oldName)
+	}
+
+	m.mu.Lock()
+	if err := m.store.RenameServer(oldName, newName); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	// Hand out a fresh ServerInfo: goroutines still holding the old one
+	// write to it rather than race with the rename
+	if info, ok := m.servers[oldName]; ok {
+		cp := *info
+		cp.Name = newName
+		m.servers[newName] = &cp
+		delete(m.servers, oldName)
+	}
+	if crashes, ok := m.crashes[oldName]; ok {
+		m.crashes[newName] = crashes
+		delete(m.crashes, oldName)
+	}
+	m.mu.Unlock()
+
+	m.jobsMu.Lock()
+	if mc, ok := m.manual[oldName]; ok {
+		m.manual[newName] = mc
+		delete(m.manual, oldName)
+	}
+	delete(m.checkLocks, oldName)
+	m.jobsMu.Unlock()
+
+	m.healthMu.Lock()
+	if st, ok := m.schedule[oldName]; ok {
+		m.schedule[newName] = st
+		delete(m.schedule, oldName)
+	}
+	m.healthMu.Unlock()
+
+	if err := m.logs.RenameLogs(oldName, newName); err != nil {
+		slog.Warn("failed to move server logs", "server", newName, "from", oldName, "err", err)
+	}
+	if err := m.history.RenameChecks(oldName, newName); err != nil {
+		slog.Warn("failed to move check history", "server", newName, "from", oldName, "err", err)
+	}
+	if info := m.getOrCreateInfo(newName); info != nil {
+		m.addLog(info, "info", i18n.T("Renamed from %s", oldName))
+		m.notify(newName, info)
+	}
+	return nil
+}
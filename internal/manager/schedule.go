@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// healthTick is the longest the health loop sleeps, so new servers and
+// edited schedules are picked up
+const healthTick = 5 * time.Second
+
+// checkSchedule is how often the health loop checks a server.
+type checkSchedule struct {
+	// key describes the schedule and tells when its settings changed
+	key      string
+	interval time.Duration
+	cron     *config.CronSchedule
+}
+
+// after returns the first check time after t; zero when there is none.
+func (s checkSchedule) after(t time.Time) time.Time {
+	if s.cron != nil {
+		return s.cron.Next(t)
+	}
+	return t.Add(s.interval)
+}
+
+// serverSchedule returns the schedule of a server: its checkCron, else its
+// checkInterval, else the global interval. ok is false when the server is
+// not checked periodically.
+func serverSchedule(srv *config.MCPServer, global int) (sched checkSchedule, ok bool) {
+	if srv.CheckCron != "" {
+		cron, err := config.ParseCron(srv.CheckCron)
+		if err != nil {
+			return checkSchedule{}, false
+		}
+		return checkSchedule{key: "cron " + srv.CheckCron, cron: cron}, true
+	}
+	interval := srv.CheckInterval
+	if interval <= 0 {
+		interval = global
+	}
+	if interval <= 0 {
+		return checkSchedule{}, false
+	}
+	return checkSchedule{key: fmt.Sprintf("every %ds", interval), interval: time.Duration(interval) * time.Second}, true
+}
+
+// scheduledServer tracks the periodic checks of one server.
+type scheduledServer struct {
+	sched checkSchedule
+	// prev is when the server was last due, or when it was scheduled
+	prev time.Time
+	next time.Time
+}
+
+// dueCheck is a server a sweep checks; a manual check of it after since
+// makes the sweep skip it.
+type dueCheck struct {
+	name  string
+	since time.Time
+}
+
+// dueChecks advances the schedules to now. It returns the servers due and
+// when the next one is, zero when none is scheduled.
+func (m *Manager) dueChecks(now time.Time) ([]dueCheck, time.Time) {
+	cfg := m.store.Get()
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	var due []dueCheck
+	var next time.Time
+	for name := range m.schedule {
+		if srv, ok := cfg.MCPServers[name]; !ok || !srv.Enabled {
+			delete(m.schedule, name)
+		}
+	}
+	for name, srv := range cfg.MCPServers {
+		if !srv.Enabled {
+			continue
+		}
+		sched, ok := serverSchedule(srv, m.healthInterval)
+		if !ok {
+			// The store rejects a bad checkCron, but a hand-edited file may hold one
+			if _, err := config.ParseCron(srv.CheckCron); srv.CheckCron != "" && err != nil && m.badCron[name] != srv.CheckCron {
+				m.badCron[name] = srv.CheckCron
+				slog.Warn("invalid checkCron, server is not checked periodically", "server", name, "err", err)
+			}
+			delete(m.schedule, name)
+			continue
+		}
+		st := m.schedule[name]
+		if st == nil || st.sched.key != sched.key {
+			st = &scheduledServer{sched: sched, prev: now, next: sched.after(now)}
+			m.schedule[name] = st
+		}
+		if !st.next.IsZero() && !st.next.After(now) {
+			due = append(due, dueCheck{name: name, since: st.prev})
+			st.prev, st.next = now, sched.after(now)
+		}
+		if !st.next.IsZero() && (next.IsZero() || st.next.Before(next)) {
+			next = st.next
+		}
+	}
+	return due, next
+}
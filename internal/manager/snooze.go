@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// Disable switches a server off with an optional reason and snooze end.
+func (m *Manager) Disable(name, reason string, until *time.Time, remind bool) error {
+	if err := m.store.Disable(name, reason, until, remind); err != nil {
+		return err
+	}
+	info := m.getOrCreateInfo(name)
+	msg := i18n.T("Disabled")
+	if reason != "" {
+		msg = i18n.T("Disabled: %s", reason)
+	}
+	if until != nil {
+		msg += i18n.T(" (snoozed until %s)", until.Local().Format(time.DateTime))
+	}
+	m.addLog(info, "info", msg)
+	m.notify(name, info)
+	return nil
+}
+
+// Enable switches a server on and checks it.
+func (m *Manager) Enable(name string) error {
+	if err := m.store.Enable(name); err != nil {
+		return err
+	}
+	go m.Check(name)
+	return nil
+}
+
+// wakeSnoozed handles the disabled servers whose snooze has ended: they are
+// enabled again, or for reminder snoozes a warning is logged once.
+func (m *Manager) wakeSnoozed(now time.Time) {
+	for name, srv := range m.store.Get().MCPServers {
+		if srv.Enabled || srv.SnoozeUntil == nil || srv.SnoozeUntil.After(now) {
+			continue
+		}
+		info := m.getOrCreateInfo(name)
+		if srv.SnoozeRemind {
+			msg := i18n.T("Snooze ended; the server is still disabled")
+			if srv.DisabledReason != "" {
+				msg = i18n.T("Snooze ended; the server is still disabled: %s", srv.DisabledReason)
+			}
+			if err := m.store.ClearSnooze(name); err != nil {
+				m.addLog(info, "error", i18n.T("Failed to clear snooze: %v", err))
+				continue
+			}
+			m.addLog(info, "warn", msg)
+			m.notify(name, info)
+			continue
+		}
+		if err := m.Enable(name); err != nil {
+			// e.g. template params broke while it was off; remind instead
+			m.addLog(info, "error", i18n.T("Snooze ended but the server cannot be enabled: %v", err))
+			m.store.ClearSnooze(name)
+			continue
+		}
+		m.addLog(info, "info", i18n.T("Snooze ended, server enabled again"))
+	}
+}
+
+// StartSnoozeLoop re-enables snoozed servers once their snooze ends.
+func (m *Manager) StartSnoozeLoop() {
+	for {
+		m.wakeSnoozed(time.Now())
+		select {
+		case <-m.stopHealth:
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// Level markers as printed by common logging libraries: a leading "info:" or
+// "[warn]", an upper-case token such as "- INFO -" near the start of the
+// line, or logfmt "level=error".
+var (
+	stderrPrefixRe = regexp.MustCompile(`(?i)^\W{0,3}(debug|trace|info|notice|warn|warning|error|err|fatal|critical|panic)\b`)
+	stderrTokenRe  = regexp.MustCompile(`\b(DEBUG|TRACE|INFO|NOTICE|WARN|WARNING|ERROR|FATAL|CRITICAL)\b`)
+	stderrLogfmtRe = regexp.MustCompile(`\blevel=(debug|info|warn|warning|error|fatal)\b`)
+)
+
+// classifyStderr guesses the severity of a stderr line. Servers often log
+// informational banners to stderr; only lines that look like problems keep
+// a warning or error level. Unrecognized lines stay "stderr".
+func classifyStderr(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var rec map[string]any
+		if json.Unmarshal([]byte(trimmed), &rec) == nil {
+			for _, key := range []string{"level", "severity", "lvl"} {
+				if v, ok := rec[key].(string); ok {
+					return normalizeLevel(v)
+				}
+			}
+		}
+	}
+	if strings.HasPrefix(trimmed, "Traceback (most recent call last)") || strings.HasPrefix(trimmed, "panic:") {
+		return "error"
+	}
+	if m := stderrPrefixRe.FindStringSubmatch(trimmed); m != nil {
+		return normalizeLevel(m[1])
+	}
+	head := trimmed
+	if len(head) > 60 {
+		head = head[:60]
+	}
+	if m := stderrTokenRe.FindStringSubmatch(head); m != nil {
+		return normalizeLevel(m[1])
+	}
+	if m := stderrLogfmtRe.FindStringSubmatch(trimmed); m != nil {
+		return normalizeLevel(m[1])
+	}
+	return "stderr"
+}
+
+func normalizeLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "debug", "trace":
+		return "debug"
+	case "info", "notice":
+		return "info"
+	case "warn", "warning":
+		return "warn"
+	case "error", "err", "fatal", "critical", "panic":
+		return "error"
+	}
+	return "stderr"
+}
+
+// stderrFilter drops stderr lines matching a server's logFilters.
+type stderrFilter []*regexp.Regexp
+
+// newStderrFilter compiles the server's filters; invalid patterns are
+// returned as errors and skipped.
+func newStderrFilter(srv *config.MCPServer) (stderrFilter, []error) {
+	var f stderrFilter
+	var errs []error
+	for _, pattern := range srv.LogFilters {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f = append(f, re)
+	}
+	return f, errs
+}
+
+func (f stderrFilter) suppressed(line string) bool {
+	for _, re := range f {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogStderr records a stderr line of a process started outside a check
+// (e.g. by the MCP proxy), applying the server's filters and classification.
+// It reports whether the line was kept.
+func (m *Manager) LogStderr(name, line string) bool {
+	srv, ok := m.store.GetServer(name)
+	if !ok {
+		return false
+	}
+	filter, _ := newStderrFilter(srv)
+	if filter.suppressed(line) {
+		return false
+	}
+	m.AppendLog(name, classifyStderr(line), line)
+	return true
+}
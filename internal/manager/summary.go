@@ -0,0 +1,107 @@
+package manager
+
+import "sort"
+
+// CatalogSummary counts servers by state and the capabilities enabled
+// servers expose, without copying their logs or tool schemas.
+type CatalogSummary struct {
+	Servers   int `json:"servers"`
+	Enabled   int `json:"enabled"`
+	Healthy   int `json:"healthy"`
+	Errors    int `json:"errors"`
+	Tools     int `json:"tools"`
+	Prompts   int `json:"prompts"`
+	Resources int `json:"resources"`
+}
+
+// Summary returns the catalog counts. Tools disabled in the config are not
+// counted, matching what the proxy exposes.
+func (m *Manager) Summary() CatalogSummary {
+	cfg := m.store.Get()
+	var sum CatalogSummary
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for name, srv := range cfg.MCPServers {
+		sum.Servers++
+		info := m.servers[name]
+		if info != nil {
+			switch info.Status {
+			case StatusHealthy:
+				sum.Healthy++
+			case StatusError:
+				sum.Errors++
+			}
+		}
+		if !srv.Enabled {
+			continue
+		}
+		sum.Enabled++
+		if info == nil {
+			continue
+		}
+		for _, tool := range info.Tools {
+			if srv.ToolEnabled(tool.Name) {
+				sum.Tools++
+			}
+		}
+		sum.Prompts += len(info.Prompts)
+		sum.Resources += len(info.Resources)
+	}
+	return sum
+}
+
+// FleetState is the health of the enabled servers taken together.
+type FleetState string
+
+const (
+	// FleetIdle means no server is enabled
+	FleetIdle FleetState = "idle"
+	// FleetPending means no enabled server failed but some are not checked yet
+	FleetPending  FleetState = "pending"
+	FleetHealthy  FleetState = "healthy"
+	FleetDegraded FleetState = "degraded"
+)
+
+// Fleet is the fleet-level view tray apps show.
+type Fleet struct {
+	State   FleetState `json:"state"`
+	Enabled int        `json:"enabled"`
+	Healthy int        `json:"healthy"`
+	// Failing lists the enabled servers in error, sorted
+	Failing []string `json:"failing"`
+}
+
+// Fleet returns the combined state of the enabled servers.
+func (m *Manager) Fleet() Fleet {
+	cfg := m.store.Get()
+	f := Fleet{Failing: []string{}}
+	m.mu.RLock()
+	for name, srv := range cfg.MCPServers {
+		if !srv.Enabled {
+			continue
+		}
+		f.Enabled++
+		if info := m.servers[name]; info != nil {
+			switch info.Status {
+			case StatusHealthy:
+				f.Healthy++
+			case StatusError:
+				f.Failing = append(f.Failing, name)
+			}
+		}
+	}
+	m.mu.RUnlock()
+	sort.Strings(f.Failing)
+
+	switch {
+	case f.Enabled == 0:
+		f.State = FleetIdle
+	case len(f.Failing) > 0:
+		f.State = FleetDegraded
+	case f.Healthy == f.Enabled:
+		f.State = FleetHealthy
+	default:
+		f.State = FleetPending
+	}
+	return f
+}
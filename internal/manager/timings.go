@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CheckTimings breaks a check down into phases so slow servers can be
+// attributed to package download/runtime boot or to the server itself.
+type CheckTimings struct {
+	// SpawnMs is the time to start the process (stdio only)
+	SpawnMs int64 `json:"spawnMs,omitempty"`
+	// FirstByteMs is spawn (or request start for HTTP) to the first output byte
+	FirstByteMs  int64 `json:"firstByteMs,omitempty"`
+	InitializeMs int64 `json:"initializeMs,omitempty"`
+	ToolsListMs  int64 `json:"toolsListMs,omitempty"`
+	// Bottleneck names the phase that took the longest: spawn, boot, initialize or tools/list
+	Bottleneck string `json:"bottleneck,omitempty"`
+}
+
+// phaseTimer records timestamps of check phases
+type phaseTimer struct {
+	start     time.Time
+	spawned   time.Time
+	initSent  time.Time
+	initDone  time.Time
+	toolsSent time.Time
+	toolsDone time.Time
+
+	firstOnce sync.Once
+	firstByte time.Time
+}
+
+func newPhaseTimer(start time.Time) *phaseTimer {
+	return &phaseTimer{start: start}
+}
+
+func (t *phaseTimer) markFirstByte() {
+	t.firstOnce.Do(func() { t.firstByte = time.Now() })
+}
+
+// watch wraps a process pipe and records when the first byte arrives.
+func (t *phaseTimer) watch(r io.Reader) io.Reader {
+	return &firstByteReader{r: r, timer: t}
+}
+
+// trace records the first response byte of HTTP requests.
+func (t *phaseTimer) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotFirstResponseByte: t.markFirstByte,
+	})
+}
+
+type firstByteReader struct {
+	r     io.Reader
+	timer *phaseTimer
+}
+
+func (f *firstByteReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if n > 0 {
+		f.timer.markFirstByte()
+	}
+	return n, err
+}
+
+func spanMs(from, to time.Time) int64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from).Milliseconds()
+}
+
+func (t *phaseTimer) result() *CheckTimings {
+	t.firstOnce.Do(func() {})
+	origin := t.spawned
+	if origin.IsZero() {
+		origin = t.start
+	}
+	res := &CheckTimings{
+		SpawnMs:      spanMs(t.start, t.spawned),
+		FirstByteMs:  spanMs(origin, t.firstByte),
+		InitializeMs: spanMs(t.initSent, t.initDone),
+		ToolsListMs:  spanMs(t.toolsSent, t.toolsDone),
+	}
+
+	// Initialize time after the process first spoke is attributed to the
+	// server; the wait before that is boot (npx/uvx download, runtime start).
+	initAfterBoot := res.InitializeMs
+	if !t.spawned.IsZero() && !t.firstByte.IsZero() && t.firstByte.After(t.initSent) {
+		initAfterBoot = spanMs(t.firstByte, t.initDone)
+	}
+	phases := []struct {
+		name string
+		ms   int64
+	}{
+		{"spawn", res.SpawnMs},
+		{"initialize", initAfterBoot},
+		{"tools/list", res.ToolsListMs},
+	}
+	if !t.spawned.IsZero() {
+		phases = append(phases, struct {
+			name string
+			ms   int64
+		}{"boot", res.FirstByteMs})
+	}
+	sort.SliceStable(phases, func(i, j int) bool { return phases[i].ms > phases[j].ms })
+	if phases[0].ms > 0 {
+		res.Bottleneck = phases[0].name
+	}
+	return res
+}
+
+func (m *Manager) setTimings(info *ServerInfo, t *phaseTimer) {
+	timings := t.result()
+	m.mu.Lock()
+	info.Timings = timings
+	m.mu.Unlock()
+}
+
+// SlowServer is a per-server entry of the slow-start insight
+type SlowServer struct {
+	Name          string        `json:"name"`
+	Status        ServerStatus  `json:"status"`
+	CheckDuration int64         `json:"checkDuration"`
+	Timings       *CheckTimings `json:"timings,omitempty"`
+}
+
+// SlowServers returns checked servers ordered by check duration, slowest first.
+func (m *Manager) SlowServers() []SlowServer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]SlowServer, 0, len(m.servers))
+	for name, info := range m.servers {
+		if info.LastCheck == nil {
+			continue
+		}
+		result = append(result, SlowServer{Name: name, Status: info.Status, CheckDuration: info.CheckDuration, Timings: info.Timings})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CheckDuration > result[j].CheckDuration
+	})
+	return result
+}
@@ -0,0 +1,188 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// ToolServers reads the MCP servers currently configured in a CLI tool's own
+// config file, so they can be imported into the catalog.
+func (m *Manager) ToolServers(toolName string) (map[string]*config.MCPServer, error) {
+	td := findToolDef(toolName)
+	if td == nil {
+		return nil, fmt.Errorf("unknown tool %q", toolName)
+	}
+	home, _ := os.UserHomeDir()
+	data, err := os.ReadFile(filepath.Join(home, td.configRel))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]*config.MCPServer{}, nil
+		}
+		return nil, err
+	}
+
+	var servers map[string]*config.MCPServer
+	switch td.format {
+	case "json-mcpServers":
+		servers, err = parseJSONMcpServers(data)
+	case "json-opencode":
+		servers, err = parseJSONOpenCode(data)
+	case "toml-codex":
+		servers, err = parseTOMLCodex(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported format %q", td.format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", td.configRel, err)
+	}
+	return servers, nil
+}
+
+func parseJSONMcpServers(data []byte) (map[string]*config.MCPServer, error) {
+	var doc struct {
+		MCPServers map[string]*config.MCPServer `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.MCPServers == nil {
+		doc.MCPServers = make(map[string]*config.MCPServer)
+	}
+	return doc.MCPServers, nil
+}
+
+func parseJSONOpenCode(data []byte) (map[string]*config.MCPServer, error) {
+	var doc struct {
+		MCP map[string]struct {
+			Type        string            `json:"type"`
+			Command     []string          `json:"command"`
+			URL         string            `json:"url"`
+			Environment map[string]string `json:"environment"`
+			Enabled     *bool             `json:"enabled"`
+		} `json:"mcp"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	servers := make(map[string]*config.MCPServer, len(doc.MCP))
+	for name, entry := range doc.MCP {
+		srv := &config.MCPServer{URL: entry.URL, Env: entry.Environment, Enabled: entry.Enabled == nil || *entry.Enabled}
+		if entry.Type == "remote" && srv.URL != "" {
+			srv.Type = "streamableHttp"
+		}
+		if len(entry.Command) > 0 {
+			srv.Command = entry.Command[0]
+			srv.Args = entry.Command[1:]
+		}
+		servers[name] = srv
+	}
+	return servers, nil
+}
+
+// parseTOMLCodex understands the subset of TOML used for [mcp_servers.*]
+// tables: string values, string arrays and the nested env table.
+func parseTOMLCodex(content string) (map[string]*config.MCPServer, error) {
+	servers := make(map[string]*config.MCPServer)
+	var cur *config.MCPServer
+	inEnv := false
+
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			cur, inEnv = nil, false
+			// The header may be followed by a comment, like our own marker
+			header, _, _ := strings.Cut(line, "]")
+			table := strings.Trim(header, "[ ")
+			rest, ok := strings.CutPrefix(table, "mcp_servers.")
+			if !ok {
+				continue
+			}
+			name, sub, _ := strings.Cut(rest, ".")
+			name = strings.Trim(name, `"`)
+			srv, ok := servers[name]
+			if !ok {
+				srv = &config.MCPServer{Enabled: true}
+				servers[name] = srv
+			}
+			cur, inEnv = srv, sub == "env"
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		if inEnv {
+			s, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if cur.Env == nil {
+				cur.Env = make(map[string]string)
+			}
+			cur.Env[key] = s
+			continue
+		}
+		switch key {
+		case "command", "url":
+			s, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if key == "command" {
+				cur.Command = s
+			} else {
+				cur.URL = s
+			}
+		case "args":
+			args, err := parseTOMLStringArray(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			cur.Args = args
+		case "disabled_tools":
+			tools, err := parseTOMLStringArray(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			cur.DisabledTools = tools
+		case "enabled":
+			cur.Enabled = value != "false"
+		}
+	}
+	return servers, sc.Err()
+}
+
+func parseTOMLStringArray(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("expected a single-line array")
+	}
+	inner := strings.TrimSpace(value[1 : len(value)-1])
+	var out []string
+	for inner != "" {
+		quoted, err := strconv.QuotedPrefix(inner)
+		if err != nil {
+			return nil, err
+		}
+		s, _ := strconv.Unquote(quoted)
+		out = append(out, s)
+		inner = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(inner[len(quoted):]), ","))
+	}
+	return out, nil
+}
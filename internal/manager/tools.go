@@ -3,11 +3,15 @@ package manager
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
 )
 
 type CLITool struct {
@@ -24,6 +28,26 @@ type DiffResult struct {
 	Proposed   string `json:"proposed"`
 }
 
+// ApplyResult reports the outcome of applying the catalog to one CLI tool
+type ApplyResult struct {
+	Tool  string      `json:"tool"`
+	OK    bool        `json:"ok"`
+	Error string      `json:"error,omitempty"`
+	Diff  *DiffResult `json:"diff,omitempty"`
+	// Pruned lists the orphaned entries a prune removed
+	Pruned []string `json:"pruned,omitempty"`
+}
+
+// Our entries are also marked inside the tool configs, so they are still
+// recognised when the catalog's own record of them is lost: JSON configs get
+// a top-level applyMarkerKey listing them, Codex sections a header comment.
+const (
+	applyMarkerKey = "mcpCatalog"
+	codexMarker    = "# managed by mcp-catalog"
+)
+
+var codexMarkedRe = regexp.MustCompile(`(?m)^\[mcp_servers\.([^\].]+)\][ \t]*` + regexp.QuoteMeta(codexMarker))
+
 type toolDef struct {
 	name        string
 	displayName string
@@ -80,9 +104,17 @@ func findToolDef(name string) *toolDef {
 }
 
 func (m *Manager) PreviewApply(toolName string) (*DiffResult, error) {
+	diff, _, err := m.previewApply(toolName, false)
+	return diff, err
+}
+
+// previewApply renders the tool config with our entries applied (or, with
+// clean set, with all of our previously applied entries removed) and returns
+// the server names that the proposed config contains on our behalf.
+func (m *Manager) previewApply(toolName string, clean bool) (*DiffResult, []string, error) {
 	td := findToolDef(toolName)
 	if td == nil {
-		return nil, fmt.Errorf("unknown tool %q", toolName)
+		return nil, nil, fmt.Errorf("unknown tool %q", toolName)
 	}
 
 	home, _ := os.UserHomeDir()
@@ -96,58 +128,369 @@ func (m *Manager) PreviewApply(toolName string) (*DiffResult, error) {
 	}
 
 	// Generate proposed
-	proposed, err := m.generateProposed(td, current)
+	managed := managedEntries(td, current, m.store.GetApplied(td.name))
+	var servers map[string]*config.MCPServer
+	if !clean {
+		servers = m.store.Get().MCPServers
+	}
+	proposed, names, err := m.generateProposed(td, current, managed, servers)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	return &DiffResult{
 		ConfigPath: configPath,
 		Current:    current,
 		Proposed:   proposed,
-	}, nil
+	}, names, nil
 }
 
 func (m *Manager) ApplyToTool(toolName string) error {
-	diff, err := m.PreviewApply(toolName)
+	return m.writeApply(toolName, false)
+}
+
+// CleanTool removes every entry previously applied by mcp-catalog from a tool config.
+func (m *Manager) CleanTool(toolName string) (*DiffResult, error) {
+	diff, _, err := m.previewApply(toolName, true)
+	if err != nil {
+		return nil, err
+	}
+	if diff.Current == "" {
+		return diff, m.store.SetApplied(toolName, nil)
+	}
+	return diff, m.writeApply(toolName, true)
+}
+
+func (m *Manager) writeApply(toolName string, clean bool) error {
+	diff, names, err := m.previewApply(toolName, clean)
 	if err != nil {
 		return err
 	}
+	if err := writeToolConfig(diff.ConfigPath, diff.Proposed); err != nil {
+		return err
+	}
+	return m.store.SetApplied(toolName, names)
+}
+
+// managedEntries is the union of the entries recorded as applied and those
+// marked as ours in the tool config itself.
+func managedEntries(td *toolDef, current string, recorded []string) []string {
+	set := make(map[string]bool)
+	for _, name := range recorded {
+		set[name] = true
+	}
+	switch td.format {
+	case "toml-codex":
+		for _, match := range codexMarkedRe.FindAllStringSubmatch(current, -1) {
+			set[match[1]] = true
+		}
+	default:
+		var doc map[string]any
+		if json.Unmarshal([]byte(current), &doc) == nil {
+			marker, _ := doc[applyMarkerKey].(map[string]any)
+			list, _ := marker["managed"].([]any)
+			for _, v := range list {
+				if name, ok := v.(string); ok {
+					set[name] = true
+				}
+			}
+		}
+	}
+	return sortedKeys(set)
+}
+
+// setApplyMarker records names as ours in a JSON tool config, or drops the
+// marker when there are none.
+func setApplyMarker(doc map[string]any, names []string) {
+	if len(names) == 0 {
+		delete(doc, applyMarkerKey)
+		return
+	}
+	doc[applyMarkerKey] = map[string]any{"managed": names}
+}
+
+// PruneTool removes the entries mcp-catalog wrote for servers that are no
+// longer in the catalog. Unlike apply, every other entry is left as it is.
+func (m *Manager) PruneTool(toolName string) (*DiffResult, []string, error) {
+	td := findToolDef(toolName)
+	if td == nil {
+		return nil, nil, fmt.Errorf("unknown tool %q", toolName)
+	}
+	home, _ := os.UserHomeDir()
+	diff := &DiffResult{ConfigPath: filepath.Join(home, td.configRel)}
+	data, err := os.ReadFile(diff.ConfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return diff, nil, m.store.SetApplied(toolName, nil)
+		}
+		return nil, nil, err
+	}
+	diff.Current = string(data)
+	diff.Proposed = diff.Current
+
+	servers := m.store.Get().MCPServers
+	var orphans, kept []string
+	for _, name := range managedEntries(td, diff.Current, m.store.GetApplied(td.name)) {
+		if _, ok := servers[name]; ok {
+			kept = append(kept, name)
+		} else {
+			orphans = append(orphans, name)
+		}
+	}
+	if len(orphans) == 0 {
+		return diff, nil, nil
+	}
 
-	dir := filepath.Dir(diff.ConfigPath)
+	if td.format == "toml-codex" {
+		out := diff.Current
+		for _, name := range orphans {
+			out = codexSectionRe(name).ReplaceAllString(out, "")
+		}
+		if out = strings.TrimRight(out, "\n\r\t "); out != "" {
+			out += "\n"
+		}
+		diff.Proposed = out
+	} else {
+		var doc map[string]any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, nil, fmt.Errorf("parse %s: %w", diff.ConfigPath, err)
+		}
+		section := "mcpServers"
+		if td.format == "json-opencode" {
+			section = "mcp"
+		}
+		if entries, ok := doc[section].(map[string]any); ok {
+			for _, name := range orphans {
+				delete(entries, name)
+			}
+		}
+		setApplyMarker(doc, kept)
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, nil, err
+		}
+		diff.Proposed = string(out) + "\n"
+	}
+
+	if err := writeToolConfig(diff.ConfigPath, diff.Proposed); err != nil {
+		return nil, nil, err
+	}
+	return diff, orphans, m.store.SetApplied(toolName, kept)
+}
+
+// PruneAll prunes orphaned entries from every detected CLI tool config.
+func (m *Manager) PruneAll() []ApplyResult {
+	var results []ApplyResult
+	for _, tool := range m.DetectTools() {
+		if !tool.HasConfig {
+			continue
+		}
+		res := ApplyResult{Tool: tool.Name}
+		diff, pruned, err := m.PruneTool(tool.Name)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.OK, res.Pruned = true, pruned
+			if len(pruned) > 0 {
+				res.Diff = diff
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// PruneAfterRemove prunes every tool config after a server was deleted from
+// the catalog, when apply.pruneOnRemove is set.
+func (m *Manager) PruneAfterRemove() []ApplyResult {
+	if !m.store.GetApplySettings().PruneOnRemove {
+		return nil
+	}
+	results := m.PruneAll()
+	for _, res := range results {
+		if res.Error != "" {
+			slog.Warn("prune tool config", "tool", res.Tool, "err", res.Error)
+		} else if len(res.Pruned) > 0 {
+			slog.Info("pruned tool config", "tool", res.Tool, "servers", res.Pruned)
+		}
+	}
+	return results
+}
+
+// configPairRe matches "key": "value" (JSON) and key = "value" (TOML) pairs
+var configPairRe = regexp.MustCompile(`"?([A-Za-z_][A-Za-z0-9_.-]*)"?\s*[:=]\s*"([^"\n]*)"`)
+
+// hasSecrets reports whether a tool config holds credentials, such as API
+// keys in a server's env.
+func hasSecrets(content string) bool {
+	for _, m := range configPairRe.FindAllStringSubmatch(content, -1) {
+		if looksLikeSecret(m[1], m[2]) {
+			return true
+		}
+	}
+	return secretValueRe.MatchString(content)
+}
+
+// writeToolConfig replaces a tool config through a temp file in the same
+// directory, so a crash or failed write never leaves it truncated. The new
+// file keeps the old one's owner and mode; new files are created 0600. A
+// config holding secrets never stays readable by group or others, even if
+// the original file was.
+func writeToolConfig(path, content string) error {
+	// Replace the target, not a symlink pointing at it (dotfiles repos)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("create dir: %w", err)
 	}
+	mode := os.FileMode(0600)
+	st, statErr := os.Stat(path)
+	if statErr == nil {
+		mode = st.Mode().Perm()
+	}
+	if mode&0077 != 0 && hasSecrets(content) {
+		slog.Warn("tool config holds secrets, restricting permissions", "path", path, "from", mode, "to", mode&^0077)
+		mode &^= 0077
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Mode and owner are set while the file is still empty
+	err = tmp.Chmod(mode)
+	if err == nil && statErr == nil {
+		err = keepOwner(tmp, st)
+	}
+	if err == nil {
+		_, err = tmp.WriteString(content)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
 
-	return os.WriteFile(diff.ConfigPath, []byte(diff.Proposed), 0644)
+// restoreToolConfig puts back what a tool config held before an apply; a
+// config the apply created is removed.
+func restoreToolConfig(diff *DiffResult) error {
+	if diff.Current == "" {
+		if err := os.Remove(diff.ConfigPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return writeToolConfig(diff.ConfigPath, diff.Current)
 }
 
-func (m *Manager) generateProposed(td *toolDef, current string) (string, error) {
+// ApplyAll applies the catalog to every detected CLI tool. All configs are
+// rendered before anything is written; if a write fails, files already
+// written in this call are restored to their previous content, and a tool
+// whose restore failed says so in its result.
+func (m *Manager) ApplyAll() []ApplyResult {
+	type pending struct {
+		idx   int
+		diff  *DiffResult
+		names []string
+	}
+
+	var results []ApplyResult
+	var plan []pending
+	for _, tool := range m.DetectTools() {
+		res := ApplyResult{Tool: tool.Name}
+		diff, names, err := m.previewApply(tool.Name, false)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Diff = diff
+			plan = append(plan, pending{idx: len(results), diff: diff, names: names})
+		}
+		results = append(results, res)
+	}
+
+	var written []pending
+	var writeErr error
+	for _, p := range plan {
+		if err := writeToolConfig(p.diff.ConfigPath, p.diff.Proposed); err != nil {
+			results[p.idx].Error = err.Error()
+			writeErr = err
+			break
+		}
+		written = append(written, p)
+	}
+
+	if writeErr != nil {
+		for _, p := range written {
+			if err := restoreToolConfig(p.diff); err != nil {
+				slog.Error("failed to roll back tool config", "tool", results[p.idx].Tool, "path", p.diff.ConfigPath, "err", err)
+				results[p.idx].Error = fmt.Sprintf("not rolled back, %s keeps the new config: %v (apply failed: %v)", p.diff.ConfigPath, err, writeErr)
+				continue
+			}
+			results[p.idx].Error = fmt.Sprintf("rolled back: %v", writeErr)
+		}
+		for _, p := range plan {
+			if results[p.idx].Error == "" {
+				results[p.idx].Error = fmt.Sprintf("skipped: %v", writeErr)
+			}
+		}
+		return results
+	}
+
+	for _, p := range written {
+		tool := results[p.idx].Tool
+		if err := m.store.SetApplied(tool, p.names); err != nil {
+			results[p.idx].Error = fmt.Sprintf("record applied servers: %v", err)
+			continue
+		}
+		results[p.idx].OK = true
+	}
+	return results
+}
+
+func (m *Manager) generateProposed(td *toolDef, current string, managed []string, servers map[string]*config.MCPServer) (string, []string, error) {
 	switch td.format {
 	case "json-mcpServers":
-		return m.proposedJSONMcpServers(current)
+		// Gemini CLI hides tools listed in a server's excludeTools
+		excludeKey := ""
+		if td.name == "gemini" {
+			excludeKey = "excludeTools"
+		}
+		return proposedJSONMcpServers(current, managed, servers, excludeKey)
 	case "json-opencode":
-		return m.proposedJSONOpenCode(current)
+		return proposedJSONOpenCode(current, managed, servers)
 	case "toml-codex":
-		return m.proposedTOMLCodex(current)
+		return proposedTOMLCodex(current, managed, servers)
 	default:
-		return "", fmt.Errorf("unsupported format %q", td.format)
+		return "", nil, fmt.Errorf("unsupported format %q", td.format)
 	}
 }
 
 // enabledServersClean returns enabled servers with the "enabled" field stripped.
-func (m *Manager) enabledServersClean() map[string]any {
-	cfg := m.store.Get()
+// Disabled tools are written under excludeKey when the CLI supports it.
+func enabledServersClean(servers map[string]*config.MCPServer, excludeKey string) map[string]any {
 	result := make(map[string]any)
-	for name, srv := range cfg.MCPServers {
-		if !srv.Enabled {
+	for name, srv := range servers {
+		// CLIs cannot speak NATS; such servers are reachable through the proxy
+		if !srv.Enabled || srv.IsNATS() {
 			continue
 		}
 		entry := make(map[string]any)
-		if srv.Type != "" {
+		// CLIs run an exec-transport helper as a plain stdio server
+		if srv.Type != "" && !srv.IsExecTransport() {
 			entry["type"] = srv.Type
 		}
-		if srv.URL != "" {
+		if srv.URL != "" && !srv.IsExecTransport() {
 			entry["url"] = srv.URL
 		}
 		if srv.Command != "" {
@@ -156,19 +499,31 @@ func (m *Manager) enabledServersClean() map[string]any {
 		if len(srv.Args) > 0 {
 			entry["args"] = srv.Args
 		}
-		if len(srv.Env) > 0 {
-			entry["env"] = srv.Env
+		if env := srv.ProcessEnv(); len(env) > 0 {
+			entry["env"] = env
 		}
 		if len(entry) == 0 {
 			continue
 		}
+		if excludeKey != "" && len(srv.DisabledTools) > 0 {
+			entry[excludeKey] = srv.DisabledTools
+		}
 		result[name] = entry
 	}
 	return result
 }
 
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // JSON format with "mcpServers" key (Claude, Cursor, Gemini)
-func (m *Manager) proposedJSONMcpServers(current string) (string, error) {
+func proposedJSONMcpServers(current string, managed []string, servers map[string]*config.MCPServer, excludeKey string) (string, []string, error) {
 	var doc map[string]any
 
 	if current != "" {
@@ -180,27 +535,32 @@ func (m *Manager) proposedJSONMcpServers(current string) (string, error) {
 		doc = make(map[string]any)
 	}
 
-	servers := m.enabledServersClean()
+	clean := enabledServersClean(servers, excludeKey)
 
-	// Merge: keep existing servers not managed by us, add/overwrite ours
+	// Merge: keep existing servers not managed by us, drop our stale ones, add/overwrite ours
 	existing, _ := doc["mcpServers"].(map[string]any)
 	if existing == nil {
 		existing = make(map[string]any)
 	}
-	for name, srv := range servers {
+	for _, name := range managed {
+		delete(existing, name)
+	}
+	for name, srv := range clean {
 		existing[name] = srv
 	}
 	doc["mcpServers"] = existing
+	names := sortedKeys(clean)
+	setApplyMarker(doc, names)
 
 	data, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return string(data) + "\n", nil
+	return string(data) + "\n", names, nil
 }
 
 // OpenCode JSON format with "mcp" key
-func (m *Manager) proposedJSONOpenCode(current string) (string, error) {
+func proposedJSONOpenCode(current string, managed []string, servers map[string]*config.MCPServer) (string, []string, error) {
 	var doc map[string]any
 
 	if current != "" {
@@ -211,7 +571,6 @@ func (m *Manager) proposedJSONOpenCode(current string) (string, error) {
 		doc = make(map[string]any)
 	}
 
-	cfg := m.store.Get()
 	mcpSection := make(map[string]any)
 
 	// Preserve existing entries not managed by us
@@ -220,11 +579,31 @@ func (m *Manager) proposedJSONOpenCode(current string) (string, error) {
 			mcpSection[k] = v
 		}
 	}
+	for _, name := range managed {
+		delete(mcpSection, name)
+	}
 
-	for name, srv := range cfg.MCPServers {
-		if !srv.Enabled {
-			continue
-		}
+	entries := openCodeEntries(servers)
+	for name, entry := range entries {
+		mcpSection[name] = entry
+	}
+	doc["mcp"] = mcpSection
+	names := sortedKeys(entries)
+	setApplyMarker(doc, names)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+	return string(data) + "\n", names, nil
+}
+
+// openCodeEntries returns the "mcp" entries of the stdio servers. OpenCode
+// has its own enabled flag, so disabled servers are written disabled rather
+// than left out, and toggles survive a round trip.
+func openCodeEntries(servers map[string]*config.MCPServer) map[string]any {
+	entries := make(map[string]any)
+	for name, srv := range servers {
 		if srv.Command == "" {
 			continue
 		}
@@ -233,28 +612,32 @@ func (m *Manager) proposedJSONOpenCode(current string) (string, error) {
 		entry := map[string]any{
 			"type":    "local",
 			"command": cmd,
-			"enabled": true,
+			"enabled": srv.Enabled,
 		}
-		mcpSection[name] = entry
+		if srv.IsExecTransport() {
+			entry["environment"] = srv.ProcessEnv()
+		}
+		entries[name] = entry
 	}
-	doc["mcp"] = mcpSection
+	return entries
+}
 
-	data, err := json.MarshalIndent(doc, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return string(data) + "\n", nil
+// codexSectionRe matches a [mcp_servers.NAME] section and its sub-tables,
+// up to the next line that opens a table.
+func codexSectionRe(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^\[mcp_servers\.` + regexp.QuoteMeta(name) + `(?:\.[^\]]+)?\][^\n]*\n(?:(?:[^\[\n][^\n]*)?\n)*`)
 }
 
 // Codex TOML format with [mcp_servers.NAME] sections
-func (m *Manager) proposedTOMLCodex(current string) (string, error) {
-	cfg := m.store.Get()
+func proposedTOMLCodex(current string, managed []string, servers map[string]*config.MCPServer) (string, []string, error) {
+	names := codexServers(servers)
 
-	// Remove existing [mcp_servers.*] sections from current
+	// Remove our previously applied and about-to-be-written sections from current
 	base := current
 	if base != "" {
-		re := regexp.MustCompile(`(?m)^\[mcp_servers\.[^\]]+\]\n(?:[^\[]*\n)*`)
-		base = re.ReplaceAllString(base, "")
+		for _, name := range append(append([]string{}, managed...), names...) {
+			base = codexSectionRe(name).ReplaceAllString(base, "")
+		}
 		base = strings.TrimRight(base, "\n\r\t ")
 	}
 
@@ -265,14 +648,34 @@ func (m *Manager) proposedTOMLCodex(current string) (string, error) {
 		sb.WriteString("\n\n")
 	}
 
-	for name, srv := range cfg.MCPServers {
-		if !srv.Enabled {
-			continue
-		}
-		if srv.Command == "" {
-			continue
+	writeCodexSections(&sb, names, servers, " "+codexMarker)
+
+	out := strings.TrimRight(sb.String(), "\n")
+	if out == "" {
+		return "", names, nil
+	}
+	return out + "\n", names, nil
+}
+
+// codexServers returns the sorted names of the servers Codex can run,
+// disabled ones included: they are written with enabled = false
+func codexServers(servers map[string]*config.MCPServer) []string {
+	var names []string
+	for name, srv := range servers {
+		if srv.Command != "" {
+			names = append(names, name)
 		}
-		sb.WriteString(fmt.Sprintf("[mcp_servers.%s]\n", name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeCodexSections writes a [mcp_servers.NAME] section per server, with
+// mark after each section header
+func writeCodexSections(sb *strings.Builder, names []string, servers map[string]*config.MCPServer, mark string) {
+	for _, name := range names {
+		srv := servers[name]
+		sb.WriteString(fmt.Sprintf("[mcp_servers.%s]%s\n", name, mark))
 		sb.WriteString(fmt.Sprintf("command = %q\n", srv.Command))
 
 		// Format args as TOML array
@@ -284,18 +687,29 @@ func (m *Manager) proposedTOMLCodex(current string) (string, error) {
 			sb.WriteString(fmt.Sprintf("%q", arg))
 		}
 		sb.WriteString(" ]\n")
+		if len(srv.DisabledTools) > 0 {
+			sb.WriteString("disabled_tools = [ ")
+			for i, tool := range srv.DisabledTools {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(fmt.Sprintf("%q", tool))
+			}
+			sb.WriteString(" ]\n")
+		}
+		if !srv.Enabled {
+			sb.WriteString("enabled = false\n")
+		}
 
-		if len(srv.Env) > 0 {
+		if env := srv.ProcessEnv(); len(env) > 0 {
 			sb.WriteString("[mcp_servers.")
 			sb.WriteString(name)
 			sb.WriteString(".env]\n")
-			for k, v := range srv.Env {
-				sb.WriteString(fmt.Sprintf("%s = %q\n", k, v))
+			for _, k := range sortedKeys(env) {
+				sb.WriteString(fmt.Sprintf("%s = %q\n", k, env[k]))
 			}
 		}
 
 		sb.WriteString("\n")
 	}
-
-	return strings.TrimRight(sb.String(), "\n") + "\n", nil
 }
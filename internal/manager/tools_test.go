@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+func TestCodexRoundTrip(t *testing.T) {
+	user := `model = "o3"
+
+[mcp_servers.mine]
+command = "mine"
+args = [
+  "--flag",
+]
+
+[profiles.fast]
+model = "o4-mini"
+`
+	servers := map[string]*config.MCPServer{
+		"alpha": {Command: "npx", Args: []string{"-y", "alpha"}, Enabled: true},
+		"beta":  {Command: "uvx", Args: []string{"beta"}, Env: map[string]string{"TOKEN": "x"}, Enabled: true},
+	}
+
+	applied, names, err := proposedTOMLCodex(user, nil, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "alpha,beta" {
+		t.Fatalf("names = %v", names)
+	}
+	again, _, err := proposedTOMLCodex(applied, names, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != applied {
+		t.Errorf("applying twice changed the config:\n%s\n---\n%s", applied, again)
+	}
+
+	// Dropping beta must take its args and env table with it
+	delete(servers, "beta")
+	pruned, _, err := proposedTOMLCodex(applied, names, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(pruned, "beta") || strings.Contains(pruned, "TOKEN") {
+		t.Errorf("beta left behind:\n%s", pruned)
+	}
+	if !strings.Contains(pruned, "[mcp_servers.alpha]") {
+		t.Errorf("alpha missing:\n%s", pruned)
+	}
+
+	cleaned, _, err := proposedTOMLCodex(pruned, []string{"alpha"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleaned != user {
+		t.Errorf("clean did not restore the user config:\n%q\nwant\n%q", cleaned, user)
+	}
+}
+
+func TestCodexSectionRe(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "multi-line args",
+			in:   "[mcp_servers.a]\ncommand = \"x\"\nargs = [\n  \"y\",\n]\n\n[other]\nk = 1\n",
+			want: "[other]\nk = 1\n",
+		},
+		{
+			name: "sub-table",
+			in:   "[mcp_servers.a]\ncommand = \"x\"\n[mcp_servers.a.env]\nK = \"v\"\n[mcp_servers.b]\ncommand = \"z\"\n",
+			want: "[mcp_servers.b]\ncommand = \"z\"\n",
+		},
+		{
+			name: "marker comment",
+			in:   "[mcp_servers.a] # managed\ncommand = \"x\"\n",
+			want: "",
+		},
+		{
+			name: "prefix of another name",
+			in:   "[mcp_servers.ab]\ncommand = \"x\"\n",
+			want: "[mcp_servers.ab]\ncommand = \"x\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codexSectionRe("a").ReplaceAllString(tt.in, ""); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteToolConfig(t *testing.T) {
+	dir := t.TempDir()
+	secret := `{"mcpServers":{"gh":{"env":{"GITHUB_TOKEN":"ghp_` + strings.Repeat("a", 36) + `"}}}}`
+
+	// A world-readable config that gains a secret is narrowed to the owner
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"theme":"dark"}`), 0644)
+	os.Chmod(path, 0644)
+	if err := writeToolConfig(path, secret); err != nil {
+		t.Fatal(err)
+	}
+	st, _ := os.Stat(path)
+	if st.Mode().Perm() != 0600 {
+		t.Errorf("mode %v, want 0600", st.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != secret {
+		t.Errorf("content %q", data)
+	}
+
+	// Without secrets the mode is kept
+	plain := filepath.Join(dir, "plain.json")
+	os.WriteFile(plain, []byte(`{}`), 0644)
+	os.Chmod(plain, 0644)
+	if err := writeToolConfig(plain, `{"mcpServers":{}}`); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := os.Stat(plain); st.Mode().Perm() != 0644 {
+		t.Errorf("plain mode %v, want 0644", st.Mode().Perm())
+	}
+
+	// A symlinked config keeps its link; the target is replaced
+	target := filepath.Join(dir, "dotfiles.json")
+	link := filepath.Join(dir, "link.json")
+	os.WriteFile(target, []byte(`{}`), 0600)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks unavailable:", err)
+	}
+	if err := writeToolConfig(link, `{"a":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Lstat(link); fi.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink replaced by a file")
+	}
+	if data, _ := os.ReadFile(target); string(data) != `{"a":1}` {
+		t.Errorf("target content %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestRestoreToolConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"new":true}`), 0600)
+	if err := restoreToolConfig(&DiffResult{ConfigPath: path, Current: `{"old":true}`}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"old":true}` {
+		t.Errorf("restored %q", data)
+	}
+
+	// A config the apply created goes away again, and one already gone is fine
+	created := filepath.Join(dir, "created.json")
+	os.WriteFile(created, []byte(`{}`), 0600)
+	for i := 0; i < 2; i++ {
+		if err := restoreToolConfig(&DiffResult{ConfigPath: created}); err != nil {
+			t.Errorf("remove %d: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("created config not removed")
+	}
+
+	// A restore that cannot be written is reported
+	blocked := filepath.Join(dir, "blocked")
+	os.MkdirAll(filepath.Join(blocked, "sub"), 0700)
+	if err := restoreToolConfig(&DiffResult{ConfigPath: blocked, Current: `{}`}); err == nil {
+		t.Error("failed restore not reported")
+	}
+}
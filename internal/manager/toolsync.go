@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ToolSync tells whether the entries mcp-catalog wrote into a CLI tool's
+// config still match the catalog. Only tools applied before are compared,
+// so configs the catalog was never applied to are not reported.
+type ToolSync struct {
+	Tool        string `json:"tool"`
+	DisplayName string `json:"displayName"`
+	InSync      bool   `json:"inSync"`
+	// Changed entries differ from the catalog's, Missing ones would be added
+	// by Apply and Extra ones removed
+	Changed []string `json:"changed,omitempty"`
+	Missing []string `json:"missing,omitempty"`
+	Extra   []string `json:"extra,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// ToolSyncStatus compares every applied CLI tool config with what Apply
+// would write now.
+func (m *Manager) ToolSyncStatus() []ToolSync {
+	var result []ToolSync
+	for _, tool := range m.DetectTools() {
+		if !tool.HasConfig {
+			continue
+		}
+		td := findToolDef(tool.Name)
+		st := ToolSync{Tool: tool.Name, DisplayName: tool.DisplayName}
+		diff, names, err := m.previewApply(tool.Name, false)
+		if err != nil {
+			st.Error = err.Error()
+			result = append(result, st)
+			continue
+		}
+		managed := managedEntries(td, diff.Current, m.store.GetApplied(tool.Name))
+		if len(managed) == 0 {
+			continue
+		}
+		current, err := toolEntries(td, diff.Current)
+		if err != nil {
+			st.Error = err.Error()
+			result = append(result, st)
+			continue
+		}
+		proposed, err := toolEntries(td, diff.Proposed)
+		if err != nil {
+			st.Error = err.Error()
+			result = append(result, st)
+			continue
+		}
+		set := make(map[string]bool)
+		for _, name := range append(managed, names...) {
+			set[name] = true
+		}
+		for _, name := range sortedKeys(set) {
+			cur, inCurrent := current[name]
+			prop, inProposed := proposed[name]
+			switch {
+			case inProposed && !inCurrent:
+				st.Missing = append(st.Missing, name)
+			case inCurrent && !inProposed:
+				st.Extra = append(st.Extra, name)
+			case cur != prop:
+				st.Changed = append(st.Changed, name)
+			}
+		}
+		st.InSync = len(st.Changed)+len(st.Missing)+len(st.Extra) == 0
+		result = append(result, st)
+	}
+	return result
+}
+
+// ReapplyStale applies the catalog to the tools that are out of sync.
+func (m *Manager) ReapplyStale() []ApplyResult {
+	var results []ApplyResult
+	for _, st := range m.ToolSyncStatus() {
+		if st.InSync || st.Error != "" {
+			continue
+		}
+		res := ApplyResult{Tool: st.Tool, OK: true}
+		if err := m.ApplyToTool(st.Tool); err != nil {
+			res.OK, res.Error = false, err.Error()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// toolEntries returns the server entries of a tool config in a canonical
+// form, so they compare equal regardless of formatting and key order.
+func toolEntries(td *toolDef, content string) (map[string]string, error) {
+	entries := make(map[string]string)
+	if strings.TrimSpace(content) == "" {
+		return entries, nil
+	}
+	if td.format == "toml-codex" {
+		servers, err := parseTOMLCodex(content)
+		if err != nil {
+			return nil, err
+		}
+		for name, srv := range servers {
+			data, err := json.Marshal(srv)
+			if err != nil {
+				return nil, err
+			}
+			entries[name] = string(data)
+		}
+		return entries, nil
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, err
+	}
+	section := "mcpServers"
+	if td.format == "json-opencode" {
+		section = "mcp"
+	}
+	list, _ := doc[section].(map[string]any)
+	for name, entry := range list {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		entries[name] = string(data)
+	}
+	return entries, nil
+}
@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// Vendor installs the package of an npx/uvx server into dir and returns a
+// copy of the server config that starts the installed copy directly, so
+// startup no longer depends on the global npm/pip state or the network.
+func Vendor(srv *config.MCPServer, dir string) (*config.MCPServer, error) {
+	if srv.Vendored != nil {
+		return nil, fmt.Errorf("server is already vendored in %s", srv.Vendored.Dir)
+	}
+	kind := launcherKind(srv.Command)
+	if kind == "" {
+		return nil, fmt.Errorf("only npx and uvx servers can be vendored (command is %q)", srv.Command)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var (
+		command string
+		args    []string
+		err     error
+	)
+	if kind == "npx" {
+		command, args, err = vendorNpm(srv, dir)
+	} else {
+		command, args, err = vendorUv(srv, dir)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	out := *srv
+	out.Command = command
+	out.Args = args
+	out.Vendored = &config.VendoredFrom{Command: srv.Command, Args: srv.Args, Dir: dir}
+	if out.Integrity, err = hashTree(dir); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Unvendor restores the original launcher command and removes the vendored files.
+func Unvendor(srv *config.MCPServer) (*config.MCPServer, error) {
+	if srv.Vendored == nil {
+		return nil, fmt.Errorf("server is not vendored")
+	}
+	out := *srv
+	out.Command = srv.Vendored.Command
+	out.Args = srv.Vendored.Args
+	out.Vendored = nil
+	out.Integrity = ""
+	if err := os.RemoveAll(srv.Vendored.Dir); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func vendorNpm(srv *config.MCPServer, dir string) (string, []string, error) {
+	pkg, rest, explicit := launcherPackage(srv.Args, npxPackageFlags, nil)
+	if pkg == "" {
+		return "", nil, fmt.Errorf("cannot find the package in npx arguments")
+	}
+	if err := runInstall(srv, "npm", "install", "--prefix", dir, "--no-audit", "--no-fund", pkg); err != nil {
+		return "", nil, err
+	}
+
+	var bin string
+	if explicit && len(rest) > 0 {
+		bin, rest = rest[0], rest[1:]
+	} else {
+		var err error
+		if bin, err = npmBinName(dir, npmPackageName(pkg)); err != nil {
+			return "", nil, err
+		}
+	}
+	if runtime.GOOS == "windows" {
+		bin += ".cmd"
+	}
+	return filepath.Join(dir, "node_modules", ".bin", bin), rest, nil
+}
+
+// npmPackageName strips the version from an npm spec ("@scope/pkg@1.2" -> "@scope/pkg").
+func npmPackageName(spec string) string {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+// npmBinName picks the executable an npx invocation of pkg would run.
+func npmBinName(dir, pkg string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "node_modules", pkg, "package.json"))
+	if err != nil {
+		return "", fmt.Errorf("read installed package.json: %w", err)
+	}
+	var manifest struct {
+		Bin json.RawMessage `json:"bin"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("parse package.json: %w", err)
+	}
+	short := pkg[strings.LastIndex(pkg, "/")+1:]
+	var single string
+	if json.Unmarshal(manifest.Bin, &single) == nil && single != "" {
+		return short, nil
+	}
+	var bins map[string]string
+	if err := json.Unmarshal(manifest.Bin, &bins); err != nil || len(bins) == 0 {
+		return "", fmt.Errorf("package %s has no executables", pkg)
+	}
+	if len(bins) == 1 {
+		for name := range bins {
+			return name, nil
+		}
+	}
+	if _, ok := bins[short]; ok {
+		return short, nil
+	}
+	return "", fmt.Errorf("package %s has several executables; use `npx -p %s <bin>`", pkg, pkg)
+}
+
+func vendorUv(srv *config.MCPServer, dir string) (string, []string, error) {
+	pkg, rest, explicit := launcherPackage(srv.Args, uvxPackageFlags, uvxValueFlags)
+	if pkg == "" {
+		return "", nil, fmt.Errorf("cannot find the package in uvx arguments")
+	}
+	if err := runInstall(srv, "uv", "venv", dir); err != nil {
+		return "", nil, err
+	}
+	binDir, exe := filepath.Join(dir, "bin"), ""
+	if runtime.GOOS == "windows" {
+		binDir, exe = filepath.Join(dir, "Scripts"), ".exe"
+	}
+	if err := runInstall(srv, "uv", "pip", "install", "--python", filepath.Join(binDir, "python"+exe), pkg); err != nil {
+		return "", nil, err
+	}
+
+	var bin string
+	if explicit && len(rest) > 0 {
+		bin, rest = rest[0], rest[1:]
+	} else {
+		// uvx runs the executable named after the package
+		bin = pkg
+		if i := strings.IndexAny(bin, "=<>!~[;@ "); i > 0 {
+			bin = bin[:i]
+		}
+	}
+	return filepath.Join(binDir, bin+exe), rest, nil
+}
+
+func runInstall(srv *config.MCPServer, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if procEnv := srv.ProcessEnv(); len(procEnv) > 0 {
+		env := cmd.Environ()
+		for k, v := range procEnv {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+		cmd.Env = env
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
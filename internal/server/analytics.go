@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// toolUsage accumulates proxied call statistics for one tool (or one server)
+type toolUsage struct {
+	Calls        int64      `json:"calls"`
+	Errors       int64      `json:"errors"`
+	SchemaTokens int        `json:"schemaTokens"`
+	ResultTokens int64      `json:"resultTokens"`
+	LastCall     *time.Time `json:"lastCall,omitempty"`
+}
+
+type analytics struct {
+	mu      sync.RWMutex
+	servers map[string]*toolUsage
+	tools   map[string]map[string]*toolUsage
+	// day is the local date callsToday counts for
+	day        string
+	callsToday int64
+}
+
+func newAnalytics() *analytics {
+	return &analytics{
+		servers: make(map[string]*toolUsage),
+		tools:   make(map[string]map[string]*toolUsage),
+	}
+}
+
+// estimateTokens converts a payload size into an approximate token count.
+func estimateTokens(size int, charsPerToken float64) int {
+	if size <= 0 {
+		return 0
+	}
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+	return int(math.Ceil(float64(size) / charsPerToken))
+}
+
+func (a *analytics) toolLocked(serverName, toolName string) *toolUsage {
+	byTool, ok := a.tools[serverName]
+	if !ok {
+		byTool = make(map[string]*toolUsage)
+		a.tools[serverName] = byTool
+	}
+	u, ok := byTool[toolName]
+	if !ok {
+		u = &toolUsage{}
+		byTool[toolName] = u
+	}
+	return u
+}
+
+func (a *analytics) serverLocked(serverName string) *toolUsage {
+	u, ok := a.servers[serverName]
+	if !ok {
+		u = &toolUsage{}
+		a.servers[serverName] = u
+	}
+	return u
+}
+
+// recordSchema stores the estimated size of a tool definition as advertised to clients.
+func (a *analytics) recordSchema(serverName string, tool proxiedTool, charsPerToken float64) {
+	raw, _ := json.Marshal(tool)
+	tokens := estimateTokens(len(raw), charsPerToken)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.toolLocked(serverName, tool.Name).SchemaTokens = tokens
+}
+
+// recordCall accounts a finished tools/call and returns the estimated result tokens.
+func (a *analytics) recordCall(serverName, toolName string, result json.RawMessage, callErr error, charsPerToken float64) int {
+	tokens := estimateTokens(len(result), charsPerToken)
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, u := range []*toolUsage{a.serverLocked(serverName), a.toolLocked(serverName, toolName)} {
+		u.Calls++
+		if callErr != nil {
+			u.Errors++
+		}
+		u.ResultTokens += int64(tokens)
+		u.LastCall = &now
+	}
+	if day := now.Format(time.DateOnly); day != a.day {
+		a.day, a.callsToday = day, 0
+	}
+	a.callsToday++
+	return tokens
+}
+
+// todayCalls returns the number of proxied tool calls since local midnight.
+func (a *analytics) todayCalls() int64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.day != time.Now().Format(time.DateOnly) {
+		return 0
+	}
+	return a.callsToday
+}
+
+type serverAnalytics struct {
+	toolUsage
+	Tools map[string]toolUsage `json:"tools"`
+}
+
+func (a *analytics) snapshot() map[string]serverAnalytics {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	result := make(map[string]serverAnalytics)
+	for serverName, byTool := range a.tools {
+		entry := serverAnalytics{Tools: make(map[string]toolUsage)}
+		if u, ok := a.servers[serverName]; ok {
+			entry.toolUsage = *u
+		}
+		for toolName, u := range byTool {
+			entry.Tools[toolName] = *u
+			entry.SchemaTokens += u.SchemaTokens
+		}
+		result[serverName] = entry
+	}
+	return result
+}
+
+// GET /api/analytics - per-server and per-tool call and token statistics
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"tokens":  s.store.GetTokenSettings(),
+		"servers": s.stats.snapshot(),
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// AuditEntry is one tool call made through the proxy with an access token.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Client  string    `json:"client"`
+	Server  string    `json:"server"`
+	Tool    string    `json:"tool"`
+	Session string    `json:"session,omitempty"`
+	Tokens  int       `json:"tokens"`
+}
+
+// AuditLog keeps the audit trail of tool calls.
+type AuditLog interface {
+	AddAudit(e AuditEntry) error
+	// Audit returns up to limit most recent entries at or after since,
+	// newest first; client "" matches every client
+	Audit(since time.Time, client string, limit int) ([]AuditEntry, error)
+}
+
+// SetAuditLog records tool calls made with access tokens in a, which the
+// audit API then queries. Without one the trail only goes to the log.
+func (s *Server) SetAuditLog(a AuditLog) {
+	s.audit = a
+}
+
+func (s *Server) recordAudit(e AuditEntry) {
+	if s.audit == nil || e.Client == "" {
+		return
+	}
+	if err := s.audit.AddAudit(e); err != nil {
+		slog.Warn("record audit entry", "client", e.Client, "err", err)
+	}
+}
+
+// GET /api/audit?since=RFC3339&client=NAME&limit=N - tool calls made with
+// access tokens, newest first
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	if s.audit == nil {
+		writeError(w, i18n.T("the audit log needs --storage sqlite"), 501)
+		return
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, i18n.T("invalid since: %v", err), 400)
+			return
+		}
+		since = t
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, i18n.T("invalid limit"), 400)
+			return
+		}
+		limit = n
+	}
+	entries, err := s.audit.Audit(since, r.URL.Query().Get("client"), limit)
+	if err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, entries)
+}
@@ -0,0 +1,510 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+const (
+	// apiTokenPrefix marks management API secrets, as mcpk_ does proxy ones
+	apiTokenPrefix = "mcpa_"
+	sessionCookie  = "mcp_manager_session"
+	sessionTTL     = 12 * time.Hour
+)
+
+// identity is who made an API request.
+type identity struct {
+	Name string      `json:"name"`
+	Role config.Role `json:"role"`
+	// Token is set when the request used an API token rather than a login
+	Token bool `json:"token,omitempty"`
+	// SSO is set for users signed in through the OIDC provider
+	SSO bool `json:"sso,omitempty"`
+}
+
+type identityKey struct{}
+
+// identityFrom is the caller of an API request; zero when logins are off.
+func identityFrom(ctx context.Context) identity {
+	id, _ := ctx.Value(identityKey{}).(identity)
+	return id
+}
+
+// uiSessions are the logins of the dashboard, kept in memory: a restart
+// signs everyone out.
+type uiSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*uiSession
+}
+
+type uiSession struct {
+	user    string
+	expires time.Time
+	// role is fixed at sign-in for OIDC users, who have no local account
+	role config.Role
+}
+
+func newUISessions() *uiSessions {
+	return &uiSessions{sessions: make(map[string]*uiSession)}
+}
+
+// create starts a session; role is set for OIDC users only.
+func (u *uiSessions) create(user string, role config.Role) (string, error) {
+	id, err := newAccessSecret()
+	if err != nil {
+		return "", err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := time.Now()
+	for id, ss := range u.sessions {
+		if now.After(ss.expires) {
+			delete(u.sessions, id)
+		}
+	}
+	u.sessions[id] = &uiSession{user: user, expires: now.Add(sessionTTL), role: role}
+	return id, nil
+}
+
+func (u *uiSessions) lookup(id string) (uiSession, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	ss, ok := u.sessions[id]
+	if !ok || time.Now().After(ss.expires) {
+		delete(u.sessions, id)
+		return uiSession{}, false
+	}
+	return *ss, true
+}
+
+func (u *uiSessions) remove(id string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.sessions, id)
+}
+
+// dropUser signs out every session of a removed user.
+func (u *uiSessions) dropUser(user string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for id, ss := range u.sessions {
+		if ss.user == user && ss.role == "" {
+			delete(u.sessions, id)
+		}
+	}
+}
+
+// SetShutdownKey lets POST /api/shutdown through without a login when it
+// carries key in X-Shutdown-Key, so --takeover works once users exist.
+func (s *Server) SetShutdownKey(key string) {
+	s.shutdownKey = key
+}
+
+// authenticate finds the caller from the session cookie or an API token.
+func (s *Server) authenticate(r *http.Request) (identity, bool) {
+	if scheme, secret, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		name, tok, ok := s.users.MatchToken(hashAccessToken(strings.TrimSpace(secret)))
+		if !ok {
+			return identity{}, false
+		}
+		return identity{Name: name, Role: tok.Role, Token: true}, true
+	}
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return identity{}, false
+	}
+	ss, ok := s.logins.lookup(c.Value)
+	if !ok {
+		return identity{}, false
+	}
+	if ss.role != "" {
+		// Turning single sign-on off ends its sessions
+		if s.users.OIDC() == nil {
+			s.logins.remove(c.Value)
+			return identity{}, false
+		}
+		return identity{Name: ss.user, Role: ss.role, SSO: true}, true
+	}
+	// The role is read on every request, so changes apply at once
+	user, ok := s.users.Get(ss.user)
+	if !ok {
+		s.logins.remove(c.Value)
+		return identity{}, false
+	}
+	return identity{Name: ss.user, Role: user.Role}, true
+}
+
+// operatorActions are the POST /api/servers/{name}/ACTION calls operators
+// may make: they change what runs, not the config.
+var operatorActions = map[string]bool{
+	"check":        true,
+	"check/cancel": true,
+	"prefetch":     true,
+	"enable":       true,
+	"disable":      true,
+}
+
+// callDataRole may see the payloads of proxied calls: captures, the
+// argument and result previews of call events and the retry queue.
+const callDataRole = config.RoleOperator
+
+// envRole may read the values of servers' env, which hold API keys; like
+// the rest of the config they are for admins, others see the names only.
+const envRole = config.RoleAdmin
+
+// requiredRole is the least role that may make a request. Viewers read
+// status; operators run checks, enable and disable servers and call tools;
+// admins change the config, apply it to tools and manage credentials.
+func requiredRole(r *http.Request) config.Role {
+	path := r.URL.Path
+	read := r.Method == "GET" || r.Method == "HEAD"
+	switch {
+	case strings.HasPrefix(path, "/api/auth/"),
+		strings.HasPrefix(path, "/api/config"),
+		strings.HasPrefix(path, "/api/tokens"),
+		strings.HasPrefix(path, "/api/apply"),
+		strings.HasPrefix(path, "/api/import/"),
+		strings.HasPrefix(path, "/api/tools/") && path != "/api/tools/sync",
+		path == "/api/audit",
+		path == "/api/shutdown":
+		return config.RoleAdmin
+	}
+	if rest, ok := strings.CutPrefix(path, "/api/servers/"); ok {
+		_, action, _ := strings.Cut(rest, "/")
+		captures := action == "captures" || strings.HasPrefix(action, "captures/")
+		switch {
+		case captures && (read || r.Method == "DELETE"):
+			// Captures hold whole requests and responses
+			return callDataRole
+		case action == "resources/read":
+			// Reading a resource fetches upstream data, like a tool call
+			return config.RoleOperator
+		case read:
+			return config.RoleViewer
+		case r.Method == "POST" && (operatorActions[action] ||
+			strings.HasPrefix(action, "tools/") && strings.HasSuffix(action, "/call")):
+			return config.RoleOperator
+		}
+		return config.RoleAdmin
+	}
+	if path == "/api/events" && r.URL.Query().Get("calls") != "" ||
+		read && (path == "/api/queue" || strings.HasPrefix(path, "/api/queue/")) {
+		// Queued calls keep their arguments and results
+		return callDataRole
+	}
+	if read {
+		return config.RoleViewer
+	}
+	switch {
+	case path == "/api/check", strings.HasPrefix(path, "/api/checks/"),
+		path == "/api/digest", path == "/api/notifiers/test", path == "/api/tray/open":
+		return config.RoleOperator
+	}
+	return config.RoleAdmin
+}
+
+// mayWatchCalls reports whether the caller may subscribe to call events;
+// /ws subscribes after the upgrade, out of requiredRole's sight.
+func (s *Server) mayWatchCalls(r *http.Request) bool {
+	return !s.users.Enabled() || identityFrom(r.Context()).Role.Allows(callDataRole)
+}
+
+// mayReadEnv reports whether the caller sees the values of servers' env.
+func (s *Server) mayReadEnv(r *http.Request) bool {
+	return !s.users.Enabled() || identityFrom(r.Context()).Role.Allows(envRole)
+}
+
+// viewInfo returns info with its env values masked unless showEnv.
+func viewInfo(info *manager.ServerInfo, showEnv bool) *manager.ServerInfo {
+	if showEnv || len(info.Config.Env) == 0 {
+		return info
+	}
+	cp := *info
+	cp.Config.Env = make(map[string]string, len(info.Config.Env))
+	for k := range info.Config.Env {
+		cp.Config.Env[k] = redactedPlaceholder
+	}
+	return &cp
+}
+
+// viewInfos applies viewInfo to every server.
+func viewInfos(infos map[string]*manager.ServerInfo, showEnv bool) map[string]*manager.ServerInfo {
+	if showEnv {
+		return infos
+	}
+	out := make(map[string]*manager.ServerInfo, len(infos))
+	for name, info := range infos {
+		out[name] = viewInfo(info, false)
+	}
+	return out
+}
+
+// openPaths answer without a login: signing in and the API description.
+var openPaths = map[string]bool{
+	"/api/auth/login":         true,
+	"/api/auth/logout":        true,
+	"/api/auth/me":            true,
+	"/api/auth/methods":       true,
+	"/api/auth/oidc/login":    true,
+	"/api/auth/oidc/callback": true,
+	"/api/openapi.json":       true,
+}
+
+// authMiddleware enforces roles on the API and the event streams once a
+// user exists. The static UI stays public: it asks for a login itself.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if !strings.HasPrefix(path, "/api/") && path != "/ws" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if path == "/api/shutdown" && s.shutdownKey != "" &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Shutdown-Key")), []byte(s.shutdownKey)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Until users.json reads again nobody can be checked, so nothing
+		// is served
+		if err := s.users.Err(); err != nil {
+			writeError(w, i18n.T("users file cannot be read: %v", err), http.StatusServiceUnavailable)
+			return
+		}
+		if openPaths[path] || !s.users.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := s.authenticate(r)
+		if !ok {
+			writeError(w, i18n.T("login required"), http.StatusUnauthorized)
+			return
+		}
+		if need := requiredRole(r); !id.Role.Allows(need) {
+			writeError(w, i18n.T("the %s role is required", need), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+	})
+}
+
+// POST /api/auth/login - {"name", "password"}; sets the session cookie
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	var req struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	role, ok := s.users.Authenticate(req.Name, req.Password)
+	if !ok {
+		writeError(w, i18n.T("invalid user name or password"), http.StatusUnauthorized)
+		return
+	}
+	if err := s.startSession(w, r, req.Name, ""); err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, identity{Name: req.Name, Role: role})
+}
+
+// startSession signs a browser in by setting the session cookie.
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, user string, role config.Role) error {
+	id, err := s.logins.create(user, role)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     s.basePath + "/",
+		MaxAge:   int(sessionTTL / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// GET /api/auth/methods - how the login form may sign in
+func (s *Server) handleAuthMethods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, map[string]bool{"password": s.users.HasPasswords(), "oidc": s.users.OIDC() != nil})
+}
+
+// POST /api/auth/logout
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		s.logins.remove(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: s.basePath + "/", MaxAge: -1})
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// GET /api/auth/me - the caller; {"auth": false} while logins are off
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	if !s.users.Enabled() {
+		writeJSON(w, map[string]any{"auth": false, "role": config.RoleAdmin})
+		return
+	}
+	id, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, i18n.T("login required"), http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]any{"auth": true, "name": id.Name, "role": id.Role, "token": id.Token, "sso": id.SSO})
+}
+
+type userInfo struct {
+	Name      string      `json:"name"`
+	Role      config.Role `json:"role"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// GET /api/auth/users - the accounts
+// POST /api/auth/users - create or update one: {"name", "role", "password"}
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		users := s.users.List()
+		list := make([]userInfo, 0, len(users))
+		for name, u := range users {
+			list = append(list, userInfo{Name: name, Role: u.Role, CreatedAt: u.CreatedAt})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		writeJSON(w, list)
+	case "POST":
+		var req struct {
+			Name     string      `json:"name"`
+			Role     config.Role `json:"role"`
+			Password string      `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		if err := s.users.Put(strings.TrimSpace(req.Name), req.Role, req.Password); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+// DELETE /api/auth/users/{name} - remove an account and sign it out
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/api/auth/users/")
+	if err := s.users.Remove(name); err != nil {
+		status := 400
+		if _, ok := s.users.Get(name); !ok {
+			status = 404
+		}
+		writeError(w, err.Error(), status)
+		return
+	}
+	s.logins.dropUser(name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type apiTokenInfo struct {
+	Name      string      `json:"name"`
+	Role      config.Role `json:"role"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// GET /api/auth/tokens - management API tokens (without secrets)
+// POST /api/auth/tokens - issue one: {"name", "role"}; the secret is only
+// returned here
+func (s *Server) handleAPITokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		tokens := s.users.Tokens()
+		list := make([]apiTokenInfo, 0, len(tokens))
+		for name, tok := range tokens {
+			list = append(list, apiTokenInfo{Name: name, Role: tok.Role, CreatedAt: tok.CreatedAt})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		writeJSON(w, list)
+	case "POST":
+		var req struct {
+			Name string      `json:"name"`
+			Role config.Role `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || strings.Contains(req.Name, "/") {
+			writeError(w, i18n.T("token name is required and must not contain '/'"), 400)
+			return
+		}
+		if !req.Role.Valid() {
+			writeError(w, i18n.T("unknown role %q", req.Role), 400)
+			return
+		}
+		if _, exists := s.users.Tokens()[req.Name]; exists {
+			writeError(w, i18n.T("token %q already exists", req.Name), http.StatusConflict)
+			return
+		}
+		secret, err := newAccessSecret()
+		if err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		secret = apiTokenPrefix + strings.TrimPrefix(secret, accessTokenPrefix)
+		tok := &config.APIToken{Hash: hashAccessToken(secret), Role: req.Role, CreatedAt: time.Now().UTC()}
+		if err := s.users.SetToken(req.Name, tok); err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		writeJSON(w, map[string]string{"name": req.Name, "role": string(req.Role), "token": secret})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+// DELETE /api/auth/tokens/{name} - revoke a management API token
+func (s *Server) handleAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	if err := s.users.RemoveToken(strings.TrimPrefix(r.URL.Path, "/api/auth/tokens/")); err != nil {
+		writeError(w, err.Error(), 404)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
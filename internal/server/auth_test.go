@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+func TestRequiredRole(t *testing.T) {
+	const (
+		viewer   = config.RoleViewer
+		operator = config.RoleOperator
+		admin    = config.RoleAdmin
+	)
+	tests := []struct {
+		method, path string
+		want         config.Role
+	}{
+		// Accounts, tokens, config and the tool configs are admin-only
+		{"GET", "/api/auth/users", admin},
+		{"POST", "/api/auth/users", admin},
+		{"DELETE", "/api/auth/users/bob", admin},
+		{"POST", "/api/auth/tokens", admin},
+		{"GET", "/api/config", admin},
+		{"PUT", "/api/config", admin},
+		{"GET", "/api/config/export", admin},
+		{"GET", "/api/tokens", admin},
+		{"POST", "/api/tokens", admin},
+		{"DELETE", "/api/tokens/ci", admin},
+		{"GET", "/api/apply/claude", admin},
+		{"POST", "/api/apply/claude", admin},
+		{"POST", "/api/apply-all", admin},
+		{"GET", "/api/import/claude-desktop", admin},
+		{"POST", "/api/import/claude-desktop", admin},
+		{"GET", "/api/tools/claude", admin},
+		{"POST", "/api/tools/claude/clean", admin},
+		{"GET", "/api/audit", admin},
+		{"POST", "/api/shutdown", admin},
+
+		// Tool sync status is only a read
+		{"GET", "/api/tools/sync", viewer},
+		{"POST", "/api/tools/sync", admin},
+
+		// Servers: viewers read, operators act, admins edit
+		{"GET", "/api/servers", viewer},
+		{"GET", "/api/servers/fs", viewer},
+		{"HEAD", "/api/servers/fs", viewer},
+		{"GET", "/api/servers/fs/logs", viewer},
+		{"GET", "/api/servers/fs/history", viewer},
+		{"GET", "/api/servers/fs/resources", viewer},
+		{"GET", "/api/servers/fs/resources/read?uri=file:///a", operator},
+		{"POST", "/api/servers/fs/check", operator},
+		{"POST", "/api/servers/fs/check/cancel", operator},
+		{"POST", "/api/servers/fs/prefetch", operator},
+		{"POST", "/api/servers/fs/enable", operator},
+		{"POST", "/api/servers/fs/disable", operator},
+		{"POST", "/api/servers/fs/tools/echo/call", operator},
+		{"POST", "/api/servers/fs/tools/echo/toggle", admin},
+		{"POST", "/api/servers/fs/rename", admin},
+		{"POST", "/api/servers/fs/trust", admin},
+		{"PUT", "/api/servers/fs", admin},
+		{"DELETE", "/api/servers/fs", admin},
+
+		// Call payloads need the operator role however they are reached
+		{"GET", "/api/servers/fs/captures", operator},
+		{"GET", "/api/servers/fs/captures/2026-01-01.jsonl", operator},
+		{"DELETE", "/api/servers/fs/captures", operator},
+		{"GET", "/api/events", viewer},
+		{"GET", "/api/events?calls=fs", operator},
+		{"GET", "/api/events?calls=*", operator},
+		{"GET", "/api/events?calls=", viewer},
+		{"GET", "/api/queue", operator},
+		{"GET", "/api/queue/abc", operator},
+
+		// Other reads are open to viewers
+		{"GET", "/api/summary", viewer},
+		{"GET", "/api/tools", viewer},
+		{"GET", "/api/tags", viewer},
+		{"GET", "/api/tray", viewer},
+		{"GET", "/ws", viewer},
+
+		// Other writes: a few operator actions, the rest admin
+		{"POST", "/api/check", operator},
+		{"POST", "/api/checks/abc/cancel", operator},
+		{"POST", "/api/digest", operator},
+		{"POST", "/api/notifiers/test", operator},
+		{"POST", "/api/tray/open", operator},
+		{"POST", "/api/servers", admin},
+		{"PUT", "/api/settings", admin},
+		{"POST", "/api/profiles/dev", admin},
+		{"DELETE", "/api/queue/abc", admin},
+		{"POST", "/api/catalog/filesystem", admin},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := requiredRole(r); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCallDataRoleMatchesCaptures(t *testing.T) {
+	captures := requiredRole(httptest.NewRequest("GET", "/api/servers/fs/captures", nil))
+	events := requiredRole(httptest.NewRequest("GET", "/api/events?calls=*", nil))
+	if captures != callDataRole || events != callDataRole {
+		t.Errorf("captures need %s and call events %s, want both %s", captures, events, callDataRole)
+	}
+}
+
+func TestWSCallSubscriptionNeedsCallDataRole(t *testing.T) {
+	for _, denied := range []bool{false, true} {
+		s := &Server{clients: make(map[*eventClient]bool)}
+		c := newEventClient("test")
+		c.callsDenied = denied
+		s.clients[c] = true
+		s.handleWSCommand(c, []byte(`{"type":"subscribe_calls","server":"fs"}`))
+
+		var ack struct {
+			OK bool `json:"ok"`
+		}
+		json.Unmarshal(<-c.send, &ack)
+		if ack.OK == denied || c.watchesCalls("fs") == denied {
+			t.Errorf("denied=%v: ack ok=%v, watching=%v", denied, ack.OK, c.watchesCalls("fs"))
+		}
+	}
+}
+
+func TestAuthLockedWhileUsersFileUnreadable(t *testing.T) {
+	s := &Server{users: config.NewUsers(t.TempDir()), logins: newUISessions()}
+	h := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(path string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+
+	if code := serve("/api/servers"); code != http.StatusOK {
+		t.Fatalf("no users file: got %d, want the API open", code)
+	}
+	os.WriteFile(s.users.Path(), []byte(`{"users":`), 0600)
+	for _, path := range []string{"/api/servers", "/api/config", "/api/auth/me", "/ws"} {
+		if code := serve(path); code != http.StatusServiceUnavailable {
+			t.Errorf("%s with a truncated users file: got %d, want 503", path, code)
+		}
+	}
+	if code := serve("/"); code != http.StatusOK {
+		t.Errorf("static UI: got %d, want 200", code)
+	}
+}
+
+func TestViewInfoMasksEnv(t *testing.T) {
+	info := &manager.ServerInfo{Name: "fs", Config: config.MCPServer{Command: "fs", Env: map[string]string{"API_KEY": "sk-123"}}}
+	if got := viewInfo(info, true); got.Config.Env["API_KEY"] != "sk-123" {
+		t.Errorf("admin view masked the env: %v", got.Config.Env)
+	}
+	got := viewInfos(map[string]*manager.ServerInfo{"fs": info}, false)["fs"]
+	if v, ok := got.Config.Env["API_KEY"]; !ok || v != redactedPlaceholder {
+		t.Errorf("viewer view = %v, want the key with its value masked", got.Config.Env)
+	}
+	if info.Config.Env["API_KEY"] != "sk-123" {
+		t.Error("masking changed the original info")
+	}
+}
+
+func TestServerUpdateMasksEnvPerClient(t *testing.T) {
+	s := &Server{clients: make(map[*eventClient]bool)}
+	admin, viewer := newEventClient("admin"), newEventClient("viewer")
+	viewer.hideEnv = true
+	s.clients[admin], s.clients[viewer] = true, true
+
+	s.broadcastServer("fs", &manager.ServerInfo{Name: "fs", Config: config.MCPServer{Env: map[string]string{"API_KEY": "sk-123"}}})
+	if msg := string(<-admin.send); !strings.Contains(msg, "sk-123") {
+		t.Errorf("admin got %s", msg)
+	}
+	if msg := string(<-viewer.send); strings.Contains(msg, "sk-123") || !strings.Contains(msg, "API_KEY") {
+		t.Errorf("viewer got %s", msg)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// batchResponseWriter captures the reply to one request of a batch. It is
+// not a Flusher, so requests inside a batch never switch to SSE.
+type batchResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *batchResponseWriter) Header() http.Header         { return b.header }
+func (b *batchResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *batchResponseWriter) WriteHeader(int)             {}
+
+// isNotification reports whether req is a notification, which gets no
+// response, not even an error.
+func isNotification(req rpcReq) bool {
+	return req.ID == 0 && strings.HasPrefix(req.Method, "notifications/")
+}
+
+// handleMCPBatch dispatches each request of a JSON-RPC batch in order and
+// replies with an array of the responses; a batch of only notifications gets
+// 202 Accepted.
+func (s *Server) handleMCPBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		s.writeRPCError(w, 0, -32700, "parse error")
+		return
+	}
+	if len(raws) == 0 {
+		s.writeRPCError(w, 0, -32600, "empty batch")
+		return
+	}
+
+	sessionID := strings.TrimSpace(r.Header.Get("MCP-Session-Id"))
+	responses := make([]json.RawMessage, 0, len(raws))
+	for _, raw := range raws {
+		var req rpcReq
+		if err := json.Unmarshal(raw, &req); err != nil {
+			msg, _ := json.Marshal(rpcResp{JSONRPC: "2.0", Error: &rpcErr{Code: -32600, Message: "invalid request"}})
+			responses = append(responses, msg)
+			continue
+		}
+		bw := &batchResponseWriter{header: make(http.Header)}
+		s.serveMCPRequest(bw, r, req)
+		if sid := bw.header.Get("MCP-Session-Id"); sid != "" {
+			sessionID = sid
+		}
+		if isNotification(req) {
+			continue
+		}
+		out := bytes.TrimSpace(bw.body.Bytes())
+		if len(out) == 0 || !json.Valid(out) {
+			// A request answered with a plain-text error still gets an entry
+			msg := strings.TrimSpace(string(out))
+			if msg == "" {
+				msg = "internal error"
+			}
+			out, _ = json.Marshal(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32603, Message: msg}})
+		}
+		responses = append(responses, json.RawMessage(out))
+	}
+
+	if sessionID != "" {
+		w.Header().Set("MCP-Session-Id", sessionID)
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(responses)
+}
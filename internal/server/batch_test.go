@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postBatch(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	s := &Server{mcpState: make(map[string]*mcpSession)}
+	r := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.handleMCPBatch(w, r, []byte(body))
+	return w
+}
+
+func TestBatchEmpty(t *testing.T) {
+	w := postBatch(t, `[]`)
+	var resp rpcResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	if resp.Error == nil || resp.Error.Code != -32600 {
+		t.Errorf("got %s, want an invalid request error", w.Body.String())
+	}
+}
+
+func TestBatchMixed(t *testing.T) {
+	w := postBatch(t, `[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"tools/list"},
+		{"jsonrpc":"2.0","id":3,"method":"no/such"},
+		5
+	]`)
+	var resps []rpcResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resps); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	want := []struct {
+		id   int
+		code int
+	}{
+		{1, 0},
+		{2, -32000}, // no session
+		{3, -32601},
+		{0, -32600},
+	}
+	if len(resps) != len(want) {
+		t.Fatalf("got %d responses, want %d: %s", len(resps), len(want), w.Body.String())
+	}
+	for i, resp := range resps {
+		code := 0
+		if resp.Error != nil {
+			code = resp.Error.Code
+		}
+		if resp.ID != want[i].id || code != want[i].code {
+			t.Errorf("response %d: id %d code %d, want id %d code %d", i, resp.ID, code, want[i].id, want[i].code)
+		}
+	}
+}
+
+func TestBatchNotificationsOnly(t *testing.T) {
+	w := postBatch(t, `[
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}
+	]`)
+	if w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Errorf("got %d %q, want 202 and no body", w.Code, w.Body.String())
+	}
+}
+
+func TestStdioBatch(t *testing.T) {
+	tests := []struct {
+		name, in string
+		want     []string
+	}{
+		{"empty", `[]`, []string{`{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"}}`}},
+		{
+			"mixed",
+			`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"no/such"}]`,
+			[]string{`[{"jsonrpc":"2.0","id":1,"result":{}},{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: no/such"}}]`},
+		},
+		{
+			"notifications only",
+			`[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","method":"notifications/cancelled"}]`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := (&Server{}).runMCPStdio(strings.NewReader(tt.in+"\n"), &out); err != nil {
+				t.Fatal(err)
+			}
+			want := ""
+			for _, line := range tt.want {
+				want += line + "\n"
+			}
+			if out.String() != want {
+				t.Errorf("got %q, want %q", out.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+type breakerState string
+
+const (
+	breakerClosed   breakerState = "closed"
+	breakerOpen     breakerState = "open"
+	breakerHalfOpen breakerState = "half-open"
+)
+
+// breaker tracks consecutive failures of one upstream server. Once open, calls
+// fail fast until the open period ends; then a single call is let through as
+// a probe, and its outcome closes or re-opens the breaker.
+type breaker struct {
+	State     breakerState `json:"state"`
+	Failures  int          `json:"failures"`
+	LastError string       `json:"lastError,omitempty"`
+	OpenUntil *time.Time   `json:"openUntil,omitempty"`
+	probing   bool
+}
+
+type breakers struct {
+	store *config.Store
+	mu    sync.Mutex
+	m     map[string]*breaker
+}
+
+func newBreakers(store *config.Store) *breakers {
+	return &breakers{store: store, m: make(map[string]*breaker)}
+}
+
+func (bs *breakers) get(name string) *breaker {
+	b, ok := bs.m[name]
+	if !ok {
+		b = &breaker{State: breakerClosed}
+		bs.m[name] = b
+	}
+	return b
+}
+
+// allow fails fast while the server's breaker is open.
+func (bs *breakers) allow(name string) error {
+	if bs.store.GetCircuitBreakerSettings().Disabled {
+		return nil
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.get(name)
+	switch b.State {
+	case breakerOpen:
+		if wait := time.Until(*b.OpenUntil); wait > 0 {
+			return fmt.Errorf("server %q is unavailable (circuit open after %d failures, last: %s); retrying in %ds",
+				name, b.Failures, b.LastError, int(wait.Seconds())+1)
+		}
+		b.State = breakerHalfOpen
+		b.probing = true
+	case breakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("server %q is unavailable (circuit half-open, probe in progress)", name)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// cancel gives back a probe slot when the call never reached the server.
+func (bs *breakers) cancel(name string) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if b, ok := bs.m[name]; ok && b.State == breakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// record updates the breaker with a call outcome. JSON-RPC errors returned
+// by the server prove it is alive and count as success.
+func (bs *breakers) record(name string, err error) {
+	var rpcErr *upstreamError
+	failed := err != nil && !errors.As(err, &rpcErr)
+	settings := bs.store.GetCircuitBreakerSettings()
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.get(name)
+	b.probing = false
+	if !failed {
+		if b.State != breakerClosed {
+			slog.Info("circuit closed", "server", name)
+		}
+		*b = breaker{State: breakerClosed}
+		return
+	}
+	b.Failures++
+	b.LastError = err.Error()
+	if settings.Disabled {
+		return
+	}
+	if b.State == breakerHalfOpen || (b.State == breakerClosed && b.Failures >= settings.FailureThreshold) {
+		until := time.Now().Add(time.Duration(settings.OpenSeconds) * time.Second)
+		b.State = breakerOpen
+		b.OpenUntil = &until
+		slog.Warn("circuit opened", "server", name, "failures", b.Failures, "err", err)
+	}
+}
+
+func (bs *breakers) snapshot() map[string]breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	out := make(map[string]breaker, len(bs.m))
+	for name, b := range bs.m {
+		out[name] = *b
+	}
+	return out
+}
+
+// GET /api/breakers - circuit breaker state per upstream server
+func (s *Server) handleBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, s.breakers.snapshot())
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// budgetNote ends a tool result cut short by the session token budget.
+const budgetNote = "[result truncated: session token budget exhausted]"
+
+// budgetFit is how many of a result's tokens fit a budget of which used are
+// spent already; a budget of 0 is no limit.
+func budgetFit(tokens, used, budget int) int {
+	if budget <= 0 {
+		return tokens
+	}
+	return max(0, min(tokens, budget-used))
+}
+
+// chargeSessionTokens adds what fits of a result's tokens to the session
+// and returns that; the check and the charge happen under one lock, so
+// concurrent calls cannot both spend the same remainder.
+func (s *Server) chargeSessionTokens(sessionID string, tokens, budget int) int {
+	s.mcpMu.Lock()
+	defer s.mcpMu.Unlock()
+	ss, ok := s.mcpState[sessionID]
+	if !ok {
+		return tokens
+	}
+	fit := budgetFit(tokens, ss.ResultTokens, budget)
+	ss.ResultTokens += fit
+	return fit
+}
+
+// trimResult cuts a tools/call result down to about tokens tokens: text
+// content is shortened, other content after the cut dropped, and a note
+// tells the client why. structuredContent cannot be cut and is dropped.
+func trimResult(result json.RawMessage, tokens int, charsPerToken float64) json.RawMessage {
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+	left := int(float64(tokens) * charsPerToken)
+
+	var doc map[string]any
+	if json.Unmarshal(result, &doc) != nil {
+		doc = map[string]any{}
+	}
+	content, _ := doc["content"].([]any)
+	var kept []any
+	for _, item := range content {
+		m, _ := item.(map[string]any)
+		if text, ok := m["text"].(string); ok && m["type"] == "text" {
+			if len(text) > left {
+				m["text"] = cutUTF8(text, left) + "…"
+				kept = append(kept, m)
+				break
+			}
+			left -= len(text)
+			kept = append(kept, m)
+			continue
+		}
+		raw, _ := json.Marshal(item)
+		if len(raw) > left {
+			break
+		}
+		left -= len(raw)
+		kept = append(kept, item)
+	}
+	doc["content"] = append(kept, map[string]any{"type": "text", "text": budgetNote})
+	delete(doc, "structuredContent")
+	out, _ := json.Marshal(doc)
+	return out
+}
+
+// cutUTF8 shortens s to at most n bytes without splitting a character.
+func cutUTF8(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBudgetFit(t *testing.T) {
+	tests := []struct {
+		name                 string
+		tokens, used, budget int
+		want                 int
+	}{
+		{"no budget", 500, 900, 0, 500},
+		{"fits", 100, 200, 1000, 100},
+		{"fits exactly", 800, 200, 1000, 800},
+		{"overshoots", 5000, 200, 1000, 800},
+		{"spent", 100, 1000, 1000, 0},
+		{"overspent", 100, 1200, 1000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := budgetFit(tt.tokens, tt.used, tt.budget); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChargeSessionTokens(t *testing.T) {
+	s := &Server{mcpState: map[string]*mcpSession{"s1": {ResultTokens: 900}}}
+	if got := s.chargeSessionTokens("s1", 300, 1000); got != 100 {
+		t.Errorf("charged %d, want 100", got)
+	}
+	if got := s.chargeSessionTokens("s1", 300, 1000); got != 0 {
+		t.Errorf("charged %d after the budget was spent, want 0", got)
+	}
+	if used := s.mcpState["s1"].ResultTokens; used != 1000 {
+		t.Errorf("session used %d tokens, want 1000", used)
+	}
+	if got := s.chargeSessionTokens("gone", 300, 1000); got != 300 {
+		t.Errorf("charged %d without a session, want 300", got)
+	}
+}
+
+func TestTrimResult(t *testing.T) {
+	tests := []struct {
+		name, result string
+		tokens       int
+		want         string
+	}{
+		{
+			"long text is cut",
+			`{"content":[{"type":"text","text":"abcdefghij"}]}`, 1,
+			`{"content":[{"text":"abcd…","type":"text"},{"text":"` + budgetNote + `","type":"text"}]}`,
+		},
+		{
+			"later items are dropped",
+			`{"content":[{"type":"text","text":"ab"},{"type":"text","text":"cdefgh"},{"type":"text","text":"ij"}],"structuredContent":{"a":1}}`, 1,
+			`{"content":[{"text":"ab","type":"text"},{"text":"cd…","type":"text"},{"text":"` + budgetNote + `","type":"text"}]}`,
+		},
+		{
+			"no characters are split",
+			`{"content":[{"type":"text","text":"яяя"}]}`, 1,
+			`{"content":[{"text":"яя…","type":"text"},{"text":"` + budgetNote + `","type":"text"}]}`,
+		},
+		{
+			"nothing left",
+			`{"content":[{"type":"image","data":"AAAA","mimeType":"image/png"}],"isError":false}`, 0,
+			`{"content":[{"text":"` + budgetNote + `","type":"text"}],"isError":false}`,
+		},
+		{
+			"not an object",
+			`"big"`, 0,
+			`{"content":[{"text":"` + budgetNote + `","type":"text"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trimResult(json.RawMessage(tt.result), tt.tokens, 4)
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// captureMaxBytes is the size at which a capture file is rotated to
+// <server>.1.jsonl, replacing the previous rotation.
+const captureMaxBytes = 16 << 20
+
+// captureSecretKeyRe matches object keys whose string values are redacted
+var captureSecretKeyRe = regexp.MustCompile(`(?i)token|secret|passw|api[_-]?key|authorization|credential|cookie`)
+
+// captureRecord is one line of a capture file: a JSON-RPC message sent to
+// or received from the upstream server.
+type captureRecord struct {
+	Time      time.Time `json:"time"`
+	Server    string    `json:"server"`
+	Transport string    `json:"transport"`
+	// Dir is "send" (to the server) or "recv" (from it)
+	Dir     string          `json:"dir"`
+	Message json.RawMessage `json:"message,omitempty"`
+	// Raw holds a received line that is not valid JSON
+	Raw string `json:"raw,omitempty"`
+}
+
+// captures appends the upstream traffic of servers with "capture" set to
+// <dir>/<server>.jsonl.
+type captures struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func newCaptures(dir string) *captures {
+	return &captures{dir: dir}
+}
+
+func (c *captures) path(server string) string {
+	return filepath.Join(c.dir, strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(server)+".jsonl")
+}
+
+func (c *captures) write(rec captureRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		slog.Warn("capture", "server", rec.Server, "err", err)
+		return
+	}
+	path := c.path(rec.Server)
+	if st, err := os.Stat(path); err == nil && st.Size() > captureMaxBytes {
+		os.Rename(path, strings.TrimSuffix(path, ".jsonl")+".1.jsonl")
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		slog.Warn("capture", "server", rec.Server, "err", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// captureTap records the messages of one proxied request.
+type captureTap struct {
+	c         *captures
+	server    string
+	transport string
+	// secrets are the server's env values, replaced wherever they show up
+	secrets []string
+}
+
+type captureTapKey struct{}
+
+// withCapture adds a tap to ctx when the server has capture enabled.
+func (s *Server) withCapture(ctx context.Context, serverName string, srv *config.MCPServer) context.Context {
+	if !srv.Capture || s.captures == nil {
+		return ctx
+	}
+	tap := &captureTap{c: s.captures, server: serverName, transport: "stdio"}
+	switch {
+	case srv.IsNATS():
+		tap.transport = "nats"
+	case !isStdioServer(srv):
+		tap.transport = "http"
+	}
+	for _, v := range srv.ProcessEnv() {
+		if len(v) >= 6 {
+			tap.secrets = append(tap.secrets, v)
+		}
+	}
+	// Longer values first, so one containing another is replaced whole
+	sort.Slice(tap.secrets, func(i, j int) bool { return len(tap.secrets[i]) > len(tap.secrets[j]) })
+	return context.WithValue(ctx, captureTapKey{}, tap)
+}
+
+// captured records msg on the tap of ctx, if any.
+func captured(ctx context.Context, dir string, msg []byte) {
+	tap, _ := ctx.Value(captureTapKey{}).(*captureTap)
+	if tap == nil {
+		return
+	}
+	rec := captureRecord{Time: time.Now(), Server: tap.server, Transport: tap.transport, Dir: dir}
+	var v any
+	if err := json.Unmarshal(msg, &v); err != nil {
+		rec.Raw = tap.redact(strings.TrimSpace(string(msg)))
+	} else {
+		rec.Message, _ = json.Marshal(tap.redactValue(v, false))
+	}
+	tap.c.write(rec)
+}
+
+func (t *captureTap) redact(text string) string {
+	for _, secret := range t.secrets {
+		text = strings.ReplaceAll(text, secret, redactedPlaceholder)
+	}
+	return redactSecrets(text)
+}
+
+// redactValue redacts a decoded message like the secret scan does, and also
+// masks the server's env values and every string under a secret-looking key.
+func (t *captureTap) redactValue(v any, secretKey bool) any {
+	switch val := v.(type) {
+	case string:
+		if secretKey && val != "" {
+			return redactedPlaceholder
+		}
+		return t.redact(val)
+	case []any:
+		for i := range val {
+			val[i] = t.redactValue(val[i], secretKey)
+		}
+		return val
+	case map[string]any:
+		for k := range val {
+			val[k] = t.redactValue(val[k], secretKey || captureSecretKeyRe.MatchString(k))
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// captureFile is a capture file as listed by the API.
+type captureFile struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// GET /api/servers/{name}/captures - list the server's capture files
+// GET /api/servers/{name}/captures/{file} - download one
+// DELETE /api/servers/{name}/captures - delete them
+func (s *Server) handleServerCaptures(w http.ResponseWriter, r *http.Request, name, file string) {
+	if _, ok := s.store.GetServer(name); !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	current := s.captures.path(name)
+	paths := []string{current, strings.TrimSuffix(current, ".jsonl") + ".1.jsonl"}
+	switch r.Method {
+	case "GET":
+		if file != "" {
+			for _, path := range paths {
+				if filepath.Base(path) == file {
+					w.Header().Set("Content-Type", "application/x-ndjson")
+					w.Header().Set("Content-Disposition", `attachment; filename="`+file+`"`)
+					http.ServeFile(w, r, path)
+					return
+				}
+			}
+			writeError(w, i18n.T("not found"), 404)
+			return
+		}
+		files := make([]captureFile, 0)
+		for _, path := range paths {
+			if st, err := os.Stat(path); err == nil {
+				files = append(files, captureFile{Name: filepath.Base(path), Size: st.Size(), Modified: st.ModTime()})
+			}
+		}
+		writeJSON(w, files)
+	case "DELETE":
+		s.captures.mu.Lock()
+		for _, path := range paths {
+			os.Remove(path)
+		}
+		s.captures.mu.Unlock()
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Chaos configures fault injection into upstream responses of the proxy,
+// for soak tests of clients, retries and circuit breakers. Rates are
+// probabilities (0-1) per forwarded request.
+type Chaos struct {
+	// Servers limits the faults to these upstream servers (empty = all)
+	Servers []string
+	// Seed makes a run reproducible
+	Seed int64
+	// DelayRate of responses held back by up to MaxDelay
+	DelayRate float64
+	MaxDelay  time.Duration
+	// DropRate of responses discarded; the caller waits until its timeout
+	DropRate float64
+	// CorruptRate of responses mangled: truncated into a decode error, or
+	// replaced by an empty result
+	CorruptRate float64
+}
+
+// chaosMonkey applies a Chaos with its own seeded source.
+type chaosMonkey struct {
+	cfg     Chaos
+	servers map[string]bool
+	mu      sync.Mutex
+	rng     *rand.Rand
+}
+
+func newChaosMonkey(cfg Chaos) *chaosMonkey {
+	c := &chaosMonkey{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+	if len(cfg.Servers) > 0 {
+		c.servers = make(map[string]bool, len(cfg.Servers))
+		for _, name := range cfg.Servers {
+			c.servers[name] = true
+		}
+	}
+	return c
+}
+
+// EnableChaos turns on fault injection for proxied upstream requests.
+func (s *Server) EnableChaos(cfg Chaos) {
+	s.chaos = newChaosMonkey(cfg)
+}
+
+// roll draws the faults of one request: a delay (0 for none), a drop and a
+// corruption mode (0 none, 1 truncate, 2 empty result).
+func (c *chaosMonkey) roll() (delay time.Duration, drop bool, corrupt int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cfg.MaxDelay > 0 && c.rng.Float64() < c.cfg.DelayRate {
+		delay = time.Duration(c.rng.Int63n(int64(c.cfg.MaxDelay)))
+	}
+	drop = c.rng.Float64() < c.cfg.DropRate
+	if c.rng.Float64() < c.cfg.CorruptRate {
+		corrupt = 1 + c.rng.Intn(2)
+	}
+	return delay, drop, corrupt
+}
+
+// apply injects faults into the outcome of an upstream request.
+func (c *chaosMonkey) apply(ctx context.Context, serverName, method string, result json.RawMessage, err error) (json.RawMessage, error) {
+	if c == nil || (c.servers != nil && !c.servers[serverName]) {
+		return result, err
+	}
+	delay, drop, corrupt := c.roll()
+	if delay > 0 {
+		slog.Info("chaos: delaying response", "server", serverName, "method", method, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if drop {
+		slog.Info("chaos: dropping response", "server", serverName, "method", method)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return result, err
+	}
+	switch corrupt {
+	case 1:
+		slog.Info("chaos: truncating response", "server", serverName, "method", method)
+		return nil, fmt.Errorf("decode response: unexpected end of JSON input (chaos, %d of %d bytes)", len(result)/2, len(result))
+	case 2:
+		slog.Info("chaos: emptying response", "server", serverName, "method", method)
+		return json.RawMessage(`{}`), nil
+	}
+	return result, nil
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// slotWaitSamples is how many recent queue waits a server keeps for its
+// wait-time percentiles
+const slotWaitSamples = 256
+
+// serverSlots limits the calls in flight per upstream server with
+// maxConcurrent set
+type serverSlots struct {
+	mu     sync.Mutex
+	queues map[string]*slotQueue
+}
+
+func newServerSlots() *serverSlots {
+	return &serverSlots{queues: make(map[string]*slotQueue)}
+}
+
+// slotQueue holds the call slots of one server. Calls that find them all
+// taken wait per session and get freed slots round-robin across sessions,
+// so a client firing many calls cannot starve the others.
+type slotQueue struct {
+	limit    int
+	inFlight int
+	// waiting holds the waiters of each session in arrival order; turn is
+	// the round-robin of sessions with waiters
+	waiting map[string][]*slotWaiter
+	turn    []string
+
+	immediate, waited, timedOut, rejected int64
+	maxQueued                             int
+	maxWait, totalWait                    time.Duration
+	// recent are the last slotWaitSamples waits, a ring from next
+	recent []time.Duration
+	next   int
+}
+
+type slotWaiter struct {
+	ready    chan struct{}
+	admitted bool
+}
+
+// queue returns the queue of a server with its current limit, creating it on
+// first use. A raised limit admits waiters at once.
+func (ss *serverSlots) queue(name string, limit int) *slotQueue {
+	q, ok := ss.queues[name]
+	if !ok {
+		q = &slotQueue{waiting: make(map[string][]*slotWaiter)}
+		ss.queues[name] = q
+	}
+	q.limit = limit
+	q.admit()
+	return q
+}
+
+func (q *slotQueue) depth() int {
+	n := 0
+	for _, ws := range q.waiting {
+		n += len(ws)
+	}
+	return n
+}
+
+// admit hands free slots to waiters, one session at a time.
+func (q *slotQueue) admit() {
+	for q.inFlight < q.limit && len(q.turn) > 0 {
+		session := q.turn[0]
+		q.turn = q.turn[1:]
+		ws := q.waiting[session]
+		w := ws[0]
+		if len(ws) > 1 {
+			q.waiting[session] = ws[1:]
+			q.turn = append(q.turn, session)
+		} else {
+			delete(q.waiting, session)
+		}
+		w.admitted = true
+		q.inFlight++
+		close(w.ready)
+	}
+}
+
+// remove drops a waiter that gave up before being admitted.
+func (q *slotQueue) remove(session string, w *slotWaiter) {
+	ws := q.waiting[session]
+	for i, x := range ws {
+		if x == w {
+			ws = append(ws[:i:i], ws[i+1:]...)
+			break
+		}
+	}
+	if len(ws) > 0 {
+		q.waiting[session] = ws
+		return
+	}
+	delete(q.waiting, session)
+	for i, s := range q.turn {
+		if s == session {
+			q.turn = append(q.turn[:i:i], q.turn[i+1:]...)
+			break
+		}
+	}
+}
+
+func (q *slotQueue) recordWait(d time.Duration) {
+	q.waited++
+	q.totalWait += d
+	if d > q.maxWait {
+		q.maxWait = d
+	}
+	if len(q.recent) < slotWaitSamples {
+		q.recent = append(q.recent, d)
+		return
+	}
+	q.recent[q.next] = d
+	q.next = (q.next + 1) % slotWaitSamples
+}
+
+// acquireSlot blocks until the server has a free call slot, or fails at once
+// with rejectWhenBusy. The returned func releases the slot.
+func (s *Server) acquireSlot(ctx context.Context, serverName string, srv *config.MCPServer) (func(), error) {
+	if srv.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+	ss := s.slots
+	ss.mu.Lock()
+	q := ss.queue(serverName, srv.MaxConcurrent)
+	release := func() {
+		ss.mu.Lock()
+		defer ss.mu.Unlock()
+		q.inFlight--
+		q.admit()
+	}
+	if q.inFlight < q.limit {
+		q.inFlight++
+		q.immediate++
+		ss.mu.Unlock()
+		return release, nil
+	}
+	if srv.RejectWhenBusy {
+		q.rejected++
+		ss.mu.Unlock()
+		return nil, fmt.Errorf("server %q is busy: %d calls in flight (maxConcurrent)", serverName, srv.MaxConcurrent)
+	}
+	session := sessionFrom(ctx)
+	w := &slotWaiter{ready: make(chan struct{})}
+	if len(q.waiting[session]) == 0 {
+		q.turn = append(q.turn, session)
+	}
+	q.waiting[session] = append(q.waiting[session], w)
+	if d := q.depth(); d > q.maxQueued {
+		q.maxQueued = d
+	}
+	ss.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-w.ready:
+		ss.mu.Lock()
+		q.recordWait(time.Since(start))
+		ss.mu.Unlock()
+		return release, nil
+	case <-ctx.Done():
+		ss.mu.Lock()
+		defer ss.mu.Unlock()
+		if w.admitted {
+			// Admitted just as the call gave up: pass the slot on
+			q.inFlight--
+			q.admit()
+		} else {
+			q.remove(session, w)
+		}
+		q.timedOut++
+		return nil, fmt.Errorf("server %q is busy: timed out waiting for one of %d call slots", serverName, srv.MaxConcurrent)
+	}
+}
+
+// slotStats are the call-slot metrics of a server with maxConcurrent, for
+// sizing the limit: a deep queue or long waits call for a higher one.
+type slotStats struct {
+	Server   string `json:"server"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"inFlight"`
+	// Queued calls wait now, from QueuedSessions sessions
+	Queued         int `json:"queued"`
+	QueuedSessions int `json:"queuedSessions"`
+	MaxQueued      int `json:"maxQueued"`
+	// Immediate calls got a slot at once, Waited ones after queueing
+	Immediate int64 `json:"immediate"`
+	Waited    int64 `json:"waited"`
+	TimedOut  int64 `json:"timedOut"`
+	Rejected  int64 `json:"rejected"`
+	// Wait times of the queued calls; the percentiles cover the recent ones
+	AvgWaitMs int64 `json:"avgWaitMs"`
+	P50WaitMs int64 `json:"p50WaitMs"`
+	P95WaitMs int64 `json:"p95WaitMs"`
+	MaxWaitMs int64 `json:"maxWaitMs"`
+}
+
+func (ss *serverSlots) stats() []slotStats {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	out := make([]slotStats, 0, len(ss.queues))
+	for name, q := range ss.queues {
+		st := slotStats{
+			Server:         name,
+			Limit:          q.limit,
+			InFlight:       q.inFlight,
+			Queued:         q.depth(),
+			QueuedSessions: len(q.turn),
+			MaxQueued:      q.maxQueued,
+			Immediate:      q.immediate,
+			Waited:         q.waited,
+			TimedOut:       q.timedOut,
+			Rejected:       q.rejected,
+			MaxWaitMs:      q.maxWait.Milliseconds(),
+		}
+		if q.waited > 0 {
+			st.AvgWaitMs = (q.totalWait / time.Duration(q.waited)).Milliseconds()
+		}
+		if len(q.recent) > 0 {
+			recent := append([]time.Duration(nil), q.recent...)
+			sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+			st.P50WaitMs = recent[len(recent)/2].Milliseconds()
+			st.P95WaitMs = recent[len(recent)*95/100].Milliseconds()
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Server < out[j].Server })
+	return out
+}
+
+// GET /api/concurrency - call slots, queue depth and wait times of the
+// servers with maxConcurrent
+func (s *Server) handleConcurrency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, s.slots.stats())
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// resourceEntry is a resource or template as listed by one upstream server
+type resourceEntry struct {
+	server string
+	uri    string
+	item   map[string]any
+}
+
+// resolveResourceConflicts applies the proxy's resourceConflicts policy to
+// entries whose original URI is exposed by more than one server.
+func (s *Server) resolveResourceConflicts(entries []resourceEntry) ([]resourceEntry, error) {
+	settings := s.store.GetProxySettings()
+	rank := make(map[string]int, len(settings.PreferServers))
+	for i, name := range settings.PreferServers {
+		rank[name] = i + 1
+	}
+	servers := s.store.Get().MCPServers
+	// before orders servers by preferServers, then by priority and name
+	before := func(a, b string) bool {
+		ra, rb := rank[a], rank[b]
+		if ra != rb {
+			return ra != 0 && (rb == 0 || ra < rb)
+		}
+		return config.ServerBefore(servers, a, b)
+	}
+
+	byURI := make(map[string][]int)
+	for i, e := range entries {
+		byURI[e.uri] = append(byURI[e.uri], i)
+	}
+
+	switch settings.ResourceConflicts {
+	case "", "namespace":
+		return entries, nil
+	case "error":
+		var conflicts []string
+		for uri, idx := range byURI {
+			seen := make(map[string]bool)
+			for _, j := range idx {
+				seen[entries[j].server] = true
+			}
+			if len(seen) < 2 {
+				continue
+			}
+			servers := make([]string, 0, len(seen))
+			for name := range seen {
+				servers = append(servers, name)
+			}
+			sort.Strings(servers)
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", uri, strings.Join(servers, ", ")))
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return nil, fmt.Errorf("resource URI conflict: %s", strings.Join(conflicts, "; "))
+		}
+		return entries, nil
+	case "prefer":
+		winner := make(map[string]string, len(byURI))
+		for uri, idx := range byURI {
+			best := entries[idx[0]].server
+			for _, j := range idx[1:] {
+				if before(entries[j].server, best) {
+					best = entries[j].server
+				}
+			}
+			winner[uri] = best
+		}
+		kept := entries[:0]
+		for _, e := range entries {
+			if winner[e.uri] == e.server {
+				kept = append(kept, e)
+			}
+		}
+		return kept, nil
+	default:
+		return nil, fmt.Errorf("unknown resourceConflicts policy %q", settings.ResourceConflicts)
+	}
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+const digestTopTools = 10
+
+// digestServerStats accumulates check outcomes for one server in the current window
+type digestServerStats struct {
+	checks    int
+	healthy   int
+	errors    []string
+	baseTools map[string]bool
+	tools     map[string]bool
+}
+
+// digest collects what happened since the previous report
+type digest struct {
+	mu         sync.Mutex
+	since      time.Time
+	servers    map[string]*digestServerStats
+	seenErrors map[string]bool
+	baseCalls  map[string]int64
+	lastSent   string
+}
+
+func newDigest() *digest {
+	return &digest{
+		since:      time.Now(),
+		servers:    make(map[string]*digestServerStats),
+		seenErrors: make(map[string]bool),
+		baseCalls:  make(map[string]int64),
+	}
+}
+
+// observe records a finished check reported through Manager.OnChange.
+func (d *digest) observe(name string, info *manager.ServerInfo) {
+	if info.Status != manager.StatusHealthy && info.Status != manager.StatusError {
+		return
+	}
+	tools := make(map[string]bool, len(info.Tools))
+	for _, t := range info.Tools {
+		tools[t.Name] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st, ok := d.servers[name]
+	if !ok {
+		st = &digestServerStats{baseTools: tools}
+		d.servers[name] = st
+	}
+	st.checks++
+	if info.Status == manager.StatusHealthy {
+		st.healthy++
+		st.tools = tools
+		if st.baseTools == nil {
+			st.baseTools = tools
+		}
+	} else if !d.seenErrors[name+"\x00"+info.Error] {
+		d.seenErrors[name+"\x00"+info.Error] = true
+		st.errors = append(st.errors, info.Error)
+	}
+}
+
+type digestServer struct {
+	Name         string   `json:"name"`
+	Checks       int      `json:"checks"`
+	UptimePct    float64  `json:"uptimePct"`
+	NewErrors    []string `json:"newErrors,omitempty"`
+	ToolsAdded   []string `json:"toolsAdded,omitempty"`
+	ToolsRemoved []string `json:"toolsRemoved,omitempty"`
+}
+
+type digestTool struct {
+	Server string `json:"server"`
+	Tool   string `json:"tool"`
+	Calls  int64  `json:"calls"`
+}
+
+type digestReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Servers  []digestServer `json:"servers"`
+	TopTools []digestTool   `json:"topTools"`
+}
+
+// build renders the current window; with reset it starts a new window.
+func (d *digest) build(usage map[string]serverAnalytics, reset bool) digestReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := digestReport{From: d.since, To: time.Now(), Servers: make([]digestServer, 0), TopTools: make([]digestTool, 0)}
+	for name, st := range d.servers {
+		ds := digestServer{Name: name, Checks: st.checks, NewErrors: st.errors}
+		if st.checks > 0 {
+			ds.UptimePct = float64(st.healthy) * 100 / float64(st.checks)
+		}
+		for tool := range st.tools {
+			if !st.baseTools[tool] {
+				ds.ToolsAdded = append(ds.ToolsAdded, tool)
+			}
+		}
+		if st.tools != nil {
+			for tool := range st.baseTools {
+				if !st.tools[tool] {
+					ds.ToolsRemoved = append(ds.ToolsRemoved, tool)
+				}
+			}
+		}
+		sort.Strings(ds.ToolsAdded)
+		sort.Strings(ds.ToolsRemoved)
+		report.Servers = append(report.Servers, ds)
+	}
+	sort.Slice(report.Servers, func(i, j int) bool { return report.Servers[i].Name < report.Servers[j].Name })
+
+	calls := make(map[string]int64)
+	for serverName, sa := range usage {
+		for toolName, u := range sa.Tools {
+			key := serverName + "\x00" + toolName
+			calls[key] = u.Calls
+			if n := u.Calls - d.baseCalls[key]; n > 0 {
+				report.TopTools = append(report.TopTools, digestTool{Server: serverName, Tool: toolName, Calls: n})
+			}
+		}
+	}
+	sort.Slice(report.TopTools, func(i, j int) bool {
+		if report.TopTools[i].Calls != report.TopTools[j].Calls {
+			return report.TopTools[i].Calls > report.TopTools[j].Calls
+		}
+		return report.TopTools[i].Server+report.TopTools[i].Tool < report.TopTools[j].Server+report.TopTools[j].Tool
+	})
+	if len(report.TopTools) > digestTopTools {
+		report.TopTools = report.TopTools[:digestTopTools]
+	}
+
+	if reset {
+		d.since = report.To
+		d.baseCalls = calls
+		for name, st := range d.servers {
+			d.servers[name] = &digestServerStats{baseTools: st.tools}
+			if st.tools == nil {
+				d.servers[name].baseTools = st.baseTools
+			}
+		}
+	}
+	return report
+}
+
+func (r digestReport) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# MCP Manager digest\n\n%s — %s\n\n", r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"))
+	sb.WriteString("## Uptime\n\n")
+	if len(r.Servers) == 0 {
+		sb.WriteString("No checks ran in this period.\n")
+	}
+	for _, srv := range r.Servers {
+		fmt.Fprintf(&sb, "- **%s**: %.1f%% (%d checks)\n", srv.Name, srv.UptimePct, srv.Checks)
+		for _, e := range srv.NewErrors {
+			fmt.Fprintf(&sb, "  - new error: %s\n", e)
+		}
+		if len(srv.ToolsAdded) > 0 {
+			fmt.Fprintf(&sb, "  - tools added: %s\n", strings.Join(srv.ToolsAdded, ", "))
+		}
+		if len(srv.ToolsRemoved) > 0 {
+			fmt.Fprintf(&sb, "  - tools removed: %s\n", strings.Join(srv.ToolsRemoved, ", "))
+		}
+	}
+	sb.WriteString("\n## Top tools\n\n")
+	if len(r.TopTools) == 0 {
+		sb.WriteString("No proxied tool calls in this period.\n")
+	}
+	for _, t := range r.TopTools {
+		fmt.Fprintf(&sb, "- %s__%s: %d calls\n", t.Server, t.Tool, t.Calls)
+	}
+	return sb.String()
+}
+
+// deliverDigest builds the digest, starts a new window, posts it to the
+// webhooks and writes the report file.
+func (s *Server) deliverDigest() (digestReport, string, error) {
+	report := s.digest.build(s.stats.snapshot(), true)
+	s.notifyDigest(report)
+	dir := s.store.GetDigestSettings().Dir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return report, "", err
+	}
+	path := filepath.Join(dir, "digest-"+report.To.Format("2006-01-02")+".md")
+	if err := os.WriteFile(path, []byte(report.Markdown()), 0644); err != nil {
+		return report, "", err
+	}
+	return report, path, nil
+}
+
+// StartDigestLoop produces the daily digest at the configured hour.
+func (s *Server) StartDigestLoop() {
+	for {
+		time.Sleep(time.Minute)
+		ds := s.store.GetDigestSettings()
+		if !ds.Enabled {
+			continue
+		}
+		now := time.Now()
+		today := now.Format("2006-01-02")
+		s.digest.mu.Lock()
+		due := now.Hour() >= ds.Hour && s.digest.lastSent != today
+		if due {
+			s.digest.lastSent = today
+		}
+		s.digest.mu.Unlock()
+		if !due {
+			continue
+		}
+		if _, path, err := s.deliverDigest(); err != nil {
+			slog.Error("digest delivery failed", "err", err)
+		} else {
+			slog.Info("digest written", "path", path)
+		}
+	}
+}
+
+// GET /api/digest - preview the digest for the current window
+// POST /api/digest - produce and deliver it now
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, s.digest.build(s.stats.snapshot(), false))
+	case "POST":
+		report, path, err := s.deliverDigest()
+		if err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		writeJSON(w, map[string]any{"path": path, "report": report})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// mcpEndpoint returns the named endpoint a proxy request addresses: "" for
+// /mcp itself, "coding" for /mcp/coding.
+func mcpEndpoint(r *http.Request) string {
+	name := strings.TrimPrefix(r.URL.Path, "/mcp")
+	return strings.Trim(name, "/")
+}
+
+// scopeServers returns the servers an endpoint exposes, with the tools it
+// hides added to each server's DisabledTools. The default endpoint exposes
+// the whole catalog.
+func (s *Server) scopeServers(endpoint string) map[string]*config.MCPServer {
+	cfg := s.store.Get()
+	if endpoint == "" {
+		return cfg.MCPServers
+	}
+	servers := make(map[string]*config.MCPServer)
+	ep, ok := cfg.Endpoints[endpoint]
+	if !ok || ep == nil {
+		return servers
+	}
+	for name, srv := range cfg.MCPServers {
+		if !ep.Includes(name, srv) {
+			continue
+		}
+		if hidden := ep.DisabledTools[name]; len(hidden) > 0 {
+			srv.DisabledTools = append(append([]string(nil), srv.DisabledTools...), hidden...)
+		}
+		servers[name] = srv
+	}
+	return servers
+}
+
+// endpointAllows reports whether a route resolved from a name the client sent
+// stays within the endpoint; tool is empty for prompts and resources.
+func (s *Server) endpointAllows(endpoint, server, tool string) bool {
+	if endpoint == "" || server == builtinServer {
+		return true
+	}
+	ep, ok := s.store.GetEndpoint(endpoint)
+	if !ok {
+		return false
+	}
+	srv, _ := s.store.GetServer(server)
+	if !ep.Includes(server, srv) {
+		return false
+	}
+	return tool == "" || ep.ToolEnabled(server, tool)
+}
+
+// sessionBudget is the result token budget of sessions on an endpoint.
+func (s *Server) sessionBudget(endpoint string) int {
+	if ep, ok := s.store.GetEndpoint(endpoint); ok && ep.SessionBudget > 0 {
+		return ep.SessionBudget
+	}
+	return s.store.GetTokenSettings().SessionBudget
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// APIError is the body of every management API error response:
+// {"error": {"code": "not_found", "message": "..."}}. Code is stable and
+// follows the status; Message is localized for people.
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorCodes names the statuses the API answers with.
+var errorCodes = map[int]string{
+	400: "bad_request",
+	401: "unauthorized",
+	403: "forbidden",
+	404: "not_found",
+	405: "method_not_allowed",
+	409: "conflict",
+	413: "too_large",
+	429: "rate_limited",
+	500: "internal",
+	501: "not_implemented",
+	502: "upstream_error",
+	503: "unavailable",
+	504: "timeout",
+}
+
+func sortedStatuses() []int {
+	statuses := make([]int, 0, len(errorCodes))
+	for status := range errorCodes {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	return statuses
+}
+
+func errorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	if status < 500 {
+		return "bad_request"
+	}
+	return "internal"
+}
+
+// writeError replies with a JSON error; it takes the place of http.Error
+// in the management API.
+func writeError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]APIError{
+		"error": {Code: errorCode(status), Message: message},
+	})
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+const (
+	// eventSendQueue is how many messages may wait for a slow client before it is evicted
+	eventSendQueue = 64
+	// sseKeepAlive keeps idle SSE connections open through proxies
+	sseKeepAlive = 15 * time.Second
+)
+
+// eventClient is a UI subscriber (WebSocket or SSE) with its own write queue.
+// All writes happen in the transport's own loop so a slow client never blocks
+// broadcasts.
+type eventClient struct {
+	remote    string
+	send      chan []byte
+	closeOnce sync.Once
+
+	// calls holds the servers whose proxied calls this client observes; "*" is all
+	callsMu sync.Mutex
+	calls   map[string]bool
+	// callsDenied is set for WebSocket clients below callDataRole
+	callsDenied bool
+	// hideEnv masks env values in the server infos sent to clients below
+	// envRole
+	hideEnv bool
+}
+
+func newEventClient(remote string) *eventClient {
+	return &eventClient{remote: remote, send: make(chan []byte, eventSendQueue)}
+}
+
+func (c *eventClient) close() {
+	c.closeOnce.Do(func() { close(c.send) })
+}
+
+// watchCalls subscribes to (or, with on false, unsubscribes from) call events
+// of a server; "" or "*" means every server.
+func (c *eventClient) watchCalls(server string, on bool) {
+	if server == "" {
+		server = "*"
+	}
+	c.callsMu.Lock()
+	defer c.callsMu.Unlock()
+	if !on {
+		if server == "*" {
+			c.calls = nil
+		} else {
+			delete(c.calls, server)
+		}
+		return
+	}
+	if c.calls == nil {
+		c.calls = make(map[string]bool)
+	}
+	c.calls[server] = true
+}
+
+func (c *eventClient) watchesCalls(server string) bool {
+	c.callsMu.Lock()
+	defer c.callsMu.Unlock()
+	return c.calls["*"] || c.calls[server]
+}
+
+// evict removes a client and stops its write loop.
+func (s *Server) evict(c *eventClient) {
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+	c.close()
+}
+
+func (s *Server) broadcast(data interface{}) {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.clients {
+		s.deliverLocked(c, msg)
+	}
+}
+
+// broadcastServer sends a server_update, with env values masked for the
+// clients that may not read them.
+func (s *Server) broadcastServer(name string, info *manager.ServerInfo) {
+	update := func(info *manager.ServerInfo) []byte {
+		msg, _ := json.Marshal(map[string]interface{}{
+			"type":   "server_update",
+			"name":   name,
+			"server": info,
+		})
+		return msg
+	}
+	full, masked := update(info), update(viewInfo(info, false))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		if c.hideEnv {
+			s.deliverLocked(c, masked)
+		} else {
+			s.deliverLocked(c, full)
+		}
+	}
+}
+
+// deliverLocked queues msg for c; s.mu must be held.
+func (s *Server) deliverLocked(c *eventClient, msg []byte) {
+	select {
+	case c.send <- msg:
+	default:
+		// The client is not keeping up; drop it instead of stalling everyone.
+		slog.Warn("evicting slow event client", "remote", c.remote)
+		delete(s.clients, c)
+		c.close()
+	}
+}
+
+// hasCallWatchers reports whether any client observes calls to server, so
+// callers can skip building events nobody reads.
+func (s *Server) hasCallWatchers(server string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for c := range s.clients {
+		if c.watchesCalls(server) {
+			return true
+		}
+	}
+	return false
+}
+
+// publishCall sends a call event to clients observing server.
+func (s *Server) publishCall(server string, data interface{}) {
+	msg, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		if c.watchesCalls(server) {
+			s.deliverLocked(c, msg)
+		}
+	}
+}
+
+// GET /api/events - Server-Sent Events carrying the same messages as /ws.
+// The SSE event name is the message type (initial, server_update, ...).
+// ?calls=a,b (or ?calls=*) adds call_started/call_finished events for those servers.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, i18n.T("streaming unsupported"), 500)
+		return
+	}
+
+	client := newEventClient(r.RemoteAddr)
+	client.hideEnv = !s.mayReadEnv(r)
+	if calls := r.URL.Query().Get("calls"); calls != "" {
+		for _, name := range strings.Split(calls, ",") {
+			client.watchCalls(strings.TrimSpace(name), true)
+		}
+	}
+	msg, _ := json.Marshal(map[string]interface{}{
+		"type":    "initial",
+		"servers": viewInfos(s.mgr.GetAllInfo(), !client.hideEnv),
+	})
+	client.send <- msg
+
+	s.mu.Lock()
+	s.clients[client] = true
+	s.mu.Unlock()
+	defer s.evict(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case msg, ok := <-client.send:
+			if !ok {
+				return
+			}
+			var head struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal(msg, &head)
+			if head.Type != "" {
+				fmt.Fprintf(w, "event: %s\n", head.Type)
+			}
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// callPreviewLimit bounds argument and result previews in call events
+const callPreviewLimit = 512
+
+var callSeq atomic.Int64
+
+// observeCall publishes call_started for watchers of the route's server and
+// returns a func that publishes call_finished.
+func (s *Server) observeCall(ctx context.Context, route toolRoute, args json.RawMessage) func(json.RawMessage, error) {
+	if !s.hasCallWatchers(route.ServerName) {
+		return func(json.RawMessage, error) {}
+	}
+	id := callSeq.Add(1)
+	start := time.Now()
+	client := accessTokenFrom(ctx)
+	started := map[string]interface{}{
+		"type":      "call_started",
+		"id":        id,
+		"server":    route.ServerName,
+		"tool":      route.ToolName,
+		"arguments": preview(args),
+		"time":      start,
+	}
+	if client != "" {
+		started["client"] = client
+	}
+	s.publishCall(route.ServerName, started)
+	return func(result json.RawMessage, err error) {
+		event := map[string]interface{}{
+			"type":       "call_finished",
+			"id":         id,
+			"server":     route.ServerName,
+			"tool":       route.ToolName,
+			"durationMs": time.Since(start).Milliseconds(),
+			"result":     preview(result),
+		}
+		if client != "" {
+			event["client"] = client
+		}
+		if err != nil {
+			event["error"] = err.Error()
+		}
+		s.publishCall(route.ServerName, event)
+	}
+}
+
+// preview shortens a payload for display, cutting on a UTF-8 boundary.
+func preview(raw json.RawMessage) string {
+	if len(raw) <= callPreviewLimit {
+		return string(raw)
+	}
+	cut := callPreviewLimit
+	for cut > 0 && !utf8.RuneStart(raw[cut]) {
+		cut--
+	}
+	return string(raw[:cut]) + "…"
+}
@@ -7,14 +7,19 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+	"github.com/naukograd-software/mcp-catalog/internal/transport"
 )
 
 const proxyProtocolVersion = "2024-11-05"
@@ -23,7 +28,13 @@ const proxyTimeout = 30 * time.Second
 const proxyResourcePrefix = "mcp-catalog://resource/"
 const proxyResourceTemplatePrefix = "mcp-catalog://resource-template/"
 
+// mcpSession is one client of the HTTP proxy; Endpoint is the named endpoint
+// it was opened on, "" for /mcp, and Token the access token it presented.
 type mcpSession struct {
+	LastSeen          time.Time
+	Endpoint          string
+	Token             string
+	ResultTokens      int
 	Tools             map[string]toolRoute
 	Prompts           map[string]promptRoute
 	Resources         map[string]resourceRoute
@@ -65,6 +76,17 @@ type rpcErr struct {
 	Message string `json:"message"`
 }
 
+// upstreamError is a JSON-RPC error returned by the upstream server itself,
+// as opposed to a transport failure.
+type upstreamError struct {
+	method string
+	err    rpcErr
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("%s: %s", e.method, e.err.Message)
+}
+
 type proxiedTool struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description,omitempty"`
@@ -78,52 +100,99 @@ type toolsListResult struct {
 type toolsCallParams struct {
 	Name      string          `json:"name"`
 	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Meta      *toolsCallMeta  `json:"_meta,omitempty"`
+}
+
+// toolsCallMeta carries proxy hints from the client
+type toolsCallMeta struct {
+	// TimeoutMs asks for a deadline other than the default 30s, capped by
+	// proxy.maxTimeoutMs
+	TimeoutMs int `json:"timeoutMs,omitempty"`
+	// ProgressToken is passed upstream when the response is streamed, so
+	// progress notifications reach the client while the tool runs
+	ProgressToken json.RawMessage `json:"progressToken,omitempty"`
 }
 
 func (s *Server) handleMCPProxy(w http.ResponseWriter, r *http.Request) {
+	r, ok := s.authorizeMCP(w, r)
+	if !ok {
+		return
+	}
+	if endpoint := mcpEndpoint(r); endpoint != "" {
+		if _, ok := s.store.GetEndpoint(endpoint); !ok {
+			http.Error(w, i18n.T("unknown MCP endpoint %q", endpoint), http.StatusNotFound)
+			return
+		}
+	}
 	switch r.Method {
 	case http.MethodDelete:
 		s.handleMCPDelete(w, r)
 		return
 	case http.MethodPost:
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		http.Error(w, i18n.T("method not allowed"), http.StatusMethodNotAllowed)
 		return
 	}
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		s.handleMCPBatch(w, r, trimmed)
+		return
+	}
 	var req rpcReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	s.serveMCPRequest(w, r, req)
+}
+
+// serveMCPRequest answers a single JSON-RPC request of the HTTP proxy.
+func (s *Server) serveMCPRequest(w http.ResponseWriter, r *http.Request, req rpcReq) {
 	if req.JSONRPC == "" {
 		req.JSONRPC = "2.0"
 	}
 
 	sessionID := strings.TrimSpace(r.Header.Get("MCP-Session-Id"))
+	endpoint := mcpEndpoint(r)
+	client := accessTokenFrom(r.Context())
+	slog.Debug("mcp request", "endpoint", endpoint, "client", client, "session", sessionID, "request_id", req.ID, "method", req.Method)
 	switch req.Method {
 	case "initialize":
-		s.handleMCPInitialize(w, req)
+		s.handleMCPInitialize(w, req, endpoint, client)
 		return
 	case "notifications/initialized":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
 		w.Header().Set("MCP-Session-Id", sessionID)
 		w.WriteHeader(http.StatusNoContent)
 		return
+	case "ping":
+		// Answered without a session too, so clients and load balancers can
+		// probe the proxy; a known session is kept alive
+		if sessionID != "" && !s.touchSession(sessionID, endpoint, client) {
+			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
+			return
+		}
+		s.writeRPCResult(w, req.ID, struct{}{}, sessionID)
+		return
 	case "tools/list":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
-		tools, routes := s.aggregateTools()
+		tools, routes := s.aggregateTools(endpoint)
 		s.updateSessionTools(sessionID, routes)
 		s.writeRPCResult(w, req.ID, toolsListResult{Tools: tools}, sessionID)
 		return
 	case "tools/call":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
@@ -141,24 +210,71 @@ func (s *Server) handleMCPProxy(w http.ResponseWriter, r *http.Request) {
 			s.writeRPCError(w, req.ID, -32601, "tool not found")
 			return
 		}
-		result, err := s.callTool(route.ServerName, route.ToolName, params.Arguments)
+		if s.sessionBudgetExhausted(sessionID) {
+			s.writeRPCError(w, req.ID, -32000, "session token budget exhausted")
+			return
+		}
+		if err := s.checkRateLimit(sessionID, route.ServerName); err != nil {
+			slog.Debug("rate limited", "server", route.ServerName, "session", sessionID, "request_id", req.ID, "err", err)
+			s.writeRPCError(w, req.ID, -32000, err.Error())
+			return
+		}
+		// Clients accepting SSE get the reply as an event stream, with upstream
+		// progress and log notifications relayed while the tool runs
+		ctx := withSession(withAccessToken(context.Background(), client), sessionID)
+		var stream *sseResponse
+		if acceptsEventStream(r) {
+			if stream, ok = startSSEResponse(w, sessionID); ok {
+				cs := &callStream{notify: stream.send}
+				if params.Meta != nil {
+					cs.progressToken = params.Meta.ProgressToken
+				}
+				ctx = withCallStream(ctx, cs)
+			}
+		}
+		result, tokens, err := s.proxyToolCall(ctx, route, params.Arguments, s.callTimeout(params.Meta))
 		if err != nil {
+			slog.Warn("tool call failed", "server", route.ServerName, "tool", route.ToolName, "client", client, "session", sessionID, "request_id", req.ID, "err", err)
+			if stream != nil {
+				stream.sendResponse(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
+				return
+			}
 			s.writeRPCError(w, req.ID, -32000, err.Error())
 			return
 		}
+		// Calls made with an access token are part of the audit trail
+		level := slog.LevelDebug
+		if client != "" {
+			level = slog.LevelInfo
+		}
+		slog.Log(ctx, level, "tool call", "server", route.ServerName, "tool", route.ToolName, "client", client, "session", sessionID, "request_id", req.ID, "tokens", tokens)
+		s.recordAudit(AuditEntry{Time: time.Now(), Client: client, Server: route.ServerName, Tool: route.ToolName, Session: sessionID, Tokens: tokens})
+		// The budget is checked before the call, so a result that would
+		// overshoot it is cut to what is left
+		if fit := s.chargeSessionTokens(sessionID, tokens, s.sessionBudget(endpoint)); fit < tokens {
+			slog.Info("tool result truncated to the session token budget", "server", route.ServerName, "tool", route.ToolName, "session", sessionID, "tokens", tokens, "kept", fit)
+			result = trimResult(result, fit, s.store.GetTokenSettings().CharsPerToken)
+		}
+		if stream != nil {
+			if len(result) == 0 {
+				result = json.RawMessage(`{}`)
+			}
+			stream.sendResponse(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: result})
+			return
+		}
 		s.writeRawResult(w, req.ID, result, sessionID)
 		return
 	case "prompts/list":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
-		items, routes := s.aggregatePrompts()
+		items, routes := s.aggregatePrompts(endpoint)
 		s.updateSessionPrompts(sessionID, routes)
 		s.writeRPCResult(w, req.ID, map[string]any{"prompts": items}, sessionID)
 		return
 	case "prompts/get":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
@@ -178,6 +294,11 @@ func (s *Server) handleMCPProxy(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		params["name"] = route.PromptName
+		if err := s.checkRateLimit(sessionID, route.ServerName); err != nil {
+			slog.Debug("rate limited", "server", route.ServerName, "session", sessionID, "request_id", req.ID, "err", err)
+			s.writeRPCError(w, req.ID, -32000, err.Error())
+			return
+		}
 		result, err := s.forwardPromptGet(route.ServerName, params)
 		if err != nil {
 			s.writeRPCError(w, req.ID, -32000, err.Error())
@@ -186,25 +307,33 @@ func (s *Server) handleMCPProxy(w http.ResponseWriter, r *http.Request) {
 		s.writeRawResult(w, req.ID, result, sessionID)
 		return
 	case "resources/list":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
-		items, routes := s.aggregateResources()
+		items, routes, err := s.aggregateResources(endpoint)
+		if err != nil {
+			s.writeRPCError(w, req.ID, -32000, err.Error())
+			return
+		}
 		s.updateSessionResources(sessionID, routes)
 		s.writeRPCResult(w, req.ID, map[string]any{"resources": items}, sessionID)
 		return
 	case "resources/templates/list":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
-		items, routes := s.aggregateResourceTemplates()
+		items, routes, err := s.aggregateResourceTemplates(endpoint)
+		if err != nil {
+			s.writeRPCError(w, req.ID, -32000, err.Error())
+			return
+		}
 		s.updateSessionResourceTemplates(sessionID, routes)
 		s.writeRPCResult(w, req.ID, map[string]any{"resourceTemplates": items}, sessionID)
 		return
 	case "resources/read":
-		if sessionID == "" || !s.hasSession(sessionID) {
+		if sessionID == "" || !s.touchSession(sessionID, endpoint, client) {
 			s.writeRPCError(w, req.ID, -32000, "missing or invalid MCP session")
 			return
 		}
@@ -224,6 +353,11 @@ func (s *Server) handleMCPProxy(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		params["uri"] = route.OriginalURI
+		if err := s.checkRateLimit(sessionID, route.ServerName); err != nil {
+			slog.Debug("rate limited", "server", route.ServerName, "session", sessionID, "request_id", req.ID, "err", err)
+			s.writeRPCError(w, req.ID, -32000, err.Error())
+			return
+		}
 		result, err := s.forwardResourceRead(route.ServerName, params)
 		if err != nil {
 			s.writeRPCError(w, req.ID, -32000, err.Error())
@@ -237,7 +371,7 @@ func (s *Server) handleMCPProxy(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleMCPInitialize(w http.ResponseWriter, req rpcReq) {
+func (s *Server) handleMCPInitialize(w http.ResponseWriter, req rpcReq, endpoint, client string) {
 	sessionID, err := newSessionID()
 	if err != nil {
 		s.writeRPCError(w, req.ID, -32603, "failed to allocate session")
@@ -245,12 +379,16 @@ func (s *Server) handleMCPInitialize(w http.ResponseWriter, req rpcReq) {
 	}
 	s.mcpMu.Lock()
 	s.mcpState[sessionID] = &mcpSession{
+		LastSeen:          time.Now(),
+		Endpoint:          endpoint,
+		Token:             client,
 		Tools:             make(map[string]toolRoute),
 		Prompts:           make(map[string]promptRoute),
 		Resources:         make(map[string]resourceRoute),
 		ResourceTemplates: make(map[string]resourceRoute),
 	}
 	s.mcpMu.Unlock()
+	s.SaveSessions()
 
 	result := map[string]any{
 		"protocolVersion": proxyProtocolVersion,
@@ -276,120 +414,149 @@ func (s *Server) handleMCPInitialize(w http.ResponseWriter, req rpcReq) {
 func (s *Server) handleMCPDelete(w http.ResponseWriter, r *http.Request) {
 	sessionID := strings.TrimSpace(r.Header.Get("MCP-Session-Id"))
 	if sessionID == "" {
-		http.Error(w, "missing MCP-Session-Id", http.StatusBadRequest)
+		http.Error(w, i18n.T("missing MCP-Session-Id"), http.StatusBadRequest)
 		return
 	}
 	s.mcpMu.Lock()
+	ss, ok := s.mcpState[sessionID]
+	if ok && ss.Token != accessTokenFrom(r.Context()) {
+		s.mcpMu.Unlock()
+		http.Error(w, i18n.T("session belongs to another client"), http.StatusForbidden)
+		return
+	}
 	delete(s.mcpState, sessionID)
 	s.mcpMu.Unlock()
+	s.limiter.forget(sessionID)
+	s.SaveSessions()
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) hasSession(sessionID string) bool {
-	s.mcpMu.RLock()
-	defer s.mcpMu.RUnlock()
-	_, ok := s.mcpState[sessionID]
-	return ok
-}
-
 func (s *Server) updateSessionTools(sessionID string, routes map[string]toolRoute) {
 	s.mcpMu.Lock()
-	defer s.mcpMu.Unlock()
 	ss, ok := s.mcpState[sessionID]
-	if !ok {
-		return
+	if ok {
+		ss.Tools = routes
+	}
+	s.mcpMu.Unlock()
+	if ok {
+		s.SaveSessions()
 	}
-	ss.Tools = routes
 }
 
 func (s *Server) updateSessionPrompts(sessionID string, routes map[string]promptRoute) {
 	s.mcpMu.Lock()
-	defer s.mcpMu.Unlock()
 	ss, ok := s.mcpState[sessionID]
-	if !ok {
-		return
+	if ok {
+		ss.Prompts = routes
+	}
+	s.mcpMu.Unlock()
+	if ok {
+		s.SaveSessions()
 	}
-	ss.Prompts = routes
 }
 
 func (s *Server) updateSessionResources(sessionID string, routes map[string]resourceRoute) {
 	s.mcpMu.Lock()
-	defer s.mcpMu.Unlock()
 	ss, ok := s.mcpState[sessionID]
-	if !ok {
-		return
+	if ok {
+		ss.Resources = routes
+	}
+	s.mcpMu.Unlock()
+	if ok {
+		s.SaveSessions()
 	}
-	ss.Resources = routes
 }
 
 func (s *Server) updateSessionResourceTemplates(sessionID string, routes map[string]resourceRoute) {
 	s.mcpMu.Lock()
-	defer s.mcpMu.Unlock()
 	ss, ok := s.mcpState[sessionID]
-	if !ok {
-		return
+	if ok {
+		ss.ResourceTemplates = routes
 	}
-	ss.ResourceTemplates = routes
-}
-
-func (s *Server) resolveToolRoute(sessionID, tool string) (toolRoute, bool) {
-	s.mcpMu.RLock()
-	ss, ok := s.mcpState[sessionID]
-	s.mcpMu.RUnlock()
+	s.mcpMu.Unlock()
 	if ok {
-		if r, ok := ss.Tools[tool]; ok {
-			return r, true
-		}
+		s.SaveSessions()
 	}
+}
 
-	parts := strings.SplitN(tool, "__", 2)
-	if len(parts) != 2 {
-		return toolRoute{}, false
+func (s *Server) sessionBudgetExhausted(sessionID string) bool {
+	ss, endpoint := s.sessionScope(sessionID)
+	budget := s.sessionBudget(endpoint)
+	if ss == nil || budget <= 0 {
+		return false
 	}
-	return toolRoute{ServerName: parts[0], ToolName: parts[1]}, true
+	s.mcpMu.RLock()
+	defer s.mcpMu.RUnlock()
+	return ss.ResultTokens >= budget
 }
 
-func (s *Server) resolvePromptRoute(sessionID, name string) (promptRoute, bool) {
+// sessionScope returns the session, nil if there is none, and the endpoint
+// requests under it are limited to.
+func (s *Server) sessionScope(sessionID string) (*mcpSession, string) {
 	s.mcpMu.RLock()
-	ss, ok := s.mcpState[sessionID]
-	s.mcpMu.RUnlock()
-	if ok {
-		if r, ok := ss.Prompts[name]; ok {
-			return r, true
+	defer s.mcpMu.RUnlock()
+	if ss, ok := s.mcpState[sessionID]; ok {
+		return ss, ss.Endpoint
+	}
+	return nil, s.stdioEndpoint
+}
+
+func (s *Server) resolveToolRoute(sessionID, tool string) (toolRoute, bool) {
+	ss, endpoint := s.sessionScope(sessionID)
+	var r toolRoute
+	ok := false
+	if ss != nil {
+		r, ok = ss.Tools[tool]
+	}
+	if !ok {
+		r, ok = s.toolAliasRoute(tool)
+	}
+	if !ok {
+		var server, name string
+		if server, name, ok = s.toolNamer().split(tool); ok {
+			r = toolRoute{ServerName: server, ToolName: name}
 		}
 	}
+	return r, ok && s.endpointAllows(endpoint, r.ServerName, r.ToolName)
+}
 
-	parts := strings.SplitN(name, "__", 2)
-	if len(parts) != 2 {
-		return promptRoute{}, false
+func (s *Server) resolvePromptRoute(sessionID, name string) (promptRoute, bool) {
+	ss, endpoint := s.sessionScope(sessionID)
+	var r promptRoute
+	ok := false
+	if ss != nil {
+		r, ok = ss.Prompts[name]
+	}
+	if !ok {
+		var server, prompt string
+		if server, prompt, ok = s.toolNamer().split(name); ok {
+			r = promptRoute{ServerName: server, PromptName: prompt}
+		}
 	}
-	return promptRoute{ServerName: parts[0], PromptName: parts[1]}, true
+	return r, ok && s.endpointAllows(endpoint, r.ServerName, "")
 }
 
 func (s *Server) resolveResourceRoute(sessionID, uri string) (resourceRoute, bool) {
-	s.mcpMu.RLock()
-	ss, ok := s.mcpState[sessionID]
-	s.mcpMu.RUnlock()
-	if ok {
-		if r, ok := ss.Resources[uri]; ok {
-			return r, true
-		}
-		if r, ok := ss.ResourceTemplates[uri]; ok {
-			return r, true
+	ss, endpoint := s.sessionScope(sessionID)
+	var r resourceRoute
+	ok := false
+	if ss != nil {
+		if r, ok = ss.Resources[uri]; !ok {
+			r, ok = ss.ResourceTemplates[uri]
 		}
 	}
-
-	if r, ok := parseProxyResourceURI(uri); ok {
-		return r, true
+	if !ok {
+		r, ok = parseProxyResourceURI(uri)
 	}
-	return resourceRoute{}, false
+	return r, ok && s.endpointAllows(endpoint, r.ServerName, "")
 }
 
-func (s *Server) aggregateTools() ([]proxiedTool, map[string]toolRoute) {
-	cfg := s.store.Get()
-	tools := make([]proxiedTool, 0)
-	routes := make(map[string]toolRoute)
-	for serverName, srv := range cfg.MCPServers {
+func (s *Server) aggregateTools(endpoint string) ([]proxiedTool, map[string]toolRoute) {
+	servers := s.scopeServers(endpoint)
+	charsPerToken := s.store.GetTokenSettings().CharsPerToken
+	var entries []toolEntry
+	for _, serverName := range config.OrderedNames(servers) {
+		srv := servers[serverName]
 		if srv == nil || !srv.Enabled {
 			continue
 		}
@@ -397,24 +564,66 @@ func (s *Server) aggregateTools() ([]proxiedTool, map[string]toolRoute) {
 		if err != nil {
 			continue
 		}
+		// Sort a copy: listTools may hand out its cached slice
+		serverTools = append([]proxiedTool(nil), serverTools...)
+		sort.SliceStable(serverTools, func(i, j int) bool { return serverTools[i].Name < serverTools[j].Name })
 		for _, t := range serverTools {
-			name := serverName + "__" + t.Name
-			tools = append(tools, proxiedTool{
-				Name:        name,
-				Description: t.Description,
-				InputSchema: t.InputSchema,
-			})
-			routes[name] = toolRoute{ServerName: serverName, ToolName: t.Name}
+			if !srv.ToolEnabled(t.Name) {
+				continue
+			}
+			s.stats.recordSchema(serverName, t, charsPerToken)
+			entries = append(entries, toolEntry{server: serverName, tool: t})
 		}
 	}
+
+	names := s.exposedToolNames(servers, entries)
+	tools := make([]proxiedTool, 0, len(entries))
+	routes := make(map[string]toolRoute)
+	for i, e := range entries {
+		tools = append(tools, proxiedTool{
+			Name:        names[i],
+			Description: describeTool(e.tool.Description, servers[e.server]),
+			InputSchema: e.tool.InputSchema,
+		})
+		routes[names[i]] = toolRoute{ServerName: e.server, ToolName: e.tool.Name}
+	}
+	if s.hasRetryQueues() {
+		tool := queuedResultToolDef(s.toolNamer().join(builtinServer, queuedResultTool))
+		tools = append(tools, tool)
+		routes[tool.Name] = toolRoute{ServerName: builtinServer, ToolName: queuedResultTool}
+	}
 	return tools, routes
 }
 
-func (s *Server) aggregatePrompts() ([]map[string]any, map[string]promptRoute) {
-	cfg := s.store.Get()
+// describeTool appends the server's description and documentation link to
+// a tool description when the server asks for it.
+func describeTool(desc string, srv *config.MCPServer) string {
+	if srv == nil || !srv.DescribeTools {
+		return desc
+	}
+	var extra []string
+	if srv.Description != "" {
+		extra = append(extra, srv.Description)
+	}
+	if srv.DocsURL != "" {
+		extra = append(extra, "Docs: "+srv.DocsURL)
+	}
+	if len(extra) == 0 {
+		return desc
+	}
+	if desc != "" {
+		desc += "\n\n"
+	}
+	return desc + strings.Join(extra, "\n")
+}
+
+func (s *Server) aggregatePrompts(endpoint string) ([]map[string]any, map[string]promptRoute) {
+	servers := s.scopeServers(endpoint)
+	namer := s.toolNamer()
 	items := make([]map[string]any, 0)
 	routes := make(map[string]promptRoute)
-	for serverName, srv := range cfg.MCPServers {
+	for _, serverName := range config.OrderedNames(servers) {
+		srv := servers[serverName]
 		if srv == nil || !srv.Enabled {
 			continue
 		}
@@ -426,12 +635,13 @@ func (s *Server) aggregatePrompts() ([]map[string]any, map[string]promptRoute) {
 		if err != nil {
 			continue
 		}
+		sortListObjects(prompts, "name")
 		for _, p := range prompts {
 			name, _ := p["name"].(string)
 			if name == "" {
 				continue
 			}
-			proxyName := serverName + "__" + name
+			proxyName := namer.join(serverName, name)
 			p["name"] = proxyName
 			items = append(items, p)
 			routes[proxyName] = promptRoute{ServerName: serverName, PromptName: name}
@@ -440,11 +650,11 @@ func (s *Server) aggregatePrompts() ([]map[string]any, map[string]promptRoute) {
 	return items, routes
 }
 
-func (s *Server) aggregateResources() ([]map[string]any, map[string]resourceRoute) {
-	cfg := s.store.Get()
-	items := make([]map[string]any, 0)
-	routes := make(map[string]resourceRoute)
-	for serverName, srv := range cfg.MCPServers {
+func (s *Server) aggregateResources(endpoint string) ([]map[string]any, map[string]resourceRoute, error) {
+	servers := s.scopeServers(endpoint)
+	var entries []resourceEntry
+	for _, serverName := range config.OrderedNames(servers) {
+		srv := servers[serverName]
 		if srv == nil || !srv.Enabled {
 			continue
 		}
@@ -456,28 +666,40 @@ func (s *Server) aggregateResources() ([]map[string]any, map[string]resourceRout
 		if err != nil {
 			continue
 		}
+		sortListObjects(resources, "uri")
 		for _, r := range resources {
 			uri, _ := r["uri"].(string)
 			if uri == "" {
 				continue
 			}
-			proxyURI := buildProxyResourceURI(serverName, uri, false)
-			r["uri"] = proxyURI
-			if name, _ := r["name"].(string); name != "" {
-				r["name"] = serverName + " :: " + name
-			}
-			items = append(items, r)
-			routes[proxyURI] = resourceRoute{ServerName: serverName, OriginalURI: uri}
+			entries = append(entries, resourceEntry{server: serverName, uri: uri, item: r})
 		}
 	}
-	return items, routes
-}
+	entries, err := s.resolveResourceConflicts(entries)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func (s *Server) aggregateResourceTemplates() ([]map[string]any, map[string]resourceRoute) {
-	cfg := s.store.Get()
-	items := make([]map[string]any, 0)
+	items := make([]map[string]any, 0, len(entries))
 	routes := make(map[string]resourceRoute)
-	for serverName, srv := range cfg.MCPServers {
+	for _, e := range entries {
+		r := e.item
+		proxyURI := buildProxyResourceURI(e.server, e.uri, false)
+		r["uri"] = proxyURI
+		if name, _ := r["name"].(string); name != "" {
+			r["name"] = e.server + " :: " + name
+		}
+		items = append(items, r)
+		routes[proxyURI] = resourceRoute{ServerName: e.server, OriginalURI: e.uri}
+	}
+	return items, routes, nil
+}
+
+func (s *Server) aggregateResourceTemplates(endpoint string) ([]map[string]any, map[string]resourceRoute, error) {
+	servers := s.scopeServers(endpoint)
+	var entries []resourceEntry
+	for _, serverName := range config.OrderedNames(servers) {
+		srv := servers[serverName]
 		if srv == nil || !srv.Enabled {
 			continue
 		}
@@ -489,21 +711,33 @@ func (s *Server) aggregateResourceTemplates() ([]map[string]any, map[string]reso
 		if err != nil {
 			continue
 		}
+		sortListObjects(tpls, "uriTemplate")
 		for _, t := range tpls {
 			uriTemplate, _ := t["uriTemplate"].(string)
 			if uriTemplate == "" {
 				continue
 			}
-			proxyURI := buildProxyResourceURI(serverName, uriTemplate, true)
-			t["uriTemplate"] = proxyURI
-			if name, _ := t["name"].(string); name != "" {
-				t["name"] = serverName + " :: " + name
-			}
-			items = append(items, t)
-			routes[proxyURI] = resourceRoute{ServerName: serverName, OriginalURI: uriTemplate, TemplateMode: true}
+			entries = append(entries, resourceEntry{server: serverName, uri: uriTemplate, item: t})
 		}
 	}
-	return items, routes
+	entries, err := s.resolveResourceConflicts(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]map[string]any, 0, len(entries))
+	routes := make(map[string]resourceRoute)
+	for _, e := range entries {
+		t := e.item
+		proxyURI := buildProxyResourceURI(e.server, e.uri, true)
+		t["uriTemplate"] = proxyURI
+		if name, _ := t["name"].(string); name != "" {
+			t["name"] = e.server + " :: " + name
+		}
+		items = append(items, t)
+		routes[proxyURI] = resourceRoute{ServerName: e.server, OriginalURI: e.uri, TemplateMode: true}
+	}
+	return items, routes, nil
 }
 
 func (s *Server) listTools(serverName string, srv *config.MCPServer) ([]proxiedTool, error) {
@@ -520,7 +754,7 @@ func (s *Server) listTools(serverName string, srv *config.MCPServer) ([]proxiedT
 	return parsed.Tools, nil
 }
 
-func (s *Server) callTool(serverName, toolName string, args json.RawMessage) (json.RawMessage, error) {
+func (s *Server) callTool(ctx context.Context, serverName, toolName string, args json.RawMessage, timeout time.Duration) (json.RawMessage, error) {
 	srv, ok := s.store.GetServer(serverName)
 	if !ok {
 		return nil, fmt.Errorf("server %q not found", serverName)
@@ -537,7 +771,47 @@ func (s *Server) callTool(serverName, toolName string, args json.RawMessage) (js
 		"name":      toolName,
 		"arguments": parsedArgs,
 	}
-	return s.forwardMCP(serverName, srv, "tools/call", params)
+	if cs := callStreamFrom(ctx); cs != nil && len(cs.progressToken) > 0 {
+		params["_meta"] = map[string]any{"progressToken": cs.progressToken}
+	}
+	return s.forwardMCPWithTimeout(ctx, timeout, serverName, srv, "tools/call", params)
+}
+
+// proxyToolCall forwards a tools/call, scans the result for secrets and records
+// its usage and estimated result tokens.
+func (s *Server) proxyToolCall(ctx context.Context, route toolRoute, args json.RawMessage, timeout time.Duration) (json.RawMessage, int, error) {
+	if route.ServerName == builtinServer {
+		result, err := s.builtinToolCall(route.ToolName, args)
+		return result, 0, err
+	}
+	if srv, ok := s.store.GetServer(route.ServerName); ok && !srv.ToolEnabled(route.ToolName) {
+		return nil, 0, fmt.Errorf("tool %q of server %q is disabled", route.ToolName, route.ServerName)
+	}
+	finish := s.observeCall(ctx, route, args)
+	result, err := s.callTool(ctx, route.ServerName, route.ToolName, args, timeout)
+	if err != nil {
+		if queued, ok := s.maybeQueue(route, args, err); ok {
+			result, err = queued, nil
+		}
+	}
+	finish(result, err)
+	if err == nil {
+		result, err = s.scanResult(route.ServerName, route.ToolName, result)
+	}
+	tokens := s.stats.recordCall(route.ServerName, route.ToolName, result, err, s.store.GetTokenSettings().CharsPerToken)
+	return result, tokens, err
+}
+
+// callTimeout honours a client's _meta.timeoutMs within the configured maximum.
+func (s *Server) callTimeout(meta *toolsCallMeta) time.Duration {
+	if meta == nil || meta.TimeoutMs <= 0 {
+		return proxyTimeout
+	}
+	timeout := time.Duration(meta.TimeoutMs) * time.Millisecond
+	if max := time.Duration(s.store.GetProxySettings().MaxTimeoutMs) * time.Millisecond; timeout > max {
+		timeout = max
+	}
+	return timeout
 }
 
 func (s *Server) forwardPromptGet(serverName string, params map[string]any) (json.RawMessage, error) {
@@ -557,21 +831,48 @@ func (s *Server) forwardResourceRead(serverName string, params map[string]any) (
 }
 
 func (s *Server) forwardMCP(serverName string, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
-	_ = serverName
-	ctx, cancel := context.WithTimeout(context.Background(), proxyTimeout)
+	return s.forwardMCPWithTimeout(context.Background(), proxyTimeout, serverName, srv, method, params)
+}
+
+// forwardMCPWithTimeout is forwardMCP with a deadline other than proxyTimeout.
+// A callStream in parent receives upstream notifications sent during the call.
+func (s *Server) forwardMCPWithTimeout(parent context.Context, timeout time.Duration, serverName string, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
-	if strings.EqualFold(strings.TrimSpace(srv.Type), "streamableHttp") || (strings.TrimSpace(srv.URL) != "" && strings.TrimSpace(srv.Command) == "") {
-		return forwardHTTP(ctx, srv, method, params)
+	ctx = s.withCapture(ctx, serverName, srv)
+	if err := s.breakers.allow(serverName); err != nil {
+		return nil, err
+	}
+	release, err := s.acquireSlot(ctx, serverName, srv)
+	if err != nil {
+		s.breakers.cancel(serverName)
+		return nil, err
+	}
+	defer release()
+	var result json.RawMessage
+	if srv.IsNATS() {
+		result, err = s.forwardNATS(ctx, srv, method, params)
+	} else if strings.EqualFold(strings.TrimSpace(srv.Type), "streamableHttp") || (strings.TrimSpace(srv.URL) != "" && strings.TrimSpace(srv.Command) == "") {
+		var client *http.Client
+		if client, err = transport.NewHTTPClient(srv, s.store.GetEgressSettings(), timeout); err == nil {
+			result, err = forwardHTTP(ctx, client, srv, method, params)
+		}
+	} else {
+		result, err = s.forwardStdio(ctx, serverName, srv, method, params)
 	}
-	return forwardStdio(ctx, srv, method, params)
+	result, err = s.chaos.apply(ctx, serverName, method, result, err)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%s timed out after %s: %w", method, timeout, err)
+	}
+	s.breakers.record(serverName, err)
+	return result, err
 }
 
-func forwardHTTP(ctx context.Context, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
+func forwardHTTP(ctx context.Context, client *http.Client, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
 	url := strings.TrimSpace(srv.URL)
 	if url == "" {
 		return nil, fmt.Errorf("missing url")
 	}
-	client := &http.Client{Timeout: proxyTimeout}
 	sessionID := ""
 
 	send := func(payload map[string]any, expect bool, expectedID int) (*rpcResp, error) {
@@ -588,6 +889,7 @@ func forwardHTTP(ctx context.Context, srv *config.MCPServer, method string, para
 		if sessionID != "" {
 			req.Header.Set("MCP-Session-Id", sessionID)
 		}
+		captured(ctx, "send", body)
 		resp, err := client.Do(req)
 		if err != nil {
 			return nil, err
@@ -596,7 +898,13 @@ func forwardHTTP(ctx context.Context, srv *config.MCPServer, method string, para
 		if sid := strings.TrimSpace(resp.Header.Get("MCP-Session-Id")); sid != "" {
 			sessionID = sid
 		}
+		if expect && resp.StatusCode < 400 && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			return readEventStream(ctx, resp.Body, expectedID)
+		}
 		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+		if len(bytes.TrimSpace(raw)) > 0 {
+			captured(ctx, "recv", raw)
+		}
 		if resp.StatusCode >= 400 {
 			return nil, fmt.Errorf("http status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
 		}
@@ -662,107 +970,102 @@ func forwardHTTP(ctx context.Context, srv *config.MCPServer, method string, para
 		return nil, err
 	}
 	if callResp.Error != nil {
-		return nil, fmt.Errorf("%s: %s", method, callResp.Error.Message)
+		return nil, &upstreamError{method: method, err: *callResp.Error}
 	}
 	return callResp.Result, nil
 }
 
-func forwardStdio(ctx context.Context, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
-	command := strings.TrimSpace(srv.Command)
-	if command == "" {
-		return nil, fmt.Errorf("missing command")
-	}
-	cmd := exec.CommandContext(ctx, command, srv.Args...)
-	if len(srv.Env) > 0 {
-		env := cmd.Environ()
-		for k, v := range srv.Env {
-			env = append(env, fmt.Sprintf("%s=%s", k, v))
-		}
-		cmd.Env = env
+// forwardNATS performs the MCP handshake and one request over the server's
+// NATS subject, relaying notifications that arrive ahead of the response.
+func (s *Server) forwardNATS(ctx context.Context, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
+	if srv.URL == "" || srv.Subject == "" {
+		return nil, fmt.Errorf("nats server needs both url and subject")
 	}
-
-	stdin, err := cmd.StdinPipe()
+	conn, err := transport.DialNATS(ctx, srv, s.store.GetEgressSettings())
 	if err != nil {
 		return nil, err
 	}
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = cmd.Process.Kill()
-		_ = cmd.Wait()
-	}()
-	go io.Copy(io.Discard, stderrPipe)
+	defer conn.Close()
 
-	stdout := bufio.NewReader(stdoutPipe)
-	writeReq := func(v any) error {
-		b, err := json.Marshal(v)
-		if err != nil {
-			return err
-		}
-		_, err = stdin.Write(append(b, '\n'))
-		return err
-	}
-	readResp := func() (*rpcResp, error) {
-		line, err := stdout.ReadString('\n')
+	request := func(id int, method string, params any) (*rpcResp, error) {
+		body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
 		if err != nil {
 			return nil, err
 		}
 		var resp rpcResp
-		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &resp); err != nil {
+		captured(ctx, "send", body)
+		_, err = conn.Request(srv.Subject, body, func(msg []byte) bool {
+			captured(ctx, "recv", msg)
+			if relayNotification(ctx, msg) {
+				return false
+			}
+			var r rpcResp
+			if json.Unmarshal(msg, &r) != nil || r.ID != id {
+				return false
+			}
+			resp = r
+			return true
+		})
+		if err != nil {
 			return nil, err
 		}
 		return &resp, nil
 	}
 
-	if err := writeReq(map[string]any{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "initialize",
-		"params": map[string]any{
-			"protocolVersion": proxyProtocolVersion,
-			"capabilities":    map[string]any{},
-			"clientInfo": map[string]any{
-				"name":    "mcp-catalog-proxy",
-				"version": "1.0.0",
-			},
+	initResp, err := request(1, "initialize", map[string]any{
+		"protocolVersion": proxyProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    "mcp-catalog-proxy",
+			"version": "1.0.0",
 		},
-	}); err != nil {
-		return nil, err
-	}
-	initResp, err := readResp()
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("initialize request: %w", err)
 	}
 	if initResp.Error != nil {
 		return nil, fmt.Errorf("initialize: %s", initResp.Error.Message)
 	}
+	initialized, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	captured(ctx, "send", initialized)
+	_ = conn.Publish(srv.Subject, initialized)
 
-	_ = writeReq(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"})
-
-	if err := writeReq(map[string]any{
-		"jsonrpc": "2.0",
-		"id":      2,
-		"method":  method,
-		"params":  params,
-	}); err != nil {
+	callResp, err := request(2, method, params)
+	if err != nil {
 		return nil, err
 	}
-	callResp, err := readResp()
+	if callResp.Error != nil {
+		return nil, &upstreamError{method: method, err: *callResp.Error}
+	}
+	if len(callResp.Result) == 0 {
+		return json.RawMessage(`{}`), nil
+	}
+	return callResp.Result, nil
+}
+
+func (s *Server) forwardStdio(ctx context.Context, serverName string, srv *config.MCPServer, method string, params any) (json.RawMessage, error) {
+	// A prewarmed server hands over an already initialized process
+	proc := s.takeWarm(serverName, srv)
+	warm := proc != nil
+	if !warm {
+		var err error
+		if proc, err = s.spawnStdio(serverName, srv); err != nil {
+			return nil, err
+		}
+	}
+	defer proc.close()
+	if !warm {
+		if err := s.initializeStdio(ctx, proc); err != nil {
+			return nil, err
+		}
+	}
+
+	callResp, err := s.roundTrip(ctx, proc, method, params)
 	if err != nil {
 		return nil, err
 	}
 	if callResp.Error != nil {
-		return nil, fmt.Errorf("%s: %s", method, callResp.Error.Message)
+		return nil, &upstreamError{method: method, err: *callResp.Error}
 	}
 
 	if len(callResp.Result) == 0 {
@@ -771,6 +1074,54 @@ func forwardStdio(ctx context.Context, srv *config.MCPServer, method string, par
 	return callResp.Result, nil
 }
 
+// reportCrash records a proxied server crash; in stdio mode there is no
+// manager, so it is only logged.
+func (s *Server) reportCrash(serverName string, report *manager.CrashReport) {
+	if s.mgr != nil {
+		s.mgr.RecordCrash(serverName, report)
+		return
+	}
+	slog.Warn("server crashed", "server", serverName, "exit_code", report.ExitCode, "signal", report.Signal, "stderr", strings.Join(report.Stderr, "\n"))
+}
+
+// readEventStream reads an upstream SSE response event by event, relaying
+// notifications until the response with expectedID arrives.
+func readEventStream(ctx context.Context, body io.Reader, expectedID int) (*rpcResp, error) {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64*1024), 2<<20)
+	var data strings.Builder
+	for {
+		more := sc.Scan()
+		line := strings.TrimRight(sc.Text(), "\r")
+		if more && line != "" {
+			if payload, ok := strings.CutPrefix(line, "data:"); ok {
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(strings.TrimPrefix(payload, " "))
+			}
+			continue
+		}
+		// A blank line (or the end of the stream) completes an event
+		if msg := strings.TrimSpace(data.String()); msg != "" && msg != "[DONE]" {
+			data.Reset()
+			captured(ctx, "recv", []byte(msg))
+			if !relayNotification(ctx, []byte(msg)) {
+				var resp rpcResp
+				if err := json.Unmarshal([]byte(msg), &resp); err == nil && resp.ID == expectedID {
+					return &resp, nil
+				}
+			}
+		}
+		if !more {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("response id=%d not found", expectedID)
+		}
+	}
+}
+
 func decodeProxyResponse(raw []byte, expectedID int) (*rpcResp, error) {
 	data := strings.TrimSpace(string(raw))
 	if data == "" {
@@ -846,6 +1197,16 @@ func parseListObjects(raw json.RawMessage, key string) ([]map[string]any, error)
 	return items, nil
 }
 
+// sortListObjects orders list items by a string field so listings do not
+// change between sessions when an upstream returns them in varying order.
+func sortListObjects(items []map[string]any, key string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, _ := items[i][key].(string)
+		b, _ := items[j][key].(string)
+		return a < b
+	})
+}
+
 func buildProxyResourceURI(serverName, originalURI string, template bool) string {
 	encoded := hex.EncodeToString([]byte(originalURI))
 	if template {
@@ -2,32 +2,61 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/naukograd-software/mcp-catalog/internal/config"
 )
 
-// RunMCPStdio starts the MCP proxy transport over stdio.
-func RunMCPStdio(store *config.Store) error {
-	s := &Server{store: store}
-	return s.runMCPStdio()
+// RunMCPStdio starts the MCP proxy transport over stdio. A non-empty
+// endpoint limits it to that named endpoint, as /mcp/{endpoint} does; a
+// non-nil chaos injects upstream faults.
+func RunMCPStdio(store *config.Store, endpoint string, chaos *Chaos) error {
+	if endpoint != "" {
+		if _, ok := store.GetEndpoint(endpoint); !ok {
+			return fmt.Errorf("unknown MCP endpoint %q", endpoint)
+		}
+	}
+	s := &Server{
+		store:    store,
+		stats:    newAnalytics(),
+		security: newSecurityReport(),
+		limiter:  newRateLimiter(),
+		slots:    newServerSlots(),
+		breakers: newBreakers(store),
+		queue:    newRetryQueue(),
+		warm:     newWarmPool(),
+		captures: newCaptures(filepath.Join(store.Dir(), "captures")),
+
+		stdioEndpoint: endpoint,
+	}
+	if chaos != nil {
+		s.EnableChaos(*chaos)
+	}
+	go s.StartWarmStandby()
+	defer s.StopWarmStandby()
+	return s.runMCPStdio(os.Stdin, os.Stdout)
 }
 
-func (s *Server) runMCPStdio() error {
-	in := bufio.NewScanner(os.Stdin)
+func (s *Server) runMCPStdio(stdin io.Reader, stdout io.Writer) error {
+	in := bufio.NewScanner(stdin)
 	in.Buffer(make([]byte, 64*1024), 2*1024*1024)
-	out := bufio.NewWriter(os.Stdout)
+	out := bufio.NewWriter(stdout)
 
 	toolRoutes := make(map[string]toolRoute)
 	promptRoutes := make(map[string]promptRoute)
 	resourceRoutes := make(map[string]resourceRoute)
 	templateRoutes := make(map[string]resourceRoute)
+	usedTokens := 0
 
-	write := func(resp rpcResp) error {
-		b, err := json.Marshal(resp)
+	writeLine := func(v any) error {
+		b, err := json.Marshal(v)
 		if err != nil {
 			return err
 		}
@@ -36,21 +65,27 @@ func (s *Server) runMCPStdio() error {
 		}
 		return out.Flush()
 	}
-
-	for in.Scan() {
-		line := strings.TrimSpace(in.Text())
-		if line == "" {
-			continue
-		}
-		var req rpcReq
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			_ = write(rpcResp{JSONRPC: "2.0", ID: 0, Error: &rpcErr{Code: -32700, Message: "parse error"}})
-			continue
+	// batch collects responses while the requests of a batch are dispatched;
+	// held keeps the notifications relayed meanwhile, so no other line is
+	// written until the batch is done
+	var batch []rpcResp
+	var held []json.RawMessage
+	inBatch := false
+	write := func(resp rpcResp) error {
+		if inBatch {
+			batch = append(batch, resp)
+			return nil
 		}
+		return writeLine(resp)
+	}
+
+	// dispatch answers one request through write
+	dispatch := func(req rpcReq) {
 		if req.JSONRPC == "" {
 			req.JSONRPC = "2.0"
 		}
 
+		slog.Debug("mcp request", "transport", "stdio", "request_id", req.ID, "method", req.Method)
 		switch req.Method {
 		case "initialize":
 			raw, _ := json.Marshal(map[string]any{
@@ -68,8 +103,10 @@ func (s *Server) runMCPStdio() error {
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: raw})
 		case "notifications/initialized":
 			// notifications have no response
+		case "ping":
+			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{}`)})
 		case "tools/list":
-			tools, routes := s.aggregateTools()
+			tools, routes := s.aggregateTools(s.stdioEndpoint)
 			toolRoutes = routes
 			raw, _ := json.Marshal(toolsListResult{Tools: tools})
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: raw})
@@ -77,24 +114,52 @@ func (s *Server) runMCPStdio() error {
 			var p toolsCallParams
 			if err := json.Unmarshal(req.Params, &p); err != nil || p.Name == "" {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32602, Message: "invalid tools/call params"}})
-				continue
+				return
 			}
 			route, ok := toolRoutes[p.Name]
 			if !ok {
 				route, ok = s.resolveToolRoute("", p.Name)
 				if !ok {
 					_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32601, Message: "tool not found"}})
-					continue
+					return
+				}
+			}
+			if budget := s.sessionBudget(s.stdioEndpoint); budget > 0 && usedTokens >= budget {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: "session token budget exhausted"}})
+				return
+			}
+			if err := s.checkRateLimit("stdio", route.ServerName); err != nil {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
+				return
+			}
+			// Relay upstream progress and log notifications while the tool runs
+			cs := &callStream{notify: func(msg json.RawMessage) {
+				if inBatch {
+					held = append(held, append(json.RawMessage(nil), msg...))
+					return
 				}
+				out.Write(append(msg, '\n'))
+				out.Flush()
+			}}
+			if p.Meta != nil {
+				cs.progressToken = p.Meta.ProgressToken
 			}
-			res, err := s.callTool(route.ServerName, route.ToolName, p.Arguments)
+			res, tokens, err := s.proxyToolCall(withCallStream(context.Background(), cs), route, p.Arguments, s.callTimeout(p.Meta))
 			if err != nil {
+				slog.Warn("tool call failed", "server", route.ServerName, "tool", route.ToolName, "request_id", req.ID, "err", err)
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
-				continue
+				return
+			}
+			// The budget of the process is spent like a session's
+			if fit := budgetFit(tokens, usedTokens, s.sessionBudget(s.stdioEndpoint)); fit < tokens {
+				slog.Info("tool result truncated to the session token budget", "server", route.ServerName, "tool", route.ToolName, "tokens", tokens, "kept", fit)
+				res = trimResult(res, fit, s.store.GetTokenSettings().CharsPerToken)
+				tokens = fit
 			}
+			usedTokens += tokens
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: res})
 		case "prompts/list":
-			items, routes := s.aggregatePrompts()
+			items, routes := s.aggregatePrompts(s.stdioEndpoint)
 			promptRoutes = routes
 			raw, _ := json.Marshal(map[string]any{"prompts": items})
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: raw})
@@ -102,7 +167,7 @@ func (s *Server) runMCPStdio() error {
 			params := map[string]any{}
 			if err := json.Unmarshal(req.Params, &params); err != nil {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32602, Message: "invalid prompts/get params"}})
-				continue
+				return
 			}
 			name, _ := params["name"].(string)
 			route, ok := promptRoutes[name]
@@ -111,22 +176,34 @@ func (s *Server) runMCPStdio() error {
 			}
 			if !ok {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32601, Message: "prompt not found"}})
-				continue
+				return
 			}
 			params["name"] = route.PromptName
+			if err := s.checkRateLimit("stdio", route.ServerName); err != nil {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
+				return
+			}
 			res, err := s.forwardPromptGet(route.ServerName, params)
 			if err != nil {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
-				continue
+				return
 			}
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: res})
 		case "resources/list":
-			items, routes := s.aggregateResources()
+			items, routes, err := s.aggregateResources(s.stdioEndpoint)
+			if err != nil {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
+				return
+			}
 			resourceRoutes = routes
 			raw, _ := json.Marshal(map[string]any{"resources": items})
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: raw})
 		case "resources/templates/list":
-			items, routes := s.aggregateResourceTemplates()
+			items, routes, err := s.aggregateResourceTemplates(s.stdioEndpoint)
+			if err != nil {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
+				return
+			}
 			templateRoutes = routes
 			raw, _ := json.Marshal(map[string]any{"resourceTemplates": items})
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: raw})
@@ -134,7 +211,7 @@ func (s *Server) runMCPStdio() error {
 			params := map[string]any{}
 			if err := json.Unmarshal(req.Params, &params); err != nil {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32602, Message: "invalid resources/read params"}})
-				continue
+				return
 			}
 			uri, _ := params["uri"].(string)
 			route, ok := resourceRoutes[uri]
@@ -142,23 +219,74 @@ func (s *Server) runMCPStdio() error {
 				route, ok = templateRoutes[uri]
 			}
 			if !ok {
-				route, ok = parseProxyResourceURI(uri)
+				route, ok = s.resolveResourceRoute("", uri)
 			}
 			if !ok {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32601, Message: "resource not found"}})
-				continue
+				return
 			}
 			params["uri"] = route.OriginalURI
+			if err := s.checkRateLimit("stdio", route.ServerName); err != nil {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
+				return
+			}
 			res, err := s.forwardResourceRead(route.ServerName, params)
 			if err != nil {
 				_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32000, Message: err.Error()}})
-				continue
+				return
 			}
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Result: res})
 		default:
+			if isNotification(req) {
+				return
+			}
 			_ = write(rpcResp{JSONRPC: "2.0", ID: req.ID, Error: &rpcErr{Code: -32601, Message: fmt.Sprintf("method not found: %s", req.Method)}})
 		}
 	}
+
+	for in.Scan() {
+		line := strings.TrimSpace(in.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			// A batch is answered with one array; notifications add nothing
+			var raws []json.RawMessage
+			if err := json.Unmarshal([]byte(line), &raws); err != nil {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: 0, Error: &rpcErr{Code: -32700, Message: "parse error"}})
+				continue
+			}
+			if len(raws) == 0 {
+				_ = write(rpcResp{JSONRPC: "2.0", ID: 0, Error: &rpcErr{Code: -32600, Message: "empty batch"}})
+				continue
+			}
+			batch, held, inBatch = nil, nil, true
+			for _, raw := range raws {
+				var req rpcReq
+				if err := json.Unmarshal(raw, &req); err != nil {
+					_ = write(rpcResp{JSONRPC: "2.0", ID: 0, Error: &rpcErr{Code: -32600, Message: "invalid request"}})
+					continue
+				}
+				dispatch(req)
+			}
+			inBatch = false
+			// Relayed progress goes out ahead of the responses it belongs to
+			for _, msg := range held {
+				out.Write(append(msg, '\n'))
+			}
+			out.Flush()
+			if len(batch) > 0 {
+				_ = writeLine(batch)
+			}
+			continue
+		}
+		var req rpcReq
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			_ = write(rpcResp{JSONRPC: "2.0", ID: 0, Error: &rpcErr{Code: -32700, Message: "parse error"}})
+			continue
+		}
+		dispatch(req)
+	}
 	if err := in.Err(); err != nil {
 		return err
 	}
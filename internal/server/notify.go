@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+const (
+	notifyTimeout  = 10 * time.Second
+	notifyLogLines = 10
+	// discordMaxContent is the length limit of a Discord message
+	discordMaxContent = 2000
+)
+
+// statusWatch remembers the last check outcome of each server to spot
+// healthy/error transitions.
+type statusWatch struct {
+	mu   sync.Mutex
+	last map[string]manager.ServerStatus
+}
+
+func newStatusWatch() *statusWatch {
+	return &statusWatch{last: make(map[string]manager.ServerStatus)}
+}
+
+// transition records the status and returns the previous one when it
+// changed. A server's first failure counts as a change, its first success
+// does not.
+func (sw *statusWatch) transition(name string, status manager.ServerStatus) (manager.ServerStatus, bool) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	prev, seen := sw.last[name]
+	sw.last[name] = status
+	if !seen {
+		return manager.StatusUnchecked, status == manager.StatusError
+	}
+	return prev, prev != status
+}
+
+// statusEvent is the JSON payload of a status change.
+type statusEvent struct {
+	Event    string               `json:"event"`
+	Server   string               `json:"server"`
+	Status   manager.ServerStatus `json:"status"`
+	Previous manager.ServerStatus `json:"previous"`
+	Error    string               `json:"error,omitempty"`
+	Logs     []manager.LogEntry   `json:"logs,omitempty"`
+	Time     time.Time            `json:"time"`
+}
+
+func (e statusEvent) text() string {
+	if e.Status == manager.StatusHealthy {
+		return fmt.Sprintf("MCP server %s recovered", e.Server)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "MCP server %s is failing: %s", e.Server, e.Error)
+	if len(e.Logs) > 0 {
+		sb.WriteString("\n```\n")
+		for _, entry := range e.Logs {
+			fmt.Fprintf(&sb, "%s [%s] %s\n", entry.Time.Format(time.TimeOnly), entry.Level, entry.Message)
+		}
+		sb.WriteString("```")
+	}
+	return sb.String()
+}
+
+// observeStatus notifies the webhooks when a finished check changed a
+// server between healthy and error.
+func (s *Server) observeStatus(name string, _ *manager.ServerInfo) {
+	info, ok := s.mgr.GetInfo(name)
+	if !ok || (info.Status != manager.StatusHealthy && info.Status != manager.StatusError) {
+		return
+	}
+	prev, changed := s.statuses.transition(name, info.Status)
+	if !changed {
+		return
+	}
+	event := statusEvent{Event: "status", Server: name, Status: info.Status, Previous: prev, Error: info.Error, Time: time.Now()}
+	if info.Status == manager.StatusError {
+		logs := info.Logs
+		if len(logs) > notifyLogLines {
+			logs = logs[len(logs)-notifyLogLines:]
+		}
+		event.Logs = logs
+	}
+	for _, n := range s.store.GetNotifiers() {
+		if !n.Wants("status", name) {
+			continue
+		}
+		if err := postNotification(n, event, event.text()); err != nil {
+			slog.Warn("webhook delivery failed", "url", n.URL, "server", name, "err", err)
+		}
+	}
+}
+
+// notifyDigest posts the digest to the webhooks that take it.
+func (s *Server) notifyDigest(report digestReport) {
+	payload := map[string]any{"event": "digest", "report": report}
+	for _, n := range s.store.GetNotifiers() {
+		if !n.Wants("digest", "") {
+			continue
+		}
+		if err := postNotification(n, payload, report.Markdown()); err != nil {
+			slog.Warn("webhook delivery failed", "url", n.URL, "event", "digest", "err", err)
+		}
+	}
+}
+
+// postNotification sends payload to a generic webhook, or text to a Slack
+// or Discord one.
+func postNotification(n config.Notifier, payload any, text string) error {
+	switch strings.ToLower(n.Format) {
+	case "", "json":
+	case "slack":
+		payload = map[string]string{"text": text}
+	case "discord":
+		if len(text) > discordMaxContent {
+			text = text[:discordMaxContent-3] + "..."
+		}
+		payload = map[string]string{"content": text}
+	default:
+		return fmt.Errorf("unknown webhook format %q", n.Format)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return nil
+}
+
+// POST /api/notifiers/test - send a sample status change to every webhook
+func (s *Server) handleNotifiersTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	event := statusEvent{
+		Event:    "status",
+		Server:   "example",
+		Status:   manager.StatusError,
+		Previous: manager.StatusHealthy,
+		Error:    "test notification from mcp-manager",
+		Time:     time.Now(),
+	}
+	type result struct {
+		URL   string `json:"url"`
+		Error string `json:"error,omitempty"`
+	}
+	results := make([]result, 0)
+	for _, n := range s.store.GetNotifiers() {
+		res := result{URL: n.URL}
+		if err := postNotification(n, event, event.text()); err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	writeJSON(w, results)
+}
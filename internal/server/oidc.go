@@ -0,0 +1,515 @@
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+const (
+	// oidcStateCookie ties the provider's redirect back to the browser that
+	// started the login
+	oidcStateCookie = "mcp_manager_oidc"
+	oidcLoginTTL    = 10 * time.Minute
+	// oidcClockSkew is tolerated between us and the provider
+	oidcClockSkew = time.Minute
+)
+
+// oidcProvider is the part of the discovery document we use.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcPending is a login sent to the provider and not yet back.
+type oidcPending struct {
+	nonce    string
+	verifier string
+	redirect string
+	expires  time.Time
+}
+
+// oidcClient signs UI users in with the authorization code flow and PKCE.
+// The discovery document and signing keys are fetched on first use and
+// again when the issuer changes or a token names an unknown key.
+type oidcClient struct {
+	http *http.Client
+
+	mu       sync.Mutex
+	issuer   string
+	provider *oidcProvider
+	keys     map[string]crypto.PublicKey
+	pending  map[string]oidcPending
+}
+
+func newOIDCClient() *oidcClient {
+	return &oidcClient{
+		http:    &http.Client{Timeout: 10 * time.Second},
+		pending: make(map[string]oidcPending),
+	}
+}
+
+func (c *oidcClient) getJSON(u string, v any) error {
+	resp, err := c.http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// discover returns the provider of issuer, fetching it when needed.
+func (c *oidcClient) discover(issuer string) (*oidcProvider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.provider != nil && c.issuer == issuer {
+		return c.provider, nil
+	}
+	var p oidcProvider
+	if err := c.getJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &p); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("oidc discovery: issuer is %q, want %q", p.Issuer, issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery: incomplete provider metadata")
+	}
+	c.issuer, c.provider, c.keys = issuer, &p, nil
+	return &p, nil
+}
+
+// key returns the signing key kid, refetching the key set once when it is
+// unknown, as after a key rotation.
+func (c *oidcClient) key(p *oidcProvider, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if k, ok := c.keys[kid]; ok {
+		return k, nil
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(p.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	c.keys = keys
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+// begin records a login and returns its state.
+func (c *oidcClient) begin(redirect string) (state string, pending oidcPending, err error) {
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = newAccessSecret(); err != nil {
+			return "", oidcPending{}, err
+		}
+		secrets[i] = strings.TrimPrefix(secrets[i], accessTokenPrefix)
+	}
+	now := time.Now()
+	pending = oidcPending{nonce: secrets[1], verifier: secrets[2], redirect: redirect, expires: now.Add(oidcLoginTTL)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for st, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, st)
+		}
+	}
+	c.pending[secrets[0]] = pending
+	return secrets[0], pending, nil
+}
+
+// finish takes the login of state; each state is good for one callback.
+func (c *oidcClient) finish(state string) (oidcPending, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[state]
+	delete(c.pending, state)
+	if !ok || time.Now().After(p.expires) {
+		return oidcPending{}, false
+	}
+	return p, true
+}
+
+// exchange trades the authorization code for the raw ID token.
+func (c *oidcClient) exchange(p *oidcProvider, cfg *config.OIDCConfig, code string, pending oidcPending) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {pending.redirect},
+		"client_id":     {cfg.ClientID},
+		"code_verifier": {pending.verifier},
+	}
+	req, err := http.NewRequest("POST", p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token: %s", resp.Status)
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("oidc token: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("oidc token: no id_token in the response")
+	}
+	return body.IDToken, nil
+}
+
+// verify checks the signature and claims of an ID token and returns them.
+func (c *oidcClient) verify(p *oidcProvider, cfg *config.OIDCConfig, raw, nonce string) (map[string]any, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("oidc: malformed id_token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("oidc: malformed id_token signature")
+	}
+	key, err := c.key(p, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("oidc: unsupported id_token algorithm %q", header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, fmt.Errorf("oidc: bad id_token signature")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" || len(sig) != 64 {
+			return nil, fmt.Errorf("oidc: unsupported id_token algorithm %q", header.Alg)
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return nil, fmt.Errorf("oidc: bad id_token signature")
+		}
+	default:
+		return nil, fmt.Errorf("oidc: unsupported signing key %q", header.Kid)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if iss, _ := claims["iss"].(string); iss != p.Issuer {
+		return nil, fmt.Errorf("oidc: id_token issuer is %q", iss)
+	}
+	if !containsString(claimStrings(claims["aud"]), cfg.ClientID) {
+		return nil, fmt.Errorf("oidc: id_token is not for client %q", cfg.ClientID)
+	}
+	exp, _ := claims["exp"].(float64)
+	if time.Now().Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("oidc: id_token expired")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("oidc: id_token nonce mismatch")
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("oidc: malformed id_token")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("oidc: malformed id_token: %w", err)
+	}
+	return nil
+}
+
+// claimStrings reads a claim that is a string or a list of strings.
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// oidcRedirectURL is where the provider sends the browser back. Without a
+// configured redirectUrl it comes from the dashboard address, never from
+// the request's Host or X-Forwarded-Proto, and only while both the listener
+// and the browser are on loopback: elsewhere the address the browser knows
+// us by cannot be trusted from the request and must be configured.
+func (s *Server) oidcRedirectURL(r *http.Request, cfg *config.OIDCConfig) (string, bool) {
+	if cfg.RedirectURL != "" {
+		return cfg.RedirectURL, true
+	}
+	u, err := url.Parse(s.dashboard)
+	if err != nil || u.Scheme != "http" || !isLoopbackHost(u.Hostname()) {
+		return "", false
+	}
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if !isLoopbackHost(host) {
+		return "", false
+	}
+	return strings.TrimSuffix(s.dashboard, "/") + "/api/auth/oidc/callback", true
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// emailAllowed reports whether the token's e-mail may sign in under the
+// domain restriction: the provider must vouch for the address, so a token
+// without email_verified is refused.
+func emailAllowed(claims map[string]any, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	email, _ := claims["email"].(string)
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return false
+	}
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	for _, d := range domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET /api/auth/oidc/login - redirect the browser to the OIDC provider
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	cfg := s.users.OIDC()
+	if cfg == nil {
+		writeError(w, i18n.T("single sign-on is not configured"), 404)
+		return
+	}
+	p, err := s.oidc.discover(cfg.Issuer)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	redirect, ok := s.oidcRedirectURL(r, cfg)
+	if !ok {
+		writeError(w, i18n.T("set the OIDC redirect URL: the dashboard is not reached over loopback"), 409)
+		return
+	}
+	state, pending, err := s.oidc.begin(redirect)
+	if err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+	challenge := sha256.Sum256([]byte(pending.verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {redirect},
+		"scope":                 {"openid " + strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {pending.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	target := p.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + q.Encode()
+	} else {
+		target += "?" + q.Encode()
+	}
+	// Lax, not Strict: the cookie must come back on the provider's redirect
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     s.basePath + "/api/auth/oidc/",
+		MaxAge:   int(oidcLoginTTL / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// GET /api/auth/oidc/callback?code=...&state=... - finish the login, map
+// the user's groups to a role and open a session
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	cfg := s.users.OIDC()
+	if cfg == nil {
+		writeError(w, i18n.T("single sign-on is not configured"), 404)
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		writeError(w, i18n.T("sign-in failed: %s", strings.TrimSpace(e+" "+q.Get("error_description"))), http.StatusUnauthorized)
+		return
+	}
+	state := q.Get("state")
+	c, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || c.Value != state {
+		writeError(w, i18n.T("sign-in expired, try again"), 400)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: s.basePath + "/api/auth/oidc/", MaxAge: -1})
+	pending, ok := s.oidc.finish(state)
+	if !ok {
+		writeError(w, i18n.T("sign-in expired, try again"), 400)
+		return
+	}
+	p, err := s.oidc.discover(cfg.Issuer)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	raw, err := s.oidc.exchange(p, cfg, q.Get("code"), pending)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	claims, err := s.oidc.verify(p, cfg, raw, pending.nonce)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	email, _ := claims["email"].(string)
+	name, _ := claims["preferred_username"].(string)
+	if name == "" {
+		name = email
+	}
+	if name == "" {
+		name, _ = claims["sub"].(string)
+	}
+	if !emailAllowed(claims, cfg.Domains) {
+		writeError(w, i18n.T("%s may not sign in here", name), http.StatusForbidden)
+		return
+	}
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	role := cfg.RoleFor(claimStrings(claims[groupsClaim]))
+	if !role.Valid() {
+		writeError(w, i18n.T("%s may not sign in here", name), http.StatusForbidden)
+		return
+	}
+	if err := s.startSession(w, r, name, role); err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	slog.Info("oidc sign-in", "user", name, "role", role)
+	http.Redirect(w, r, s.basePath+"/", http.StatusFound)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+func TestEmailAllowed(t *testing.T) {
+	domains := []string{"corp.com"}
+	tests := []struct {
+		name    string
+		claims  map[string]any
+		domains []string
+		want    bool
+	}{
+		{"no restriction", map[string]any{}, nil, true},
+		{"verified", map[string]any{"email": "a@corp.com", "email_verified": true}, domains, true},
+		{"domain case", map[string]any{"email": "a@Corp.COM", "email_verified": true}, domains, true},
+		{"other domain", map[string]any{"email": "a@evil.com", "email_verified": true}, domains, false},
+		{"unverified", map[string]any{"email": "a@corp.com", "email_verified": false}, domains, false},
+		{"no email_verified", map[string]any{"email": "a@corp.com"}, domains, false},
+		{"no email", map[string]any{"email_verified": true}, domains, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emailAllowed(tt.claims, tt.domains); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCRedirectURL(t *testing.T) {
+	tests := []struct {
+		name, dashboard, remote, redirect string
+		want                              string
+		ok                                bool
+	}{
+		{"configured", "http://0.0.0.0:8080/", "10.0.0.5:4000", "https://mm.corp.com/cb", "https://mm.corp.com/cb", true},
+		{"loopback", "http://localhost:8080/mm/", "127.0.0.1:4000", "", "http://localhost:8080/mm/api/auth/oidc/callback", true},
+		{"remote browser", "http://localhost:8080/", "10.0.0.5:4000", "", "", false},
+		{"remote listener", "http://10.0.0.1:8080/", "127.0.0.1:4000", "", "", false},
+		{"no tcp listener", "", "127.0.0.1:4000", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{dashboard: tt.dashboard}
+			r := httptest.NewRequest("GET", "/api/auth/oidc/login", nil)
+			r.RemoteAddr = tt.remote
+			r.Host = "evil.example"
+			r.Header.Set("X-Forwarded-Proto", "https")
+			got, ok := s.oidcRedirectURL(r, &config.OIDCConfig{RedirectURL: tt.redirect})
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %q %v, want %q %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
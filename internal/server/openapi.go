@@ -0,0 +1,221 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// apiOp is one operation of the management API, as published in
+// /api/openapi.json. Add an entry with every new endpoint.
+type apiOp struct {
+	method  string
+	path    string
+	summary string
+	// query lists the query parameters
+	query []string
+	// body is set when the operation takes a JSON request body
+	body bool
+	// stream is set for Server-Sent Events responses
+	stream bool
+}
+
+var apiOps = []apiOp{
+	{method: "GET", path: "/api/servers", summary: "List servers with their status, optionally only those with one of the tags", query: []string{"tag"}},
+	{method: "GET", path: "/api/tags", summary: "Tags in use and the servers carrying each"},
+	{method: "GET", path: "/api/servers/{name}", summary: "Server status, tools, prompts, resources and logs"},
+	{method: "PUT", path: "/api/servers/{name}", summary: "Add or update a server", body: true},
+	{method: "DELETE", path: "/api/servers/{name}", summary: "Remove a server"},
+	{method: "GET", path: "/api/servers/{name}/logs", summary: "Persisted server log entries", query: []string{"since", "limit"}},
+	{method: "GET", path: "/api/servers/{name}/logs/stream", summary: "Live server log entries", query: []string{"tail"}, stream: true},
+	{method: "GET", path: "/api/servers/{name}/history", summary: "Check history and uptime over 24h and 7d", query: []string{"since", "limit"}},
+	{method: "GET", path: "/api/servers/{name}/crashes", summary: "Recent crash reports of the server process"},
+	{method: "GET", path: "/api/servers/{name}/captures", summary: "Traffic capture files of the server"},
+	{method: "DELETE", path: "/api/servers/{name}/captures", summary: "Delete the traffic capture files of the server"},
+	{method: "GET", path: "/api/servers/{name}/captures/{file}", summary: "Download a traffic capture file"},
+	{method: "GET", path: "/api/servers/{name}/resources", summary: "List the server's resources upstream", query: []string{"cursor"}},
+	{method: "GET", path: "/api/servers/{name}/resources/read", summary: "Read a resource of the server", query: []string{"uri"}},
+	{method: "POST", path: "/api/servers/{name}/rename", summary: "Rename the server, keeping its status, logs, history and proxy routes", body: true},
+	{method: "POST", path: "/api/servers/{name}/check", summary: "Check the server"},
+	{method: "POST", path: "/api/servers/{name}/check/cancel", summary: "Cancel the running check of the server"},
+	{method: "POST", path: "/api/servers/{name}/prefetch", summary: "Download the server's npx/uvx package into the cache"},
+	{method: "POST", path: "/api/servers/{name}/disable", summary: "Disable the server, optionally for a while", body: true},
+	{method: "POST", path: "/api/servers/{name}/enable", summary: "Enable the server and check it"},
+	{method: "POST", path: "/api/servers/{name}/trust", summary: "Pin the current package hash of the server"},
+	{method: "POST", path: "/api/servers/{name}/tools/{tool}/toggle", summary: "Enable or disable one tool of the server"},
+	{method: "POST", path: "/api/servers/{name}/tools/{tool}/call", summary: "Call a tool of the server directly", body: true},
+	{method: "GET", path: "/api/catalog", summary: "Curated server templates"},
+	{method: "POST", path: "/api/catalog/{id}/add", summary: "Add a server from a template", body: true},
+	{method: "POST", path: "/api/check", summary: "Check a set of servers (default: all enabled)", query: []string{"servers", "wait"}},
+	{method: "GET", path: "/api/checks", summary: "Pending and running checks"},
+	{method: "POST", path: "/api/checks/{id}/cancel", summary: "Cancel a check"},
+	{method: "GET", path: "/api/config", summary: "The full config"},
+	{method: "GET", path: "/api/config/export", summary: "The config, or a client config in another format", query: []string{"format"}},
+	{method: "POST", path: "/api/config/import", summary: "Import a config, or selected servers of it", query: []string{"strategy", "servers", "dryRun"}, body: true},
+	{method: "POST", path: "/api/config/diff", summary: "Difference between two configs", body: true},
+	{method: "GET", path: "/api/import/claude-desktop", summary: "Preview importing servers from Claude Desktop", query: []string{"path", "strategy", "servers"}},
+	{method: "POST", path: "/api/import/claude-desktop", summary: "Import servers from Claude Desktop", query: []string{"path", "strategy", "servers", "overwrite"}},
+	{method: "GET", path: "/api/tools", summary: "Detected CLI tools, or MCP tools matching q", query: []string{"q"}},
+	{method: "GET", path: "/api/tools/{tool}/diff", summary: "What applying the catalog would change in a CLI tool config"},
+	{method: "POST", path: "/api/tools/{tool}/apply", summary: "Apply the catalog to a CLI tool"},
+	{method: "GET", path: "/api/tools/sync", summary: "CLI tool configs out of sync with the catalog"},
+	{method: "POST", path: "/api/tools/sync", summary: "Apply the catalog again to the CLI tools out of sync"},
+	{method: "POST", path: "/api/apply/{tool}/clean", summary: "Remove the entries written by mcp-catalog from a CLI tool config"},
+	{method: "POST", path: "/api/apply/{tool}/prune", summary: "Remove entries of servers no longer in the catalog from a CLI tool config"},
+	{method: "POST", path: "/api/apply-all", summary: "Apply the catalog to every detected CLI tool"},
+	{method: "GET", path: "/api/settings", summary: "Settings and the available locales"},
+	{method: "PUT", path: "/api/settings", summary: "Change settings", body: true},
+	{method: "GET", path: "/api/settings/health", summary: "State of the background health checks"},
+	{method: "GET", path: "/api/summary", summary: "Catalog counters in one request"},
+	{method: "GET", path: "/api/tray", summary: "Fleet state for tray apps", query: []string{"since", "wait"}},
+	{method: "GET", path: "/api/tray/events", summary: "Fleet state changes", stream: true},
+	{method: "POST", path: "/api/tray/open", summary: "Open the dashboard in the default browser"},
+	{method: "GET", path: "/api/tokens", summary: "Proxy access tokens, without secrets"},
+	{method: "POST", path: "/api/tokens", summary: "Issue an access token", body: true},
+	{method: "DELETE", path: "/api/tokens/{name}", summary: "Revoke an access token and close its sessions"},
+	{method: "GET", path: "/api/insights/slow", summary: "Slowest servers with a per-phase breakdown"},
+	{method: "GET", path: "/api/analytics", summary: "Call and token statistics per server and tool"},
+	{method: "GET", path: "/api/audit", summary: "Tool calls made with access tokens, newest first", query: []string{"since", "client", "limit"}},
+	{method: "GET", path: "/api/security", summary: "Secret and PII detection counters"},
+	{method: "GET", path: "/api/digest", summary: "Digest of the current window"},
+	{method: "POST", path: "/api/digest", summary: "Produce and deliver the digest now"},
+	{method: "POST", path: "/api/notifiers/test", summary: "Send a test notification to every webhook"},
+	{method: "GET", path: "/api/breakers", summary: "Circuit breaker state per upstream server"},
+	{method: "GET", path: "/api/concurrency", summary: "maxConcurrent slots, queues and wait times"},
+	{method: "GET", path: "/api/profiles", summary: "Config profiles and the active one"},
+	{method: "POST", path: "/api/profiles", summary: "Create a profile, empty or as a copy", body: true},
+	{method: "POST", path: "/api/profiles/{name}/switch", summary: "Make a profile active"},
+	{method: "POST", path: "/api/profiles/{name}/clone", summary: "Copy a profile", body: true},
+	{method: "DELETE", path: "/api/profiles/{name}", summary: "Delete a profile"},
+	{method: "GET", path: "/api/queue", summary: "Calls held by the retry queue"},
+	{method: "GET", path: "/api/queue/{id}", summary: "A call held by the retry queue and its result"},
+	{method: "GET", path: "/api/events", summary: "The WebSocket messages as Server-Sent Events", query: []string{"calls"}, stream: true},
+	{method: "POST", path: "/api/auth/login", summary: "Log in to the UI with a user name and password", body: true},
+	{method: "POST", path: "/api/auth/logout", summary: "End the UI session"},
+	{method: "GET", path: "/api/auth/me", summary: "The signed-in user and role"},
+	{method: "GET", path: "/api/auth/methods", summary: "Whether passwords and single sign-on are available"},
+	{method: "GET", path: "/api/auth/oidc/login", summary: "Redirect to the OIDC provider to sign in"},
+	{method: "GET", path: "/api/auth/oidc/callback", summary: "Finish signing in with the OIDC provider", query: []string{"code", "state"}},
+	{method: "GET", path: "/api/auth/users", summary: "Users and their roles"},
+	{method: "POST", path: "/api/auth/users", summary: "Create a user or change its role and password", body: true},
+	{method: "DELETE", path: "/api/auth/users/{name}", summary: "Remove a user"},
+	{method: "GET", path: "/api/auth/tokens", summary: "API tokens, without secrets"},
+	{method: "POST", path: "/api/auth/tokens", summary: "Issue an API token with a role", body: true},
+	{method: "DELETE", path: "/api/auth/tokens/{name}", summary: "Revoke an API token"},
+	{method: "POST", path: "/api/shutdown", summary: "Ask this instance to shut down"},
+	{method: "GET", path: "/api/openapi.json", summary: "This document"},
+}
+
+var pathParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// operationID turns "GET /api/servers/{name}/logs" into getServersNameLogs.
+func operationID(op apiOp) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(op.method))
+	for _, seg := range strings.FieldsFunc(strings.TrimPrefix(op.path, "/api/"), func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '{' || r == '}'
+	}) {
+		sb.WriteString(strings.ToUpper(seg[:1]) + seg[1:])
+	}
+	return sb.String()
+}
+
+// openAPISpec builds the OpenAPI 3 document of the management API.
+func openAPISpec() map[string]any {
+	errorResponse := map[string]any{"$ref": "#/components/responses/Error"}
+	paths := make(map[string]map[string]any)
+	for _, op := range apiOps {
+		var params []map[string]any
+		for _, m := range pathParamRe.FindAllStringSubmatch(op.path, -1) {
+			params = append(params, map[string]any{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		for _, q := range op.query {
+			params = append(params, map[string]any{
+				"name": q, "in": "query",
+				"schema": map[string]string{"type": "string"},
+			})
+		}
+		ok := map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/json": map[string]any{}},
+		}
+		if op.stream {
+			ok["content"] = map[string]any{"text/event-stream": map[string]any{}}
+		}
+		tag, _, _ := strings.Cut(strings.TrimPrefix(op.path, "/api/"), "/")
+		operation := map[string]any{
+			"operationId": operationID(op),
+			"summary":     op.summary,
+			"tags":        []string{strings.TrimSuffix(tag, ".json")},
+			"responses":   map[string]any{"200": ok, "default": errorResponse},
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if op.body {
+			operation["requestBody"] = map[string]any{
+				"content": map[string]any{"application/json": map[string]any{
+					"schema": map[string]string{"type": "object"},
+				}},
+			}
+		}
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]any)
+		}
+		paths[op.path][strings.ToLower(op.method)] = operation
+	}
+
+	codes := make([]string, 0, len(errorCodes))
+	for _, status := range sortedStatuses() {
+		codes = append(codes, errorCodes[status])
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "mcp-catalog management API",
+			"version":     "1",
+			"description": "Manage MCP servers, check their health and apply the catalog to CLI tools. Errors are JSON: {\"error\": {\"code\", \"message\"}}.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"Error": map[string]any{
+					"type":     "object",
+					"required": []string{"error"},
+					"properties": map[string]any{
+						"error": map[string]any{
+							"type":     "object",
+							"required": []string{"code", "message"},
+							"properties": map[string]any{
+								"code":    map[string]any{"type": "string", "enum": codes},
+								"message": map[string]any{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error",
+					"content": map[string]any{"application/json": map[string]any{
+						"schema": map[string]string{"$ref": "#/components/schemas/Error"},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// GET /api/openapi.json - OpenAPI 3 description of the management API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, openAPISpec())
+}
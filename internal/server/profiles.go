@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// profileErrorStatus maps profile errors to HTTP statuses.
+func profileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, config.ErrNoProfile):
+		return 404
+	case errors.Is(err, config.ErrProfileExists):
+		return 409
+	}
+	return 400
+}
+
+// GET /api/profiles - the profiles and which one is active
+// POST /api/profiles - create one: {"name": "work", "from": "default"};
+// "from" clones an existing profile, without it the profile starts empty
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		profiles, err := s.store.Profiles()
+		if err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		writeJSON(w, map[string]any{"active": s.store.Profile(), "profiles": profiles})
+	case "POST":
+		var body struct {
+			Name string `json:"name"`
+			From string `json:"from"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		if err := s.store.CreateProfile(body.Name, body.From); err != nil {
+			writeError(w, err.Error(), profileErrorStatus(err))
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+// POST /api/profiles/{name}/switch - make the profile active
+// POST /api/profiles/{name}/clone - copy it: {"name": "client-x"}
+// DELETE /api/profiles/{name}
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/profiles/"), "/")
+	switch {
+	case r.Method == "DELETE" && action == "":
+		if err := s.store.DeleteProfile(name); err != nil {
+			writeError(w, err.Error(), profileErrorStatus(err))
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	case r.Method == "POST" && action == "switch":
+		previous := s.store.Profile()
+		if err := s.mgr.SwitchProfile(name); err != nil {
+			writeError(w, err.Error(), profileErrorStatus(err))
+			return
+		}
+		go s.reconcileWarm()
+		go s.ReportToolSync()
+		s.broadcast(map[string]interface{}{
+			"type":     "profile_switched",
+			"profile":  name,
+			"previous": previous,
+		})
+		writeJSON(w, map[string]string{"status": "ok", "profile": name})
+	case r.Method == "POST" && action == "clone":
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		if err := s.store.CreateProfile(body.Name, name); err != nil {
+			writeError(w, err.Error(), profileErrorStatus(err))
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	case action == "" || action == "switch" || action == "clone":
+		writeError(w, i18n.T("method not allowed"), 405)
+	default:
+		writeError(w, i18n.T("not found"), 404)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// tokenBucket refills at rate tokens per second up to burst
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+func (b *tokenBucket) refill(now time.Time, limit config.RateLimit) {
+	b.rate = limit.RequestsPerSecond
+	b.burst = float64(limit.Burst)
+	if b.burst <= 0 {
+		b.burst = math.Max(1, math.Ceil(b.rate))
+	}
+	if b.last.IsZero() {
+		b.tokens = b.burst
+	} else {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// wait is how long until one token is available
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// rateLimiter holds the global, per-client, per-session and per-server
+// buckets. Limits are read from the config on every call so edits apply
+// without a restart.
+type rateLimiter struct {
+	mu       sync.Mutex
+	global   tokenBucket
+	clients  map[string]*tokenBucket
+	sessions map[string]*tokenBucket
+	servers  map[string]*tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		clients:  make(map[string]*tokenBucket),
+		sessions: make(map[string]*tokenBucket),
+		servers:  make(map[string]*tokenBucket),
+	}
+}
+
+// rateLimitError reports which limit rejected a call and when to retry
+type rateLimitError struct {
+	scope      string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded (%s), retry in %dms", e.scope, e.retryAfter.Milliseconds())
+}
+
+// allow takes one token from every applicable bucket, or none if any of them
+// is empty. clientLimit is the limit of the access token named client.
+func (l *rateLimiter) allow(settings config.RateLimitSettings, sessionID, serverName string, srv *config.MCPServer, client string, clientLimit *config.RateLimit) error {
+	serverLimit := settings.Server
+	if srv != nil && srv.RateLimit != nil {
+		serverLimit = srv.RateLimit
+	}
+
+	type scoped struct {
+		scope  string
+		bucket *tokenBucket
+		limit  *config.RateLimit
+	}
+	var buckets []scoped
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if active(settings.Global) {
+		buckets = append(buckets, scoped{"global", &l.global, settings.Global})
+	}
+	if active(clientLimit) && client != "" {
+		b, ok := l.clients[client]
+		if !ok {
+			b = &tokenBucket{}
+			l.clients[client] = b
+		}
+		buckets = append(buckets, scoped{"client " + client, b, clientLimit})
+	}
+	if active(settings.Session) && sessionID != "" {
+		b, ok := l.sessions[sessionID]
+		if !ok {
+			b = &tokenBucket{}
+			l.sessions[sessionID] = b
+		}
+		buckets = append(buckets, scoped{"session", b, settings.Session})
+	}
+	if active(serverLimit) {
+		b, ok := l.servers[serverName]
+		if !ok {
+			b = &tokenBucket{}
+			l.servers[serverName] = b
+		}
+		buckets = append(buckets, scoped{"server " + serverName, b, serverLimit})
+	}
+
+	now := time.Now()
+	for _, sb := range buckets {
+		sb.bucket.refill(now, *sb.limit)
+		if wait := sb.bucket.wait(); wait > 0 {
+			return &rateLimitError{scope: sb.scope, retryAfter: wait}
+		}
+	}
+	for _, sb := range buckets {
+		sb.bucket.tokens--
+	}
+	return nil
+}
+
+// forget drops the bucket of a closed session
+func (l *rateLimiter) forget(sessionID string) {
+	l.mu.Lock()
+	delete(l.sessions, sessionID)
+	l.mu.Unlock()
+}
+
+// forgetClient drops the bucket of a revoked access token
+func (l *rateLimiter) forgetClient(client string) {
+	l.mu.Lock()
+	delete(l.clients, client)
+	l.mu.Unlock()
+}
+
+func active(limit *config.RateLimit) bool {
+	return limit != nil && limit.RequestsPerSecond > 0
+}
+
+// checkRateLimit applies the configured limits to a call routed to serverName.
+func (s *Server) checkRateLimit(sessionID, serverName string) error {
+	settings := s.store.GetRateLimitSettings()
+	srv, _ := s.store.GetServer(serverName)
+	var clientLimit *config.RateLimit
+	client := s.sessionAccessToken(sessionID)
+	// The token may have been revoked since the session authorized
+	if tok, ok := s.store.GetAccessTokens()[client]; ok && client != "" {
+		clientLimit = tok.RateLimit
+	}
+	if !active(settings.Global) && !active(settings.Session) && !active(settings.Server) && (srv == nil || !active(srv.RateLimit)) && !active(clientLimit) {
+		return nil
+	}
+	return s.limiter.allow(settings, sessionID, serverName, srv, client, clientLimit)
+}
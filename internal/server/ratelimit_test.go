@@ -0,0 +1,35 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// A session whose access token was revoked meanwhile is limited by the
+// other settings only, not by a limit looked up on a missing token.
+func TestCheckRateLimitRevokedToken(t *testing.T) {
+	store := config.NewStore(filepath.Join(t.TempDir(), "config.json"))
+	if err := store.SetAccessToken("ci", &config.AccessToken{Hash: "h", RateLimit: &config.RateLimit{RequestsPerSecond: 1, Burst: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		store:    store,
+		limiter:  newRateLimiter(),
+		mcpState: map[string]*mcpSession{"sess": {Token: "ci"}},
+	}
+
+	if err := s.checkRateLimit("sess", "fs"); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if err := s.checkRateLimit("sess", "fs"); err == nil {
+		t.Fatal("token limit not applied")
+	}
+	if err := store.RemoveAccessToken("ci"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.checkRateLimit("sess", "fs"); err != nil {
+		t.Errorf("after revocation: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// POST /api/servers/{name}/rename - {"name": NEW}; keeps the server's
+// status, logs, history and statistics, and the tools proxy sessions
+// already listed keep working under the names they were given
+func (s *Server) handleServerRename(w http.ResponseWriter, r *http.Request, name string) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	newName := strings.TrimSpace(body.Name)
+	if newName == "" || strings.Contains(newName, "/") {
+		writeError(w, i18n.T("server name is required and must not contain '/'"), 400)
+		return
+	}
+	if newName == name {
+		writeJSON(w, map[string]string{"status": "ok", "name": name})
+		return
+	}
+	if _, ok := s.store.GetServer(name); !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	if _, exists := s.store.GetServer(newName); exists {
+		writeError(w, i18n.T("server %q already exists", newName), http.StatusConflict)
+		return
+	}
+	if _, ok := s.mgr.ActiveCheck(name); ok {
+		writeError(w, i18n.T("server %q is being checked", name), http.StatusConflict)
+		return
+	}
+	if err := s.mgr.RenameServer(name, newName); err != nil {
+		writeError(w, err.Error(), storeErrorStatus(err))
+		return
+	}
+	s.renameProxyState(name, newName)
+	go s.reconcileWarm()
+	s.broadcast(map[string]interface{}{
+		"type": "server_renamed",
+		"from": name,
+		"to":   newName,
+	})
+	slog.Info("server renamed", "server", newName, "from", name)
+	writeJSON(w, map[string]string{"status": "ok", "name": newName})
+}
+
+// renameProxyState points the routes of open proxy sessions, queued calls,
+// breakers, statistics and traffic captures of a server at its new name.
+func (s *Server) renameProxyState(oldName, newName string) {
+	// Swap in new route maps: lookups read them without holding mcpMu
+	s.mcpMu.Lock()
+	for _, ss := range s.mcpState {
+		ss.Tools = retargetRoutes(ss.Tools, func(r *toolRoute) *string { return &r.ServerName }, oldName, newName)
+		ss.Prompts = retargetRoutes(ss.Prompts, func(r *promptRoute) *string { return &r.ServerName }, oldName, newName)
+		ss.Resources = retargetRoutes(ss.Resources, func(r *resourceRoute) *string { return &r.ServerName }, oldName, newName)
+		ss.ResourceTemplates = retargetRoutes(ss.ResourceTemplates, func(r *resourceRoute) *string { return &r.ServerName }, oldName, newName)
+	}
+	s.mcpMu.Unlock()
+	s.SaveSessions()
+
+	s.queue.mu.Lock()
+	for _, call := range s.queue.calls {
+		if call.Server == oldName && call.State == queuedPending {
+			call.Server = newName
+		}
+	}
+	s.queue.mu.Unlock()
+
+	s.breakers.mu.Lock()
+	if b, ok := s.breakers.m[oldName]; ok {
+		s.breakers.m[newName] = b
+		delete(s.breakers.m, oldName)
+	}
+	s.breakers.mu.Unlock()
+
+	s.stats.mu.Lock()
+	if usage, ok := s.stats.servers[oldName]; ok {
+		s.stats.servers[newName] = usage
+		delete(s.stats.servers, oldName)
+	}
+	if tools, ok := s.stats.tools[oldName]; ok {
+		s.stats.tools[newName] = tools
+		delete(s.stats.tools, oldName)
+	}
+	s.stats.mu.Unlock()
+
+	// The warm process was started under the old name; reconcileWarm
+	// replaces it
+	s.warm.mu.Lock()
+	wp, ok := s.warm.procs[oldName]
+	delete(s.warm.procs, oldName)
+	s.warm.mu.Unlock()
+	if ok {
+		wp.proc.close()
+	}
+
+	s.captures.mu.Lock()
+	from, to := s.captures.path(oldName), s.captures.path(newName)
+	for _, suffix := range []string{".jsonl", ".1.jsonl"} {
+		err := os.Rename(strings.TrimSuffix(from, ".jsonl")+suffix, strings.TrimSuffix(to, ".jsonl")+suffix)
+		if err != nil && !os.IsNotExist(err) {
+			slog.Warn("failed to move traffic capture", "server", newName, "err", err)
+		}
+	}
+	s.captures.mu.Unlock()
+}
+
+// retargetRoutes returns a copy of routes with the routes to server oldName
+// sent to newName; serverOf points at a route's server name.
+func retargetRoutes[R any](routes map[string]R, serverOf func(*R) *string, oldName, newName string) map[string]R {
+	if routes == nil {
+		return nil
+	}
+	out := make(map[string]R, len(routes))
+	for key, route := range routes {
+		if p := serverOf(&route); *p == oldName {
+			*p = newName
+		}
+		out[key] = route
+	}
+	return out
+}
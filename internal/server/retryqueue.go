@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+const (
+	// builtinServer is the pseudo server name of tools the proxy serves itself
+	builtinServer = "mcp_catalog"
+	// queuedResultTool looks up the outcome of a queued call
+	queuedResultTool = "queued_result"
+
+	retryQueueTick      = 5 * time.Second
+	retryQueueRetention = 24 * time.Hour
+)
+
+type queuedCallState string
+
+const (
+	queuedPending queuedCallState = "pending"
+	queuedDone    queuedCallState = "done"
+	queuedFailed  queuedCallState = "failed"
+)
+
+// queuedCall is a tools/call accepted while its server was unreachable
+type queuedCall struct {
+	ID          string          `json:"id"`
+	Server      string          `json:"server"`
+	Tool        string          `json:"tool"`
+	Arguments   json.RawMessage `json:"arguments,omitempty"`
+	State       queuedCallState `json:"state"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	QueuedAt    time.Time       `json:"queuedAt"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+	CompletedAt *time.Time      `json:"completedAt,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+}
+
+type retryQueue struct {
+	mu    sync.Mutex
+	calls map[string]*queuedCall
+	seq   int
+	start sync.Once
+}
+
+func newRetryQueue() *retryQueue {
+	return &retryQueue{calls: make(map[string]*queuedCall)}
+}
+
+// retryQueueFor returns the server's queue settings if tool is eligible.
+func retryQueueFor(srv *config.MCPServer, tool string) (config.RetryQueueSettings, bool) {
+	if srv == nil || srv.RetryQueue == nil {
+		return config.RetryQueueSettings{}, false
+	}
+	rq := *srv.RetryQueue
+	if rq.MaxAttempts <= 0 {
+		rq.MaxAttempts = 10
+	}
+	if rq.RetryIntervalSec <= 0 {
+		rq.RetryIntervalSec = 30
+	}
+	for _, t := range rq.Tools {
+		if t == "*" || t == tool {
+			return rq, true
+		}
+	}
+	return rq, false
+}
+
+// maybeQueue stores a failed call for later delivery when its tool is
+// eligible and the failure means the server was unreachable. The returned
+// result tells the client how to fetch the outcome.
+func (s *Server) maybeQueue(route toolRoute, args json.RawMessage, callErr error) (json.RawMessage, bool) {
+	var rpcErr *upstreamError
+	if errors.As(callErr, &rpcErr) {
+		return nil, false
+	}
+	srv, _ := s.store.GetServer(route.ServerName)
+	rq, ok := retryQueueFor(srv, route.ToolName)
+	if !ok {
+		return nil, false
+	}
+
+	q := s.queue
+	q.start.Do(func() { go s.runRetryQueue() })
+	now := time.Now()
+	q.mu.Lock()
+	q.seq++
+	call := &queuedCall{
+		ID:          fmt.Sprintf("q-%d-%d", now.Unix(), q.seq),
+		Server:      route.ServerName,
+		Tool:        route.ToolName,
+		Arguments:   args,
+		State:       queuedPending,
+		Attempts:    1,
+		LastError:   callErr.Error(),
+		QueuedAt:    now,
+		NextAttempt: now.Add(time.Duration(rq.RetryIntervalSec) * time.Second),
+	}
+	q.calls[call.ID] = call
+	q.mu.Unlock()
+	slog.Info("tool call queued", "server", route.ServerName, "tool", route.ToolName, "id", call.ID, "err", callErr)
+
+	text := fmt.Sprintf("Server %q is unreachable (%s). The call was queued as %s and will be retried; "+
+		"call the %s tool with {\"id\": %q} to get its result.", route.ServerName, callErr, call.ID, s.toolNamer().join(builtinServer, queuedResultTool), call.ID)
+	result, _ := json.Marshal(map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"_meta":   map[string]any{"queuedCallId": call.ID},
+	})
+	return result, true
+}
+
+func (s *Server) runRetryQueue() {
+	ticker := time.NewTicker(retryQueueTick)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.retryDue()
+	}
+}
+
+// retryDue attempts every pending call whose retry time has come, one at a
+// time, and forgets finished calls past the retention period.
+func (s *Server) retryDue() {
+	q := s.queue
+	now := time.Now()
+	var due []*queuedCall
+	q.mu.Lock()
+	for id, call := range q.calls {
+		switch {
+		case call.State == queuedPending && !now.Before(call.NextAttempt):
+			due = append(due, call)
+		case call.CompletedAt != nil && now.Sub(*call.CompletedAt) > retryQueueRetention:
+			delete(q.calls, id)
+		}
+	}
+	q.mu.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].QueuedAt.Before(due[j].QueuedAt) })
+
+	for _, call := range due {
+		result, err := s.callTool(context.Background(), call.Server, call.Tool, call.Arguments, proxyTimeout)
+		srv, _ := s.store.GetServer(call.Server)
+		rq, _ := retryQueueFor(srv, call.Tool)
+
+		q.mu.Lock()
+		call.Attempts++
+		done := time.Now()
+		var rpcErr *upstreamError
+		switch {
+		case err == nil:
+			call.State, call.Result, call.LastError = queuedDone, result, ""
+			call.CompletedAt = &done
+		case errors.As(err, &rpcErr) || call.Attempts >= rq.MaxAttempts || srv == nil:
+			call.State, call.LastError = queuedFailed, err.Error()
+			call.CompletedAt = &done
+		default:
+			call.LastError = err.Error()
+			call.NextAttempt = done.Add(time.Duration(rq.RetryIntervalSec) * time.Second)
+		}
+		state := call.State
+		q.mu.Unlock()
+		if state != queuedPending {
+			slog.Info("queued tool call finished", "server", call.Server, "tool", call.Tool, "id", call.ID, "state", state)
+		}
+	}
+}
+
+func (s *Server) queuedCalls() []queuedCall {
+	q := s.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]queuedCall, 0, len(q.calls))
+	for _, call := range q.calls {
+		out = append(out, *call)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].QueuedAt.Before(out[j].QueuedAt) })
+	return out
+}
+
+func (s *Server) queuedCall(id string) (queuedCall, bool) {
+	q := s.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	call, ok := q.calls[id]
+	if !ok {
+		return queuedCall{}, false
+	}
+	return *call, true
+}
+
+// hasRetryQueues reports whether any enabled server queues calls, in which
+// case the queued_result tool is advertised.
+func (s *Server) hasRetryQueues() bool {
+	for _, srv := range s.store.Get().MCPServers {
+		if srv.Enabled && srv.RetryQueue != nil && len(srv.RetryQueue.Tools) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func queuedResultToolDef(name string) proxiedTool {
+	return proxiedTool{
+		Name:        name,
+		Description: "Get the state and result of a tool call that was queued because its server was unreachable.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"id":{"type":"string","description":"Queued call id"}},"required":["id"]}`),
+	}
+}
+
+// builtinToolCall serves tools implemented by the proxy itself.
+func (s *Server) builtinToolCall(tool string, args json.RawMessage) (json.RawMessage, error) {
+	if tool != queuedResultTool {
+		return nil, fmt.Errorf("tool %q not found", tool)
+	}
+	var in struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(args, &in); err != nil || in.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	call, ok := s.queuedCall(in.ID)
+	if !ok {
+		return nil, fmt.Errorf("queued call %q not found", in.ID)
+	}
+	if call.State == queuedDone {
+		// Hand back the upstream result as if the call had just succeeded
+		return call.Result, nil
+	}
+	text := fmt.Sprintf("Call %s to %s is %s after %d attempt(s)", call.ID, s.toolNamer().join(call.Server, call.Tool), call.State, call.Attempts)
+	if call.LastError != "" {
+		text += ": " + call.LastError
+	}
+	result, _ := json.Marshal(map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": call.State == queuedFailed,
+		"_meta":   map[string]any{"queuedCall": call},
+	})
+	return result, nil
+}
+
+// GET /api/queue, GET /api/queue/{id} - calls held by the retry queue
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/queue"), "/")
+	if id == "" {
+		writeJSON(w, s.queuedCalls())
+		return
+	}
+	call, ok := s.queuedCall(id)
+	if !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	writeJSON(w, call)
+}
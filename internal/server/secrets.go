@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// securityReport counts secret/PII detections in proxied results
+type securityReport struct {
+	mu       sync.RWMutex
+	ByKind   map[string]int64 `json:"byKind"`
+	ByServer map[string]int64 `json:"byServer"`
+	Logged   int64            `json:"logged"`
+	Redacted int64            `json:"redacted"`
+	Blocked  int64            `json:"blocked"`
+}
+
+func newSecurityReport() *securityReport {
+	return &securityReport{
+		ByKind:   make(map[string]int64),
+		ByServer: make(map[string]int64),
+	}
+}
+
+func (r *securityReport) record(serverName, action string, kinds map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for kind, n := range kinds {
+		r.ByKind[kind] += int64(n)
+		r.ByServer[serverName] += int64(n)
+	}
+	switch action {
+	case "redact":
+		r.Redacted++
+	case "block":
+		r.Blocked++
+	default:
+		r.Logged++
+	}
+}
+
+func (r *securityReport) snapshot() map[string]any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	byKind := make(map[string]int64, len(r.ByKind))
+	for k, v := range r.ByKind {
+		byKind[k] = v
+	}
+	byServer := make(map[string]int64, len(r.ByServer))
+	for k, v := range r.ByServer {
+		byServer[k] = v
+	}
+	return map[string]any{
+		"byKind":   byKind,
+		"byServer": byServer,
+		"logged":   r.Logged,
+		"redacted": r.Redacted,
+		"blocked":  r.Blocked,
+	}
+}
+
+// detectSecrets counts pattern matches per kind in text.
+func detectSecrets(text string) map[string]int {
+	found := make(map[string]int)
+	for _, p := range secretPatterns {
+		if n := len(p.re.FindAllStringIndex(text, -1)); n > 0 {
+			found[p.kind] += n
+		}
+	}
+	return found
+}
+
+// detectValue counts pattern matches in every string of a decoded JSON
+// value, the same strings redactValue rewrites.
+func detectValue(v any) map[string]int {
+	found := make(map[string]int)
+	var walk func(any)
+	walk = func(v any) {
+		switch t := v.(type) {
+		case string:
+			for kind, n := range detectSecrets(t) {
+				found[kind] += n
+			}
+		case []any:
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]any:
+			for _, e := range t {
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+	return found
+}
+
+func redactSecrets(text string) string {
+	for _, p := range secretPatterns {
+		text = p.re.ReplaceAllString(text, redactedPlaceholder)
+	}
+	return text
+}
+
+// redactValue walks a decoded JSON value and redacts every string in place.
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case string:
+		return redactSecrets(t)
+	case []any:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+		return t
+	case map[string]any:
+		for k := range t {
+			t[k] = redactValue(t[k])
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// scanResult applies the configured secret scan action to a tool result.
+func (s *Server) scanResult(serverName, toolName string, result json.RawMessage) (json.RawMessage, error) {
+	action := strings.ToLower(strings.TrimSpace(s.store.GetSecretScanSettings().Action))
+	if action == "" || len(result) == 0 {
+		return result, nil
+	}
+	// Detection looks at the decoded strings, as redaction does: escapes in
+	// the raw JSON would otherwise hide matches or invent them
+	var decoded any
+	decodeErr := json.Unmarshal(result, &decoded)
+	var kinds map[string]int
+	if decodeErr != nil {
+		kinds = detectSecrets(string(result))
+	} else {
+		kinds = detectValue(decoded)
+	}
+	if len(kinds) == 0 {
+		return result, nil
+	}
+
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	s.security.record(serverName, action, kinds)
+	slog.Warn("secret scan match", "server", serverName, "tool", toolName, "kinds", strings.Join(names, ","), "action", action)
+
+	switch action {
+	case "block":
+		return nil, fmt.Errorf("result blocked: contains sensitive data (%s)", strings.Join(names, ", "))
+	case "redact":
+		if decodeErr != nil {
+			return nil, fmt.Errorf("result blocked: cannot redact undecodable result")
+		}
+		redacted, err := json.Marshal(redactValue(decoded))
+		if err != nil {
+			return nil, fmt.Errorf("result blocked: %w", err)
+		}
+		return redacted, nil
+	default:
+		return result, nil
+	}
+}
+
+// GET /api/security - secret/PII detection counters
+func (s *Server) handleSecurity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	report := s.security.snapshot()
+	report["action"] = s.store.GetSecretScanSettings().Action
+	writeJSON(w, report)
+}
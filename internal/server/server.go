@@ -3,14 +3,24 @@ package server
 import (
 	"embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/naukograd-software/mcp-catalog/internal/catalog"
 	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
 	"github.com/naukograd-software/mcp-catalog/internal/manager"
 )
 
@@ -20,32 +30,83 @@ var staticFiles embed.FS
 type Server struct {
 	store    *config.Store
 	mgr      *manager.Manager
-	clients  map[*websocket.Conn]bool
+	clients  map[*eventClient]bool
 	mu       sync.RWMutex
 	mcpMu    sync.RWMutex
 	mcpState map[string]*mcpSession
+	stats    *analytics
+	security *securityReport
+	digest   *digest
+	limiter  *rateLimiter
+	slots    *serverSlots
+	breakers *breakers
+	queue    *retryQueue
+	warm     *warmPool
+	fleet    *fleetWatch
+	statuses *statusWatch
+	captures *captures
+	// audit keeps the tool calls made with access tokens, nil to only log them
+	audit    AuditLog
 	upgrader websocket.Upgrader
+
+	// sessionsFileMu serializes writes of sessions.json
+	sessionsFileMu sync.Mutex
+	// stdioEndpoint is the named endpoint the stdio proxy serves, "" for all
+	stdioEndpoint string
+	// chaos injects upstream faults in `mcp-manager chaos`, nil otherwise
+	chaos *chaosMonkey
+	// basePath prefixes every route ("/mcp-manager"), "" to serve at the root
+	basePath string
+	// users are the accounts of the UI and API; logins their dashboard sessions
+	users  *config.Users
+	logins *uiSessions
+	// oidc signs users in with the provider in users.json, if any
+	oidc *oidcClient
+	// shutdownKey authorizes POST /api/shutdown from --takeover
+	shutdownKey string
+	// dashboard is the UI address tray apps open, from the listen address
+	dashboard string
+
+	shutdownOnce sync.Once
+	shutdown     chan struct{}
 }
 
 func New(store *config.Store, mgr *manager.Manager) *Server {
 	s := &Server{
 		store:    store,
 		mgr:      mgr,
-		clients:  make(map[*websocket.Conn]bool),
+		clients:  make(map[*eventClient]bool),
 		mcpState: make(map[string]*mcpSession),
+		stats:    newAnalytics(),
+		security: newSecurityReport(),
+		digest:   newDigest(),
+		limiter:  newRateLimiter(),
+		slots:    newServerSlots(),
+		breakers: newBreakers(store),
+		queue:    newRetryQueue(),
+		warm:     newWarmPool(),
+		fleet:    newFleetWatch(),
+		statuses: newStatusWatch(),
+		captures: newCaptures(filepath.Join(store.Dir(), "captures")),
+		users:    config.NewUsers(store.Dir()),
+		logins:   newUISessions(),
+		oidc:     newOIDCClient(),
+		shutdown: make(chan struct{}),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool { return true },
 		},
 	}
 
+	s.loadSessions()
+	if err := s.users.Load(); err != nil {
+		slog.Error("cannot read users, the API is locked until the file is fixed", "path", s.users.Path(), "err", err)
+	}
+
 	// Subscribe to manager events
-	mgr.OnChange(func(name string, info *manager.ServerInfo) {
-		s.broadcast(map[string]interface{}{
-			"type":   "server_update",
-			"name":   name,
-			"server": info,
-		})
-	})
+	mgr.OnChange(s.digest.observe)
+	mgr.OnChange(s.observeStatus)
+	mgr.OnChange(func(string, *manager.ServerInfo) { s.refreshFleet() })
+	mgr.OnChange(s.broadcastServer)
 
 	return s
 }
@@ -55,47 +116,194 @@ func (s *Server) Handler() http.Handler {
 
 	// API routes
 	mux.HandleFunc("/api/servers", s.handleServers)
+	mux.HandleFunc("/api/tags", s.handleTags)
 	mux.HandleFunc("/api/servers/", s.handleServer)
 	mux.HandleFunc("/api/config", s.handleConfig)
 	mux.HandleFunc("/api/config/export", s.handleExport)
 	mux.HandleFunc("/api/config/import", s.handleImport)
+	mux.HandleFunc("/api/config/diff", s.handleConfigDiff)
+	mux.HandleFunc("/api/import/claude-desktop", s.handleImportDesktop)
 	mux.HandleFunc("/api/tools", s.handleTools)
 	mux.HandleFunc("/api/tools/", s.handleToolAction)
+	mux.HandleFunc("/api/tools/sync", s.handleToolSync)
+	mux.HandleFunc("/api/apply/", s.handleApplyAction)
+	mux.HandleFunc("/api/apply-all", s.handleApplyAll)
 	mux.HandleFunc("/api/settings", s.handleSettings)
+	mux.HandleFunc("/api/settings/health", s.handleHealthStatus)
+	mux.HandleFunc("/api/summary", s.handleSummary)
+	mux.HandleFunc("/api/tray", s.handleTray)
+	mux.HandleFunc("/api/tray/events", s.handleTrayEvents)
+	mux.HandleFunc("/api/tray/open", s.handleTrayOpen)
+	mux.HandleFunc("/api/tokens", s.handleAccessTokens)
+	mux.HandleFunc("/api/tokens/", s.handleAccessToken)
+	mux.HandleFunc("/api/check", s.handleCheckBatch)
+	mux.HandleFunc("/api/checks", s.handleChecks)
+	mux.HandleFunc("/api/checks/", s.handleCheckAction)
+	mux.HandleFunc("/api/insights/slow", s.handleSlowServers)
+	mux.HandleFunc("/api/catalog", s.handleCatalog)
+	mux.HandleFunc("/api/catalog/", s.handleCatalogAdd)
+	mux.HandleFunc("/api/analytics", s.handleAnalytics)
+	mux.HandleFunc("/api/audit", s.handleAudit)
+	mux.HandleFunc("/api/security", s.handleSecurity)
+	mux.HandleFunc("/api/digest", s.handleDigest)
+	mux.HandleFunc("/api/notifiers/test", s.handleNotifiersTest)
+	mux.HandleFunc("/api/shutdown", s.handleShutdown)
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/auth/login", s.handleLogin)
+	mux.HandleFunc("/api/auth/logout", s.handleLogout)
+	mux.HandleFunc("/api/auth/me", s.handleMe)
+	mux.HandleFunc("/api/auth/methods", s.handleAuthMethods)
+	mux.HandleFunc("/api/auth/oidc/login", s.handleOIDCLogin)
+	mux.HandleFunc("/api/auth/oidc/callback", s.handleOIDCCallback)
+	mux.HandleFunc("/api/auth/users", s.handleUsers)
+	mux.HandleFunc("/api/auth/users/", s.handleUser)
+	mux.HandleFunc("/api/auth/tokens", s.handleAPITokens)
+	mux.HandleFunc("/api/auth/tokens/", s.handleAPIToken)
 	mux.HandleFunc("/ws", s.handleWS)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/breakers", s.handleBreakers)
+	mux.HandleFunc("/api/concurrency", s.handleConcurrency)
+	mux.HandleFunc("/api/profiles", s.handleProfiles)
+	mux.HandleFunc("/api/profiles/", s.handleProfile)
+	mux.HandleFunc("/api/queue", s.handleQueue)
+	mux.HandleFunc("/api/queue/", s.handleQueue)
 	mux.HandleFunc("/mcp", s.handleMCPProxy)
+	mux.HandleFunc("/mcp/", s.handleMCPProxy)
 
 	// Static files
 	staticFS, err := fs.Sub(staticFiles, "static")
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
 	mux.Handle("/", http.FileServer(http.FS(staticFS)))
 
+	return s.wrap(s.authMiddleware(mux))
+}
+
+// ProxyHandler serves the MCP proxy alone, for listeners that clients reach
+// but that must not expose the UI or the management API.
+func (s *Server) ProxyHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", s.handleMCPProxy)
+	mux.HandleFunc("/mcp/", s.handleMCPProxy)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, i18n.T("not found"), 404)
+	})
+	return s.wrap(mux)
+}
+
+func (s *Server) wrap(mux http.Handler) http.Handler {
+	if s.basePath != "" {
+		return recoveryMiddleware(withBasePath(s.basePath, mux))
+	}
 	return recoveryMiddleware(mux)
 }
 
+// SetBasePath serves every route, the UI included, under prefix (e.g.
+// "/mcp-manager"), for reverse proxies that pass the prefix through.
+// Call it before Handler.
+func (s *Server) SetBasePath(prefix string) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	s.basePath = prefix
+}
+
+// BasePath returns the prefix set by SetBasePath, "" for none.
+func (s *Server) BasePath() string {
+	return s.basePath
+}
+
+// withBasePath strips prefix before routing; requests outside it are 404.
+// The bare prefix redirects to prefix/, where the UI resolves its relative
+// API and WebSocket URLs.
+func withBasePath(prefix string, next http.Handler) http.Handler {
+	strip := http.StripPrefix(prefix, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix:
+			target := prefix + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, prefix+"/"):
+			strip.ServeHTTP(w, r)
+		default:
+			writeError(w, i18n.T("not found"), 404)
+		}
+	})
+}
+
 func recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("panic recovered: %v [%s %s]", err, r.Method, r.URL.Path)
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				slog.Error("panic recovered", "err", err, "method", r.Method, "path", r.URL.Path)
+				writeError(w, i18n.T("internal server error"), http.StatusInternalServerError)
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// GET /api/servers - list all servers with status
+// GET /api/servers?tag=db,cloud - list all servers with status; with tag,
+// only those carrying one of the tags
 func (s *Server) handleServers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
-		http.Error(w, "method not allowed", 405)
+		writeError(w, i18n.T("method not allowed"), 405)
 		return
 	}
 
 	info := s.mgr.GetAllInfo()
-	writeJSON(w, info)
+	if tags := queryList(r, "tag"); len(tags) > 0 {
+		for name, si := range info {
+			if !si.Config.HasAnyTag(tags) {
+				delete(info, name)
+			}
+		}
+	}
+	writeJSON(w, viewInfos(info, s.mayReadEnv(r)))
+}
+
+// queryList reads a query parameter given repeatedly or comma-separated.
+func queryList(r *http.Request, key string) []string {
+	var out []string
+	for _, v := range r.URL.Query()[key] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+type tagCount struct {
+	Tag     string   `json:"tag"`
+	Servers []string `json:"servers"`
+}
+
+// GET /api/tags - the tags in use and the servers carrying each
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	byTag := make(map[string][]string)
+	for name, srv := range s.store.Get().MCPServers {
+		for _, tag := range srv.Tags {
+			byTag[tag] = append(byTag[tag], name)
+		}
+	}
+	tags := make([]tagCount, 0, len(byTag))
+	for tag, names := range byTag {
+		sort.Strings(names)
+		tags = append(tags, tagCount{Tag: tag, Servers: names})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	writeJSON(w, tags)
 }
 
 // /api/servers/{name} - manage a specific server
@@ -110,22 +318,50 @@ func (s *Server) handleServer(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case "GET":
+		if action == "logs" {
+			s.handleServerLogs(w, r, name)
+			return
+		}
+		if action == "logs/stream" {
+			s.handleServerLogStream(w, r, name)
+			return
+		}
+		if action == "crashes" {
+			writeJSON(w, s.mgr.Crashes(name))
+			return
+		}
+		if action == "history" {
+			s.handleServerHistory(w, r, name)
+			return
+		}
+		if file, ok := strings.CutPrefix(action, "captures"); ok && (file == "" || file[0] == '/') {
+			s.handleServerCaptures(w, r, name, strings.TrimPrefix(file, "/"))
+			return
+		}
+		if action == "resources" {
+			s.handleServerResources(w, r, name)
+			return
+		}
+		if action == "resources/read" {
+			s.handleServerResourceRead(w, r, name)
+			return
+		}
 		info, ok := s.mgr.GetInfo(name)
 		if !ok {
-			http.Error(w, "not found", 404)
+			writeError(w, i18n.T("not found"), 404)
 			return
 		}
-		writeJSON(w, info)
+		writeJSON(w, viewInfo(info, s.mayReadEnv(r)))
 
 	case "PUT":
 		// Add or update server
 		var srv config.MCPServer
 		if err := json.NewDecoder(r.Body).Decode(&srv); err != nil {
-			http.Error(w, err.Error(), 400)
+			writeError(w, err.Error(), 400)
 			return
 		}
 		if err := s.store.AddServer(name, &srv); err != nil {
-			http.Error(w, err.Error(), 500)
+			writeError(w, err.Error(), storeErrorStatus(err))
 			return
 		}
 		if srv.Enabled {
@@ -134,25 +370,447 @@ func (s *Server) handleServer(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, map[string]string{"status": "ok"})
 
 	case "DELETE":
+		if action == "captures" {
+			s.handleServerCaptures(w, r, name, "")
+			return
+		}
 		s.mgr.RemoveServer(name)
 		if err := s.store.RemoveServer(name); err != nil {
-			http.Error(w, err.Error(), 500)
+			writeError(w, err.Error(), 500)
 			return
 		}
+		s.mgr.PruneAfterRemove()
 		writeJSON(w, map[string]string{"status": "ok"})
 
 	case "POST":
 		switch action {
+		case "rename":
+			s.handleServerRename(w, r, name)
 		case "check":
+			if job, ok := s.mgr.ActiveCheck(name); ok {
+				writeJSON(w, map[string]string{"status": "already checking", "checkId": job.ID})
+				return
+			}
 			go s.mgr.Check(name)
 			writeJSON(w, map[string]string{"status": "ok"})
+		case "check/cancel":
+			job, ok := s.mgr.CancelServerCheck(name)
+			if !ok {
+				writeError(w, i18n.T("no check in progress"), 404)
+				return
+			}
+			writeJSON(w, map[string]string{"status": "ok", "checkId": job.ID})
+		case "prefetch":
+			go s.mgr.Prefetch(name)
+			writeJSON(w, map[string]string{"status": "ok"})
+		case "disable":
+			s.handleServerDisable(w, r, name)
+		case "enable":
+			if _, ok := s.store.GetServer(name); !ok {
+				writeError(w, i18n.T("not found"), 404)
+				return
+			}
+			if err := s.mgr.Enable(name); err != nil {
+				writeError(w, err.Error(), storeErrorStatus(err))
+				return
+			}
+			writeJSON(w, map[string]string{"status": "ok"})
+		case "trust":
+			hash, err := s.mgr.Trust(name)
+			if err != nil {
+				writeError(w, err.Error(), 400)
+				return
+			}
+			writeJSON(w, map[string]string{"status": "ok", "integrity": hash})
 		default:
-			http.Error(w, "unknown action", 400)
+			if tool, ok := strings.CutPrefix(action, "tools/"); ok && strings.HasSuffix(tool, "/toggle") {
+				s.handleToolToggle(w, name, strings.TrimSuffix(tool, "/toggle"))
+				return
+			}
+			if tool, ok := strings.CutPrefix(action, "tools/"); ok && strings.HasSuffix(tool, "/call") {
+				s.handleToolCall(w, r, name, strings.TrimSuffix(tool, "/call"))
+				return
+			}
+			writeError(w, i18n.T("unknown action"), 400)
 		}
 
 	default:
-		http.Error(w, "method not allowed", 405)
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+// POST /api/servers/{name}/disable - switch a server off with
+// {"reason": "...", "until": RFC3339 | "for": "2h", "remind": bool}; at the
+// end of the snooze it is enabled again, or with remind only a warning is logged
+func (s *Server) handleServerDisable(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.store.GetServer(name); !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	var req struct {
+		Reason string     `json:"reason"`
+		Until  *time.Time `json:"until"`
+		For    string     `json:"for"`
+		Remind bool       `json:"remind"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	until := req.Until
+	if req.For != "" {
+		d, err := time.ParseDuration(req.For)
+		if err != nil || d <= 0 {
+			writeError(w, i18n.T("invalid snooze duration %q", req.For), 400)
+			return
+		}
+		t := time.Now().Add(d).UTC()
+		until = &t
+	}
+	if until != nil && !until.After(time.Now()) {
+		writeError(w, i18n.T("snooze end is in the past"), 400)
+		return
+	}
+	if err := s.mgr.Disable(name, strings.TrimSpace(req.Reason), until, req.Remind); err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// POST /api/servers/{name}/tools/{tool}/toggle - switch one tool on or off
+func (s *Server) handleToolToggle(w http.ResponseWriter, name, tool string) {
+	if tool == "" {
+		writeError(w, i18n.T("tool name required"), 400)
+		return
+	}
+	enabled, err := s.mgr.ToggleTool(name, tool)
+	if err != nil {
+		writeError(w, err.Error(), 404)
+		return
+	}
+	writeJSON(w, map[string]any{"status": "ok", "tool": tool, "enabled": enabled})
+}
+
+// POST /api/servers/{name}/tools/{tool}/call - call a tool directly with
+// {"arguments": {...}, "timeoutMs": N}, bypassing MCP sessions
+func (s *Server) handleToolCall(w http.ResponseWriter, r *http.Request, name, tool string) {
+	if tool == "" {
+		writeError(w, i18n.T("tool name required"), 400)
+		return
+	}
+	if _, ok := s.store.GetServer(name); !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	var req struct {
+		Arguments json.RawMessage `json:"arguments"`
+		TimeoutMs int             `json:"timeoutMs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	start := time.Now()
+	route := toolRoute{ServerName: name, ToolName: tool}
+	result, _, err := s.proxyToolCall(r.Context(), route, req.Arguments, s.callTimeout(&toolsCallMeta{TimeoutMs: req.TimeoutMs}))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"result":     result,
+		"durationMs": time.Since(start).Milliseconds(),
+	})
+}
+
+// GET /api/servers/{name}/resources?cursor= - live resources/list of one upstream
+func (s *Server) handleServerResources(w http.ResponseWriter, r *http.Request, name string) {
+	srv, ok := s.store.GetServer(name)
+	if !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	params := map[string]any{}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		params["cursor"] = cursor
+	}
+	result, err := s.forwardMCPWithTimeout(r.Context(), proxyTimeout, name, srv, "resources/list", params)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(result)
+}
+
+// GET /api/servers/{name}/resources/read?uri= - fetch one resource from the upstream
+func (s *Server) handleServerResourceRead(w http.ResponseWriter, r *http.Request, name string) {
+	uri := r.URL.Query().Get("uri")
+	if uri == "" {
+		writeError(w, i18n.T("uri is required"), 400)
+		return
+	}
+	srv, ok := s.store.GetServer(name)
+	if !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	result, err := s.forwardMCPWithTimeout(r.Context(), proxyTimeout, name, srv, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(result)
+}
+
+// GET /api/servers/{name}/logs?since=RFC3339&limit=N - persisted log history
+func (s *Server) handleServerLogs(w http.ResponseWriter, r *http.Request, name string) {
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, i18n.T("invalid since: %v", err), 400)
+			return
+		}
+		since = t
+	}
+	limit := 1000
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, i18n.T("invalid limit"), 400)
+			return
+		}
+		limit = n
+	}
+	entries, err := s.mgr.History(name, since, limit)
+	if err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+// GET /api/servers/{name}/history?since=RFC3339&limit=N - check results of
+// the last 7 days with uptime and average latency over 24h and 7d
+func (s *Server) handleServerHistory(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.store.GetServer(name); !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, i18n.T("invalid since: %v", err), 400)
+			return
+		}
+		since = t
+	}
+	limit := 1000
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, i18n.T("invalid limit"), 400)
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, s.mgr.CheckHistory(name, since, limit))
+}
+
+// GET /api/servers/{name}/logs/stream?tail=N - Server-Sent Events with new
+// log entries; tail replays the last N entries kept in memory first.
+func (s *Server) handleServerLogStream(w http.ResponseWriter, r *http.Request, name string) {
+	info, ok := s.mgr.GetInfo(name)
+	if !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, i18n.T("streaming unsupported"), 500)
+		return
+	}
+	entries, unsubscribe := s.mgr.SubscribeLogs(name)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(200)
+
+	send := func(e manager.LogEntry) {
+		data, _ := json.Marshal(e)
+		fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("tail")); err == nil && n > 0 {
+		backlog := info.Logs
+		if len(backlog) > n {
+			backlog = backlog[len(backlog)-n:]
+		}
+		for _, e := range backlog {
+			send(e)
+		}
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-entries:
+			send(e)
+			flusher.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// POST /api/check?servers=a,b,c - check a set of servers (default: all enabled)
+// and stream per-server progress over the WebSocket as "check_batch" events.
+// With wait=1 the call blocks and returns the resulting server infos.
+func (s *Server) handleCheckBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+
+	var names []string
+	if raw := strings.TrimSpace(r.URL.Query().Get("servers")); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := s.store.GetServer(name); !ok {
+				writeError(w, i18n.T("server %q not found", name), 404)
+				return
+			}
+			names = append(names, name)
+		}
+	} else {
+		for name, srv := range s.store.Get().MCPServers {
+			if srv.Enabled {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	batchID, err := newSessionID()
+	if err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+
+	if r.URL.Query().Get("wait") == "1" {
+		s.runCheckBatch(batchID, names)
+		result := make(map[string]*manager.ServerInfo)
+		for _, name := range names {
+			if info, ok := s.mgr.GetInfo(name); ok {
+				result[name] = info
+			}
+		}
+		writeJSON(w, viewInfos(result, s.mayReadEnv(r)))
+		return
+	}
+
+	go s.runCheckBatch(batchID, names)
+	writeJSON(w, map[string]interface{}{"batchId": batchID, "servers": names})
+}
+
+func (s *Server) runCheckBatch(batchID string, names []string) {
+	s.broadcast(map[string]interface{}{
+		"type":    "check_batch",
+		"event":   "started",
+		"batchId": batchID,
+		"servers": names,
+		"total":   len(names),
+	})
+	failed := 0
+	for i, name := range names {
+		event := map[string]interface{}{
+			"type":      "check_batch",
+			"event":     "progress",
+			"batchId":   batchID,
+			"server":    name,
+			"completed": i + 1,
+			"total":     len(names),
+			"status":    manager.StatusHealthy,
+		}
+		if err := s.mgr.Check(name); err != nil {
+			failed++
+			event["status"] = manager.StatusError
+			event["error"] = err.Error()
+		}
+		s.broadcast(event)
+	}
+	s.broadcast(map[string]interface{}{
+		"type":    "check_batch",
+		"event":   "done",
+		"batchId": batchID,
+		"total":   len(names),
+		"failed":  failed,
+	})
+}
+
+// GET /api/checks - pending and running health checks
+func (s *Server) handleChecks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
 	}
+	writeJSON(w, s.mgr.Checks())
+}
+
+// POST /api/checks/{id}/cancel
+func (s *Server) handleCheckAction(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/checks/")
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[1] != "cancel" {
+		writeError(w, i18n.T("unknown action"), 400)
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	if err := s.mgr.CancelCheck(parts[0]); err != nil {
+		writeError(w, err.Error(), 404)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// ShutdownRequested is closed when another instance asks this one to exit.
+func (s *Server) ShutdownRequested() <-chan struct{} {
+	return s.shutdown
+}
+
+// POST /api/shutdown - used by `mcp-manager --takeover`
+func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+}
+
+// GET /api/insights/slow - servers by check duration with a per-phase breakdown
+func (s *Server) handleSlowServers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, s.mgr.SlowServers())
 }
 
 // GET /api/config - get full config
@@ -162,26 +820,59 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 		cfg := s.store.Get()
 		writeJSON(w, cfg)
 	case "PUT":
-		var cfg config.Config
-		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-			http.Error(w, err.Error(), 400)
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		cfg, err := config.Parse(data)
+		if err != nil {
+			writeError(w, err.Error(), 400)
 			return
 		}
-		if err := s.store.Set(&cfg); err != nil {
-			http.Error(w, err.Error(), 500)
+		if err := s.store.Set(cfg); err != nil {
+			writeError(w, err.Error(), storeErrorStatus(err))
 			return
 		}
 		writeJSON(w, map[string]string{"status": "ok"})
 	default:
-		http.Error(w, "method not allowed", 405)
+		writeError(w, i18n.T("method not allowed"), 405)
 	}
 }
 
-// GET /api/config/export
+// GET /api/config/export - the catalog config
+// GET /api/config/export?format=claude-desktop|vscode|mcp-json|codex|opencode
+// - the enabled servers as a client config
+// GET /api/config/export?format=list - the client formats
 func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "list" {
+		writeJSON(w, manager.ExportFormats)
+		return
+	}
+	if format != "" && format != "catalog" {
+		f, ok := manager.FindExportFormat(format)
+		if !ok {
+			writeError(w, i18n.T("unknown export format %q", format), 400)
+			return
+		}
+		data, err := s.mgr.ExportServers(format)
+		if err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		if strings.HasSuffix(f.Filename, ".toml") {
+			w.Header().Set("Content-Type", "application/toml")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Header().Set("Content-Disposition", "attachment; filename="+f.Filename)
+		w.Write(data)
+		return
+	}
 	data, err := s.store.Export()
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, err.Error(), 500)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -189,28 +880,155 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
-// POST /api/config/import
+// POST /api/config/import - replace the config, or with ?strategy=
+// (skip, overwrite, rename), ?servers=a,b or ?dryRun=1 merge only its
+// servers and report what was added, changed and skipped
 func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, "method not allowed", 405)
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err.Error(), 400)
 		return
 	}
-	var cfg config.Config
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		http.Error(w, err.Error(), 400)
+	cfg, err := config.Parse(data)
+	if err != nil {
+		writeError(w, err.Error(), 400)
 		return
 	}
-	if err := s.store.Set(&cfg); err != nil {
-		http.Error(w, err.Error(), 500)
+	q := r.URL.Query()
+	if q.Has("strategy") || q.Has("servers") || q.Has("dryRun") {
+		s.importServers(w, "request", cfg.MCPServers, importOptions(q), isTrue(q.Get("dryRun")))
+		return
+	}
+	if err := s.store.Set(cfg); err != nil {
+		writeError(w, err.Error(), storeErrorStatus(err))
 		return
 	}
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
+// importOptions reads ?strategy= and ?servers=a,b of an import request.
+func importOptions(q url.Values) manager.ImportOptions {
+	opts := manager.ImportOptions{Strategy: q.Get("strategy")}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(q.Get("servers"), ",") {
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			opts.Servers = append(opts.Servers, name)
+		}
+	}
+	return opts
+}
+
+func isTrue(v string) bool {
+	return v == "1" || v == "true"
+}
+
+// importServers merges incoming servers with opts and writes the report,
+// checking the enabled servers it added or changed.
+func (s *Server) importServers(w http.ResponseWriter, source string, incoming map[string]*config.MCPServer, opts manager.ImportOptions, dryRun bool) {
+	if err := opts.Validate(incoming); err != nil {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	plan, err := s.mgr.ImportServers(source, incoming, opts, dryRun)
+	if err != nil {
+		writeError(w, err.Error(), storeErrorStatus(err))
+		return
+	}
+	if !plan.DryRun {
+		cfg := s.store.Get()
+		for _, name := range plan.Imported() {
+			if srv := cfg.MCPServers[name]; srv != nil && srv.Enabled {
+				go s.mgr.Check(name)
+			}
+		}
+	}
+	writeJSON(w, plan)
+}
+
+// POST /api/config/diff - {"from": config, "to": config}; a missing side is
+// the current config
+func (s *Server) handleConfigDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	var body struct {
+		From json.RawMessage `json:"from"`
+		To   json.RawMessage `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	side := func(raw json.RawMessage, name string) (*config.Config, error) {
+		if len(raw) == 0 || string(raw) == "null" {
+			return s.store.Get(), nil
+		}
+		cfg, err := config.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return cfg, nil
+	}
+	from, err := side(body.From, "from")
+	if err != nil {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	to, err := side(body.To, "to")
+	if err != nil {
+		writeError(w, err.Error(), 400)
+		return
+	}
+	diff, err := config.Diff(from, to)
+	if err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, diff)
+}
+
+// GET /api/import/claude-desktop - preview merging Claude Desktop's servers
+// POST /api/import/claude-desktop - merge them
+// ?path= reads another claude_desktop_config.json; ?strategy= and ?servers=
+// as for /api/config/import (?overwrite=1 is strategy=overwrite)
+func (s *Server) handleImportDesktop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	q := r.URL.Query()
+	path, servers, err := manager.ReadClaudeDesktop(q.Get("path"))
+	if err != nil {
+		status := 500
+		if errors.Is(err, fs.ErrNotExist) {
+			status = 404
+		}
+		writeError(w, err.Error(), status)
+		return
+	}
+	opts := importOptions(q)
+	opts.Merge = true
+	if opts.Strategy == "" && isTrue(q.Get("overwrite")) {
+		opts.Strategy = manager.StrategyOverwrite
+	}
+	s.importServers(w, path, servers, opts, r.Method == "GET")
+}
+
 // GET /api/tools - list installed CLI tools
+// GET /api/tools?q= - search MCP tools discovered by the last checks
 func (s *Server) handleTools(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
-		http.Error(w, "method not allowed", 405)
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	if r.URL.Query().Has("q") {
+		writeJSON(w, s.mgr.SearchTools(r.URL.Query().Get("q")))
 		return
 	}
 	tools := s.mgr.DetectTools()
@@ -230,111 +1048,242 @@ func (s *Server) handleToolAction(w http.ResponseWriter, r *http.Request) {
 	switch action {
 	case "diff":
 		if r.Method != "GET" {
-			http.Error(w, "method not allowed", 405)
+			writeError(w, i18n.T("method not allowed"), 405)
 			return
 		}
 		diff, err := s.mgr.PreviewApply(name)
 		if err != nil {
-			http.Error(w, err.Error(), 500)
+			writeError(w, err.Error(), 500)
 			return
 		}
 		writeJSON(w, diff)
 
 	case "apply":
 		if r.Method != "POST" {
-			http.Error(w, "method not allowed", 405)
+			writeError(w, i18n.T("method not allowed"), 405)
 			return
 		}
 		if err := s.mgr.ApplyToTool(name); err != nil {
-			http.Error(w, err.Error(), 500)
+			writeError(w, err.Error(), 500)
 			return
 		}
 		writeJSON(w, map[string]string{"status": "ok"})
 
 	default:
-		http.Error(w, "unknown action", 400)
+		writeError(w, i18n.T("unknown action"), 400)
 	}
 }
 
-// GET/PUT /api/settings
-func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+// GET /api/tools/sync - which applied CLI tool configs no longer match the catalog
+// POST /api/tools/sync - re-apply the catalog to those
+func (s *Server) handleToolSync(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		writeJSON(w, map[string]int{
-			"healthCheckInterval": s.store.GetHealthCheckInterval(),
-		})
-	case "PUT":
-		var body struct {
-			HealthCheckInterval int `json:"healthCheckInterval"`
+		writeJSON(w, s.mgr.ToolSyncStatus())
+	case "POST":
+		results := s.mgr.ReapplyStale()
+		s.ReportToolSync()
+		writeJSON(w, results)
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+// ReportToolSync logs and broadcasts which applied CLI tool configs are out
+// of sync with the catalog, e.g. after editing servers without pressing
+// Apply.
+func (s *Server) ReportToolSync() {
+	var stale []manager.ToolSync
+	for _, st := range s.mgr.ToolSyncStatus() {
+		if !st.InSync && st.Error == "" {
+			stale = append(stale, st)
 		}
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			http.Error(w, err.Error(), 400)
+	}
+	if len(stale) > 0 {
+		names := make([]string, len(stale))
+		for i, st := range stale {
+			names[i] = st.Tool
+		}
+		slog.Warn(fmt.Sprintf("%d tools out of sync with the catalog; press Apply to update them", len(stale)), "tools", names)
+	}
+	s.broadcast(map[string]interface{}{
+		"type":  "tools_sync",
+		"stale": stale,
+	})
+}
+
+// POST /api/apply/{tool}/clean - remove entries written by mcp-catalog from a tool config
+// POST /api/apply/{tool}/prune - remove only our entries for servers no longer in the catalog
+func (s *Server) handleApplyAction(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/apply/")
+	parts := strings.SplitN(path, "/", 2)
+	name := parts[0]
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch action {
+	case "clean":
+		if r.Method != "POST" {
+			writeError(w, i18n.T("method not allowed"), 405)
 			return
 		}
-		if err := s.store.SetHealthCheckInterval(body.HealthCheckInterval); err != nil {
-			http.Error(w, err.Error(), 500)
+		diff, err := s.mgr.CleanTool(name)
+		if err != nil {
+			writeError(w, err.Error(), 500)
 			return
 		}
-		s.mgr.SetHealthInterval(body.HealthCheckInterval)
-		writeJSON(w, map[string]string{"status": "ok"})
+		writeJSON(w, diff)
+
+	case "prune":
+		if r.Method != "POST" {
+			writeError(w, i18n.T("method not allowed"), 405)
+			return
+		}
+		diff, pruned, err := s.mgr.PruneTool(name)
+		if err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		writeJSON(w, manager.ApplyResult{Tool: name, OK: true, Diff: diff, Pruned: pruned})
+
 	default:
-		http.Error(w, "method not allowed", 405)
+		writeError(w, i18n.T("unknown action"), 400)
 	}
 }
 
-// WebSocket handler
-func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := s.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Printf("WS upgrade error: %v", err)
+// POST /api/apply-all - apply the catalog to every detected CLI tool
+func (s *Server) handleApplyAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
 		return
 	}
+	writeJSON(w, s.mgr.ApplyAll())
+}
 
-	s.mu.Lock()
-	s.clients[conn] = true
-	s.mu.Unlock()
+// GET /api/catalog - curated server templates
+func (s *Server) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, catalog.List())
+}
 
-	// Send initial state
-	info := s.mgr.GetAllInfo()
-	msg, _ := json.Marshal(map[string]interface{}{
-		"type":    "initial",
-		"servers": info,
-	})
-	conn.WriteMessage(websocket.TextMessage, msg)
+// POST /api/catalog/{id}/add - add a server from a curated template
+func (s *Server) handleCatalogAdd(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/catalog/")
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[1] != "add" {
+		writeError(w, i18n.T("unknown action"), 400)
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	tpl, ok := catalog.Find(parts[0])
+	if !ok {
+		writeError(w, i18n.T("not found"), 404)
+		return
+	}
 
-	// Read loop (keep alive)
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
+	var body struct {
+		Name   string            `json:"name"`
+		Params map[string]string `json:"params"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, err.Error(), 400)
+			return
 		}
 	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		name = tpl.ID
+	}
+	if _, exists := s.store.GetServer(name); exists {
+		writeError(w, i18n.T("server %q already exists", name), 409)
+		return
+	}
 
-	s.mu.Lock()
-	delete(s.clients, conn)
-	s.mu.Unlock()
-	conn.Close()
-}
-
-func (s *Server) broadcast(data interface{}) {
-	msg, err := json.Marshal(data)
+	srv, err := tpl.Render(body.Params)
 	if err != nil {
+		writeError(w, err.Error(), 400)
 		return
 	}
+	if err := s.store.AddServer(name, srv); err != nil {
+		writeError(w, err.Error(), storeErrorStatus(err))
+		return
+	}
+	go func() {
+		if s.store.GetPrefetchSettings().Enabled {
+			s.mgr.Prefetch(name)
+		}
+		s.mgr.Check(name)
+	}()
+	writeJSON(w, map[string]string{"status": "ok", "name": name})
+}
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	for conn := range s.clients {
-		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			conn.Close()
-			go func(c *websocket.Conn) {
-				s.mu.Lock()
-				delete(s.clients, c)
-				s.mu.Unlock()
-			}(conn)
+// GET/PUT /api/settings - {"healthCheckInterval": seconds, "locale": "en"}
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, map[string]any{
+			"healthCheckInterval": s.store.GetHealthCheckInterval(),
+			"locale":              s.store.GetLocale(),
+			"locales":             i18n.Locales(),
+		})
+	case "PUT":
+		// Omitted fields are left unchanged
+		var body struct {
+			HealthCheckInterval *int    `json:"healthCheckInterval"`
+			Locale              *string `json:"locale"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		if body.Locale != nil && !i18n.Known(*body.Locale) {
+			writeError(w, i18n.T("unknown locale %q", *body.Locale), 400)
+			return
+		}
+		if body.HealthCheckInterval != nil {
+			if err := s.store.SetHealthCheckInterval(*body.HealthCheckInterval); err != nil {
+				writeError(w, err.Error(), 500)
+				return
+			}
+			s.mgr.SetHealthInterval(*body.HealthCheckInterval)
 		}
+		if body.Locale != nil {
+			if err := s.store.SetLocale(*body.Locale); err != nil {
+				writeError(w, err.Error(), 500)
+				return
+			}
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+func (s *Server) handleHealthStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, s.mgr.HealthStatus())
+}
+
+// storeErrorStatus maps a failed config write to 400 when the config itself
+// was rejected, 500 otherwise.
+func storeErrorStatus(err error) int {
+	var perr *config.ParamError
+	if errors.As(err, &perr) {
+		return 400
 	}
+	return 500
 }
 
 func writeJSON(w http.ResponseWriter, v interface{}) {
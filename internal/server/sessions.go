@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const sessionGCInterval = time.Minute
+
+func (s *Server) sessionsPath() string {
+	return filepath.Join(s.store.Dir(), "sessions.json")
+}
+
+type sessionKey struct{}
+
+// withSession records the proxy session a call belongs to.
+func withSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+// sessionFrom is the proxy session of a call, "" over stdio and for the
+// manager's own calls.
+func sessionFrom(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// touchSession reports whether the proxy session exists on the endpoint and
+// belongs to the client's access token, and marks it as used.
+func (s *Server) touchSession(sessionID, endpoint, client string) bool {
+	s.mcpMu.Lock()
+	defer s.mcpMu.Unlock()
+	ss, ok := s.mcpState[sessionID]
+	if !ok || ss.Endpoint != endpoint || ss.Token != client {
+		return false
+	}
+	ss.LastSeen = time.Now()
+	return true
+}
+
+// expired reports whether a session has been idle longer than the TTL.
+func (ss *mcpSession) expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(ss.LastSeen) > ttl
+}
+
+func (s *Server) sessionTTL() time.Duration {
+	return time.Duration(s.store.GetProxySettings().SessionTTLSec) * time.Second
+}
+
+// expireSessions drops sessions idle longer than the configured TTL.
+func (s *Server) expireSessions() {
+	ttl := s.sessionTTL()
+	if ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	var expired []string
+	s.mcpMu.Lock()
+	for id, ss := range s.mcpState {
+		if ss.expired(ttl, now) {
+			delete(s.mcpState, id)
+			expired = append(expired, id)
+		}
+	}
+	s.mcpMu.Unlock()
+	if len(expired) == 0 {
+		return
+	}
+	for _, id := range expired {
+		s.limiter.forget(id)
+	}
+	slog.Debug("expired idle proxy sessions", "count", len(expired))
+}
+
+// StartSessionGC expires idle proxy sessions and, with persistence enabled,
+// saves the rest so their last use survives a crash.
+func (s *Server) StartSessionGC() {
+	for {
+		time.Sleep(sessionGCInterval)
+		s.expireSessions()
+		s.SaveSessions()
+	}
+}
+
+// SaveSessions writes the proxy sessions to disk when persistence is enabled.
+func (s *Server) SaveSessions() {
+	if !s.store.GetProxySettings().PersistSessions {
+		return
+	}
+	s.sessionsFileMu.Lock()
+	defer s.sessionsFileMu.Unlock()
+
+	s.mcpMu.RLock()
+	data, err := json.Marshal(s.mcpState)
+	s.mcpMu.RUnlock()
+	if err != nil {
+		slog.Warn("failed to encode proxy sessions", "err", err)
+		return
+	}
+	path := s.sessionsPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		slog.Warn("failed to save proxy sessions", "path", path, "err", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Warn("failed to save proxy sessions", "path", path, "err", err)
+	}
+}
+
+// loadSessions restores sessions saved by a previous run, skipping those
+// that expired in the meantime.
+func (s *Server) loadSessions() {
+	if !s.store.GetProxySettings().PersistSessions {
+		return
+	}
+	data, err := os.ReadFile(s.sessionsPath())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("failed to read proxy sessions", "err", err)
+		}
+		return
+	}
+	var saved map[string]*mcpSession
+	if err := json.Unmarshal(data, &saved); err != nil {
+		slog.Warn("ignoring unreadable proxy sessions file", "path", s.sessionsPath(), "err", err)
+		return
+	}
+	ttl := s.sessionTTL()
+	now := time.Now()
+	s.mcpMu.Lock()
+	for id, ss := range saved {
+		if ss == nil || ss.expired(ttl, now) {
+			continue
+		}
+		s.mcpState[id] = ss
+	}
+	restored := len(s.mcpState)
+	s.mcpMu.Unlock()
+	if restored > 0 {
+		slog.Info("restored proxy sessions", "count", restored)
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+// stdioProc is a spawned stdio MCP server the proxy talks JSON-RPC to.
+type stdioProc struct {
+	serverName string
+	srv        *config.MCPServer
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	stdout     *bufio.Reader
+	tail       *manager.StderrTail
+	stderrDone chan struct{}
+	nextID     int
+}
+
+// spawnStdio starts the server process. It is not tied to a call context, so
+// a warm process outlives the call that spawned it; callers kill it with close.
+func (s *Server) spawnStdio(serverName string, srv *config.MCPServer) (*stdioProc, error) {
+	command := strings.TrimSpace(srv.Command)
+	if command == "" {
+		return nil, fmt.Errorf("missing command")
+	}
+	if err := manager.VerifyIntegrity(srv); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(command, srv.Args...)
+	if procEnv := srv.ProcessEnv(); len(procEnv) > 0 {
+		env := cmd.Environ()
+		for k, v := range procEnv {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+		cmd.Env = env
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &stdioProc{
+		serverName: serverName,
+		srv:        srv,
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     bufio.NewReader(stdoutPipe),
+		tail:       &manager.StderrTail{},
+		stderrDone: make(chan struct{}),
+	}
+	go func() {
+		defer close(p.stderrDone)
+		sc := bufio.NewScanner(stderrPipe)
+		sc.Buffer(make([]byte, 64*1024), 64*1024)
+		for sc.Scan() {
+			if s.mgr != nil && !s.mgr.LogStderr(serverName, sc.Text()) {
+				continue
+			}
+			p.tail.Add(sc.Text())
+		}
+		io.Copy(io.Discard, stderrPipe)
+	}()
+	return p, nil
+}
+
+// exited reports whether the process has gone away, judged by its stderr
+// reaching EOF.
+func (p *stdioProc) exited() bool {
+	select {
+	case <-p.stderrDone:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *stdioProc) close() {
+	_ = p.cmd.Process.Kill()
+	_ = p.cmd.Wait()
+}
+
+// stdioFailed reports a crash if the server died while we talked to it.
+func (s *Server) stdioFailed(p *stdioProc, err error) error {
+	kill := func() { _ = p.cmd.Process.Kill() }
+	if report := manager.ReapExited("proxy", p.srv, p.cmd, kill, p.tail, p.stderrDone); report != nil {
+		s.reportCrash(p.serverName, report)
+	}
+	return err
+}
+
+// roundTrip sends one request and waits for its response, relaying
+// notifications to the call stream in ctx. The process is killed if ctx ends
+// first.
+func (s *Server) roundTrip(ctx context.Context, p *stdioProc, method string, params any) (*rpcResp, error) {
+	stop := context.AfterFunc(ctx, func() { _ = p.cmd.Process.Kill() })
+	defer stop()
+
+	p.nextID++
+	b, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      p.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	captured(ctx, "send", b)
+	if _, err := p.stdin.Write(append(b, '\n')); err != nil {
+		return nil, s.stdioFailed(p, err)
+	}
+	for {
+		line, err := p.stdout.ReadString('\n')
+		if err != nil {
+			return nil, s.stdioFailed(p, err)
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			captured(ctx, "recv", []byte(line))
+		}
+		if line == "" || relayNotification(ctx, []byte(line)) {
+			continue
+		}
+		var resp rpcResp
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			return nil, s.stdioFailed(p, err)
+		}
+		return &resp, nil
+	}
+}
+
+// initializeStdio performs the MCP handshake.
+func (s *Server) initializeStdio(ctx context.Context, p *stdioProc) error {
+	resp, err := s.roundTrip(ctx, p, "initialize", map[string]any{
+		"protocolVersion": proxyProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    "mcp-catalog-proxy",
+			"version": "1.0.0",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("initialize: %s", resp.Error.Message)
+	}
+	b, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	captured(ctx, "send", b)
+	_, _ = p.stdin.Write(append(b, '\n'))
+	return nil
+}
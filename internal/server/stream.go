@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// callStream receives notifications an upstream server sends while a tool
+// call is in flight, so they can be relayed to the client as they arrive.
+type callStream struct {
+	// progressToken is the client's _meta.progressToken, passed upstream
+	progressToken json.RawMessage
+	notify        func(msg json.RawMessage)
+}
+
+type callStreamKey struct{}
+
+func withCallStream(ctx context.Context, cs *callStream) context.Context {
+	return context.WithValue(ctx, callStreamKey{}, cs)
+}
+
+func callStreamFrom(ctx context.Context) *callStream {
+	cs, _ := ctx.Value(callStreamKey{}).(*callStream)
+	return cs
+}
+
+// relayNotification passes an upstream message on to the stream if it is a
+// notification the client can use mid-call. It reports whether msg was a
+// notification or a server request, i.e. not the response being waited for.
+func relayNotification(ctx context.Context, msg []byte) bool {
+	var head struct {
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(msg, &head) != nil || head.Method == "" {
+		return false
+	}
+	switch head.Method {
+	case "notifications/progress", "notifications/message":
+		if cs := callStreamFrom(ctx); cs != nil {
+			cs.notify(json.RawMessage(msg))
+		}
+	}
+	return true
+}
+
+// acceptsEventStream reports whether the client allows an SSE response to a
+// POST, as in the streamable HTTP transport.
+func acceptsEventStream(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+// sseResponse writes JSON-RPC messages of one POST as Server-Sent Events.
+type sseResponse struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// startSSEResponse sends the event-stream headers right away, so the client
+// sees the call is accepted before the tool finishes.
+func startSSEResponse(w http.ResponseWriter, sessionID string) (*sseResponse, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if sessionID != "" {
+		w.Header().Set("MCP-Session-Id", sessionID)
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseResponse{w: w, flusher: flusher}, true
+}
+
+func (s *sseResponse) send(msg json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", msg)
+	s.flusher.Flush()
+}
+
+func (s *sseResponse) sendResponse(resp rpcResp) {
+	msg, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	s.send(msg)
+}
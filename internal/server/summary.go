@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+type catalogSummary struct {
+	manager.CatalogSummary
+	Sessions   int   `json:"sessions"`
+	CallsToday int64 `json:"callsToday"`
+}
+
+// activeSessions counts proxy sessions that have not yet expired.
+func (s *Server) activeSessions() int {
+	ttl := s.sessionTTL()
+	now := time.Now()
+	s.mcpMu.RLock()
+	defer s.mcpMu.RUnlock()
+	n := 0
+	for _, ss := range s.mcpState {
+		if !ss.expired(ttl, now) {
+			n++
+		}
+	}
+	return n
+}
+
+// GET /api/summary - catalog counts for status bars and `mcp-manager status`
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	writeJSON(w, catalogSummary{
+		CatalogSummary: s.mgr.Summary(),
+		Sessions:       s.activeSessions(),
+		CallsToday:     s.stats.todayCalls(),
+	})
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+// accessTokenPrefix marks proxy secrets so they are recognisable in logs and
+// secret scanners.
+const accessTokenPrefix = "mcpk_"
+
+func hashAccessToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAccessSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return accessTokenPrefix + hex.EncodeToString(b), nil
+}
+
+type accessTokenKey struct{}
+
+// withAccessToken records the name of the token a proxy request presented.
+func withAccessToken(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, name)
+}
+
+// accessTokenFrom is the token name of a proxy request, "" when the proxy is
+// open or the request came over stdio.
+func accessTokenFrom(ctx context.Context) string {
+	name, _ := ctx.Value(accessTokenKey{}).(string)
+	return name
+}
+
+// authorizeMCP checks the bearer token of a proxy request once any access
+// token is configured, and returns the request tagged with the token name.
+func (s *Server) authorizeMCP(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if !s.store.HasAccessTokens() {
+		return r, true
+	}
+	scheme, secret, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(secret) == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-catalog"`)
+		writeError(w, i18n.T("access token required"), http.StatusUnauthorized)
+		return nil, false
+	}
+	name, tok, ok := s.store.MatchAccessToken(hashAccessToken(strings.TrimSpace(secret)))
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-catalog", error="invalid_token"`)
+		writeError(w, i18n.T("invalid access token"), http.StatusUnauthorized)
+		return nil, false
+	}
+	if tok.Endpoint != "" && mcpEndpoint(r) != tok.Endpoint {
+		writeError(w, i18n.T("token %q is limited to /mcp/%s", name, tok.Endpoint), http.StatusForbidden)
+		return nil, false
+	}
+	return r.WithContext(withAccessToken(r.Context(), name)), true
+}
+
+// sessionAccessToken is the name of the token a proxy session was opened with.
+func (s *Server) sessionAccessToken(sessionID string) string {
+	s.mcpMu.RLock()
+	defer s.mcpMu.RUnlock()
+	if ss, ok := s.mcpState[sessionID]; ok {
+		return ss.Token
+	}
+	return ""
+}
+
+// dropTokenSessions closes every proxy session opened with a revoked token.
+func (s *Server) dropTokenSessions(name string) {
+	var dropped []string
+	s.mcpMu.Lock()
+	for id, ss := range s.mcpState {
+		if ss.Token == name {
+			delete(s.mcpState, id)
+			dropped = append(dropped, id)
+		}
+	}
+	s.mcpMu.Unlock()
+	for _, id := range dropped {
+		s.limiter.forget(id)
+	}
+	s.limiter.forgetClient(name)
+	s.SaveSessions()
+}
+
+type accessTokenInfo struct {
+	Name      string            `json:"name"`
+	Endpoint  string            `json:"endpoint,omitempty"`
+	RateLimit *config.RateLimit `json:"rateLimit,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Sessions  int               `json:"sessions"`
+}
+
+// GET  /api/tokens - list proxy access tokens (without secrets)
+// POST /api/tokens - issue a token; the secret is only returned here
+func (s *Server) handleAccessTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		sessions := make(map[string]int)
+		s.mcpMu.RLock()
+		for _, ss := range s.mcpState {
+			sessions[ss.Token]++
+		}
+		s.mcpMu.RUnlock()
+		tokens := s.store.GetAccessTokens()
+		list := make([]accessTokenInfo, 0, len(tokens))
+		for name, tok := range tokens {
+			list = append(list, accessTokenInfo{
+				Name:      name,
+				Endpoint:  tok.Endpoint,
+				RateLimit: tok.RateLimit,
+				CreatedAt: tok.CreatedAt,
+				Sessions:  sessions[name],
+			})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		writeJSON(w, list)
+	case "POST":
+		var req struct {
+			Name      string            `json:"name"`
+			Endpoint  string            `json:"endpoint"`
+			RateLimit *config.RateLimit `json:"rateLimit"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, err.Error(), 400)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || strings.Contains(req.Name, "/") {
+			writeError(w, i18n.T("token name is required and must not contain '/'"), 400)
+			return
+		}
+		if _, exists := s.store.GetAccessTokens()[req.Name]; exists {
+			writeError(w, i18n.T("token %q already exists", req.Name), http.StatusConflict)
+			return
+		}
+		if req.Endpoint != "" {
+			if _, ok := s.store.GetEndpoint(req.Endpoint); !ok {
+				writeError(w, i18n.T("unknown MCP endpoint %q", req.Endpoint), 400)
+				return
+			}
+		}
+		secret, err := newAccessSecret()
+		if err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		tok := &config.AccessToken{
+			Hash:      hashAccessToken(secret),
+			Endpoint:  req.Endpoint,
+			RateLimit: req.RateLimit,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := s.store.SetAccessToken(req.Name, tok); err != nil {
+			writeError(w, err.Error(), 500)
+			return
+		}
+		writeJSON(w, map[string]string{"name": req.Name, "token": secret})
+	default:
+		writeError(w, i18n.T("method not allowed"), 405)
+	}
+}
+
+// DELETE /api/tokens/{name} - revoke a token and close its sessions
+func (s *Server) handleAccessToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "DELETE" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/api/tokens/")
+	if err := s.store.RemoveAccessToken(name); err != nil {
+		writeError(w, err.Error(), 404)
+		return
+	}
+	s.dropTokenSessions(name)
+	w.WriteHeader(http.StatusNoContent)
+}
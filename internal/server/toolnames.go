@@ -0,0 +1,140 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// toolEntry is an upstream tool before it gets its exposed name
+type toolEntry struct {
+	server string
+	tool   proxiedTool
+}
+
+// toolNamer builds exposed tool and prompt names from the proxy settings
+type toolNamer struct {
+	sep    string
+	maxLen int
+}
+
+func (s *Server) toolNamer() toolNamer {
+	ps := s.store.GetProxySettings()
+	return toolNamer{sep: ps.ToolSeparator, maxLen: ps.MaxToolNameLength}
+}
+
+// join is the plain server-prefixed name, used for prompts and the built-in tools.
+func (n toolNamer) join(server, name string) string {
+	return server + n.sep + name
+}
+
+// split reverses join for clients that call names they did not list.
+func (n toolNamer) split(name string) (server, rest string, ok bool) {
+	return strings.Cut(name, n.sep)
+}
+
+// exposedToolNames picks the name each tool is advertised under. Names are
+// server + separator + tool (or just the tool in prefix-free mode when no
+// other server has a tool of that name), restricted to [A-Za-z0-9_-] and the
+// configured length; a per-tool alias from the server's toolAliases wins.
+// Names that are too long or collide get a short hash suffix so every tool
+// stays reachable and the name is stable across sessions.
+func (s *Server) exposedToolNames(servers map[string]*config.MCPServer, entries []toolEntry) []string {
+	n := s.toolNamer()
+	prefixFree := s.store.GetProxySettings().ToolPrefix == "on-conflict"
+
+	providers := make(map[string]int)
+	for _, e := range entries {
+		providers[sanitizeToolName(e.tool.Name)]++
+	}
+
+	names := make([]string, len(entries))
+	aliased := make([]bool, len(entries))
+	for i, e := range entries {
+		if alias := servers[e.server].ToolAliases[e.tool.Name]; alias != "" {
+			names[i], aliased[i] = alias, true
+			continue
+		}
+		if bare := sanitizeToolName(e.tool.Name); prefixFree && providers[bare] == 1 && len(bare) <= n.maxLen {
+			names[i] = bare
+			continue
+		}
+		names[i] = n.prefixed(e.server, e.tool.Name)
+	}
+
+	byName := make(map[string][]int)
+	for i, name := range names {
+		byName[name] = append(byName[name], i)
+	}
+	for name, idx := range byName {
+		if len(idx) < 2 {
+			continue
+		}
+		// Explicit aliases keep their name, then the highest-priority server
+		sort.Slice(idx, func(a, b int) bool {
+			ia, ib := idx[a], idx[b]
+			if aliased[ia] != aliased[ib] {
+				return aliased[ia]
+			}
+			return config.ServerBefore(servers, entries[ia].server, entries[ib].server)
+		})
+		for _, i := range idx[1:] {
+			names[i] = n.hashed(entries[i].server, entries[i].tool.Name)
+			slog.Warn("tool name collision", "name", name, "server", entries[i].server, "tool", entries[i].tool.Name, "renamed", names[i])
+		}
+	}
+	return names
+}
+
+func (n toolNamer) base(server, tool string) string {
+	return sanitizeToolName(strings.ReplaceAll(server, n.sep, "_")) + n.sep + sanitizeToolName(tool)
+}
+
+func (n toolNamer) prefixed(server, tool string) string {
+	name := n.base(server, tool)
+	if len(name) > n.maxLen {
+		return n.hashed(server, tool)
+	}
+	return name
+}
+
+// hashed shortens the prefixed name and appends a hash of the original
+// server and tool names.
+func (n toolNamer) hashed(server, tool string) string {
+	sum := sha256.Sum256([]byte(server + "\x00" + tool))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	base := n.base(server, tool)
+	if len(base)+len(suffix) > n.maxLen {
+		base = base[:n.maxLen-len(suffix)]
+	}
+	return base + suffix
+}
+
+// sanitizeToolName replaces characters many clients reject in tool names.
+func sanitizeToolName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// toolAliasRoute finds a tool by its configured alias, for clients that call
+// tools without listing them in this session.
+func (s *Server) toolAliasRoute(name string) (toolRoute, bool) {
+	servers := s.store.Get().MCPServers
+	for _, serverName := range config.OrderedNames(servers) {
+		for tool, alias := range servers[serverName].ToolAliases {
+			if alias == name {
+				return toolRoute{ServerName: serverName, ToolName: tool}, true
+			}
+		}
+	}
+	return toolRoute{}, false
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+)
+
+// maxTrayWait caps how long a long-poll on /api/tray may block.
+const maxTrayWait = 5 * time.Minute
+
+// fleetWatch tracks the fleet state and wakes tray clients when it moves,
+// e.g. from healthy to degraded. Count changes within a state do not.
+type fleetWatch struct {
+	mu      sync.Mutex
+	fleet   manager.Fleet
+	changed chan struct{}
+}
+
+func newFleetWatch() *fleetWatch {
+	return &fleetWatch{changed: make(chan struct{})}
+}
+
+// refreshFleet recomputes the fleet state and signals waiters on a transition.
+func (s *Server) refreshFleet() {
+	f := s.mgr.Fleet()
+	fw := s.fleet
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	moved := f.State != fw.fleet.State
+	fw.fleet = f
+	if moved {
+		close(fw.changed)
+		fw.changed = make(chan struct{})
+	}
+}
+
+// currentFleet returns the fleet and a channel closed on its next transition.
+func (s *Server) currentFleet() (manager.Fleet, <-chan struct{}) {
+	fw := s.fleet
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.fleet, fw.changed
+}
+
+// SetDashboardURL sets the UI address reported to tray apps and opened by
+// POST /api/tray/open. It comes from the listen address, never from a
+// request's Host header, so callers cannot choose what the host opens.
+func (s *Server) SetDashboardURL(url string) {
+	s.dashboard = url
+}
+
+type trayStatus struct {
+	manager.Fleet
+	Dashboard string `json:"dashboard"`
+}
+
+// GET /api/tray?since=STATE&wait=SEC - fleet state for tray apps; with since,
+// blocks up to wait seconds until the state differs from it
+func (s *Server) handleTray(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	s.refreshFleet()
+	fleet, changed := s.currentFleet()
+	if since := manager.FleetState(r.URL.Query().Get("since")); since != "" && since == fleet.State {
+		wait := 30 * time.Second
+		if v, err := strconv.Atoi(r.URL.Query().Get("wait")); err == nil && v >= 0 {
+			wait = min(time.Duration(v)*time.Second, maxTrayWait)
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+		fleet, _ = s.currentFleet()
+	}
+	writeJSON(w, trayStatus{Fleet: fleet, Dashboard: s.dashboard})
+}
+
+// GET /api/tray/events - SSE stream with one "fleet" event on connect and one
+// per fleet state transition
+func (s *Server) handleTrayEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, i18n.T("streaming unsupported"), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+
+	s.refreshFleet()
+	fleet, changed := s.currentFleet()
+	send := func(f manager.Fleet) {
+		data, _ := json.Marshal(trayStatus{Fleet: f, Dashboard: s.dashboard})
+		fmt.Fprintf(w, "event: fleet\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+	send(fleet)
+
+	// Config edits (a server disabled or removed) do not emit manager
+	// events, so the state is also recomputed on every keepalive.
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-changed:
+			fleet, changed = s.currentFleet()
+			send(fleet)
+		case <-keepAlive.C:
+			s.refreshFleet()
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// POST /api/tray/open - open the dashboard in the default browser of the
+// machine running the manager
+func (s *Server) handleTrayOpen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, i18n.T("method not allowed"), 405)
+		return
+	}
+	url := s.dashboard
+	if url == "" {
+		writeError(w, i18n.T("no TCP listener serves the dashboard"), 409)
+		return
+	}
+	if err := openBrowser(url); err != nil {
+		writeError(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok", "url": url})
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open browser: %w", err)
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+const warmStandbyTick = 10 * time.Second
+
+// warmPool holds one initialized, idle process per prewarmed stdio server.
+type warmPool struct {
+	mu       sync.Mutex
+	procs    map[string]*warmProc
+	starting map[string]bool
+}
+
+type warmProc struct {
+	proc *stdioProc
+	// launch identifies the command line the process was started with
+	launch string
+}
+
+func newWarmPool() *warmPool {
+	return &warmPool{
+		procs:    make(map[string]*warmProc),
+		starting: make(map[string]bool),
+	}
+}
+
+// launchKey identifies what a stdio process was started as; a warm process
+// is only reused while it still matches the server config.
+func launchKey(srv *config.MCPServer) string {
+	b, _ := json.Marshal(struct {
+		Command string            `json:"command"`
+		Args    []string          `json:"args"`
+		Env     map[string]string `json:"env"`
+	}{srv.Command, srv.Args, srv.ProcessEnv()})
+	return string(b)
+}
+
+func isStdioServer(srv *config.MCPServer) bool {
+	if strings.EqualFold(strings.TrimSpace(srv.Type), "streamableHttp") {
+		return false
+	}
+	return strings.TrimSpace(srv.Command) != ""
+}
+
+// wantsWarm reports whether a standby process should be kept for srv.
+func wantsWarm(srv *config.MCPServer) bool {
+	return srv != nil && srv.Enabled && srv.Prewarm && isStdioServer(srv)
+}
+
+// takeWarm hands out the standby process of a server, if one is ready, and
+// starts its replacement.
+func (s *Server) takeWarm(serverName string, srv *config.MCPServer) *stdioProc {
+	if !wantsWarm(srv) {
+		return nil
+	}
+	w := s.warm
+	w.mu.Lock()
+	wp, ok := w.procs[serverName]
+	delete(w.procs, serverName)
+	w.mu.Unlock()
+	go s.refillWarm(serverName, srv)
+	if !ok {
+		return nil
+	}
+	if wp.launch != launchKey(srv) || wp.proc.exited() {
+		wp.proc.close()
+		return nil
+	}
+	return wp.proc
+}
+
+// refillWarm spawns and initializes a standby process unless one is ready or
+// already starting.
+func (s *Server) refillWarm(serverName string, srv *config.MCPServer) {
+	w := s.warm
+	w.mu.Lock()
+	if _, ok := w.procs[serverName]; ok || w.starting[serverName] {
+		w.mu.Unlock()
+		return
+	}
+	w.starting[serverName] = true
+	w.mu.Unlock()
+
+	proc, err := s.spawnStdio(serverName, srv)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), proxyTimeout)
+		err = s.initializeStdio(ctx, proc)
+		cancel()
+		if err != nil {
+			proc.close()
+		}
+	}
+
+	w.mu.Lock()
+	delete(w.starting, serverName)
+	if err == nil {
+		w.procs[serverName] = &warmProc{proc: proc, launch: launchKey(srv)}
+	}
+	w.mu.Unlock()
+	if err != nil {
+		slog.Warn("failed to start warm standby process", "server", serverName, "err", err)
+	}
+}
+
+// reconcileWarm starts missing standby processes and stops those whose server
+// was removed, disabled, edited or has exited.
+func (s *Server) reconcileWarm() {
+	cfg := s.store.Get()
+	w := s.warm
+	var stale []*stdioProc
+	w.mu.Lock()
+	for name, wp := range w.procs {
+		srv := cfg.MCPServers[name]
+		if !wantsWarm(srv) || wp.launch != launchKey(srv) || wp.proc.exited() {
+			stale = append(stale, wp.proc)
+			delete(w.procs, name)
+		}
+	}
+	w.mu.Unlock()
+	for _, proc := range stale {
+		proc.close()
+	}
+	for name, srv := range cfg.MCPServers {
+		if wantsWarm(srv) {
+			go s.refillWarm(name, srv)
+		}
+	}
+}
+
+// StartWarmStandby keeps standby processes of prewarmed servers ready.
+func (s *Server) StartWarmStandby() {
+	for {
+		s.reconcileWarm()
+		time.Sleep(warmStandbyTick)
+	}
+}
+
+// StopWarmStandby kills the idle standby processes.
+func (s *Server) StopWarmStandby() {
+	w := s.warm
+	w.mu.Lock()
+	procs := w.procs
+	w.procs = make(map[string]*warmProc)
+	w.mu.Unlock()
+	for _, wp := range procs {
+		wp.proc.close()
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/naukograd-software/mcp-catalog/internal/i18n"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+// WebSocket handler
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Warn("WS upgrade error", "err", err)
+		return
+	}
+	client := newEventClient(conn.RemoteAddr().String())
+	client.callsDenied = !s.mayWatchCalls(r)
+	client.hideEnv = !s.mayReadEnv(r)
+
+	// Send initial state
+	info := viewInfos(s.mgr.GetAllInfo(), !client.hideEnv)
+	msg, _ := json.Marshal(map[string]interface{}{
+		"type":    "initial",
+		"servers": info,
+	})
+	client.send <- msg
+
+	s.mu.Lock()
+	s.clients[client] = true
+	s.mu.Unlock()
+
+	go s.wsWritePump(conn, client)
+	s.wsReadPump(conn, client)
+}
+
+// wsReadPump handles client commands and detects dead peers via pong deadlines.
+func (s *Server) wsReadPump(conn *websocket.Conn, c *eventClient) {
+	defer s.evict(c)
+	conn.SetReadLimit(64 * 1024)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.handleWSCommand(c, data)
+	}
+}
+
+// wsCommand is a message from the dashboard:
+//
+//	{"type": "subscribe_calls", "server": "name"}   // "" or "*" for all servers
+//	{"type": "unsubscribe_calls", "server": "name"}
+type wsCommand struct {
+	Type   string `json:"type"`
+	Server string `json:"server,omitempty"`
+}
+
+func (s *Server) handleWSCommand(c *eventClient, data []byte) {
+	var cmd wsCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return
+	}
+	switch cmd.Type {
+	case "subscribe_calls", "unsubscribe_calls":
+		reply := map[string]interface{}{"type": cmd.Type, "server": cmd.Server, "ok": true}
+		if cmd.Type == "subscribe_calls" && c.callsDenied {
+			reply["ok"], reply["error"] = false, i18n.T("the %s role is required", callDataRole)
+		} else {
+			c.watchCalls(cmd.Server, cmd.Type == "subscribe_calls")
+		}
+		ack, _ := json.Marshal(reply)
+		s.mu.Lock()
+		if s.clients[c] {
+			s.deliverLocked(c, ack)
+		}
+		s.mu.Unlock()
+	default:
+		slog.Debug("unknown WS command", "type", cmd.Type, "remote", c.remote)
+	}
+}
+
+// wsWritePump delivers queued messages and pings the client.
+func (s *Server) wsWritePump(conn *websocket.Conn, c *eventClient) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+		s.evict(c)
+	}()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// configStorage keeps config profiles as one row per server and one per
+// top-level setting.
+type configStorage struct {
+	d  *DB
+	mu sync.Mutex
+	// saved holds the rows last read or written per profile, so Save only
+	// touches the ones that changed
+	saved map[string]*profileRows
+}
+
+type profileRows struct {
+	servers  map[string]string
+	settings map[string]string
+}
+
+// ConfigStorage returns the config.Storage kept in the database. When the
+// database holds no profile yet, the JSON config at jsonPath and the
+// profiles next to it are imported; the files are left in place.
+func (d *DB) ConfigStorage(jsonPath string) (config.Storage, error) {
+	cs := &configStorage{d: d, saved: make(map[string]*profileRows)}
+	existing, err := cs.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return cs, nil
+	}
+	files := config.FileStorage(jsonPath)
+	profiles, err := files.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	for name := range profiles {
+		data, err := files.Load(name)
+		if err != nil || data == nil {
+			continue
+		}
+		cfg, err := config.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("import %s: %w", files.Location(name), err)
+		}
+		if err := cs.Save(name, cfg); err != nil {
+			return nil, err
+		}
+		slog.Info("imported config into database", "from", files.Location(name), "db", d.path)
+	}
+	return cs, nil
+}
+
+func (cs *configStorage) Location(profile string) string {
+	return fmt.Sprintf("%s (profile %s)", cs.d.path, profile)
+}
+
+func (cs *configStorage) Load(profile string) ([]byte, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	var modified int64
+	err := cs.d.db.QueryRow(`SELECT modified FROM profiles WHERE name = ?`, profile).Scan(&modified)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cs.readRows(profile)
+	if err != nil {
+		return nil, err
+	}
+	cs.saved[profile] = rows
+
+	doc := make(map[string]json.RawMessage, len(rows.settings)+1)
+	for key, value := range rows.settings {
+		doc[key] = json.RawMessage(value)
+	}
+	servers := make(map[string]json.RawMessage, len(rows.servers))
+	for name, value := range rows.servers {
+		servers[name] = json.RawMessage(value)
+	}
+	doc["mcpServers"], err = json.Marshal(servers)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func (cs *configStorage) readRows(profile string) (*profileRows, error) {
+	rows := &profileRows{servers: make(map[string]string), settings: make(map[string]string)}
+	for _, q := range []struct {
+		query string
+		into  map[string]string
+	}{
+		{`SELECT name, config FROM servers WHERE profile = ?`, rows.servers},
+		{`SELECT key, value FROM settings WHERE profile = ?`, rows.settings},
+	} {
+		res, err := cs.d.db.Query(q.query, profile)
+		if err != nil {
+			return nil, err
+		}
+		for res.Next() {
+			var key, value string
+			if err := res.Scan(&key, &value); err != nil {
+				res.Close()
+				return nil, err
+			}
+			q.into[key] = value
+		}
+		res.Close()
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// splitConfig turns a config into the rows it is stored as.
+func splitConfig(cfg *config.Config) (*profileRows, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var servers map[string]json.RawMessage
+	if raw, ok := doc["mcpServers"]; ok {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return nil, err
+		}
+		delete(doc, "mcpServers")
+	}
+	rows := &profileRows{servers: make(map[string]string), settings: make(map[string]string)}
+	for name, raw := range servers {
+		rows.servers[name] = string(raw)
+	}
+	for key, raw := range doc {
+		rows.settings[key] = string(raw)
+	}
+	return rows, nil
+}
+
+func (cs *configStorage) Save(profile string, cfg *config.Config) error {
+	next, err := splitConfig(cfg)
+	if err != nil {
+		return err
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	prev, ok := cs.saved[profile]
+	if !ok {
+		if prev, err = cs.readRows(profile); err != nil {
+			return err
+		}
+	}
+
+	tx, err := cs.d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, t := range []struct {
+		upsert, remove string
+		prev, next     map[string]string
+	}{
+		{
+			`INSERT INTO servers (profile, name, config) VALUES (?, ?, ?)
+				ON CONFLICT (profile, name) DO UPDATE SET config = excluded.config`,
+			`DELETE FROM servers WHERE profile = ? AND name = ?`,
+			prev.servers, next.servers,
+		},
+		{
+			`INSERT INTO settings (profile, key, value) VALUES (?, ?, ?)
+				ON CONFLICT (profile, key) DO UPDATE SET value = excluded.value`,
+			`DELETE FROM settings WHERE profile = ? AND key = ?`,
+			prev.settings, next.settings,
+		},
+	} {
+		for key, value := range t.next {
+			if old, ok := t.prev[key]; ok && old == value {
+				continue
+			}
+			if _, err := tx.Exec(t.upsert, profile, key, value); err != nil {
+				return err
+			}
+		}
+		for key := range t.prev {
+			if _, ok := t.next[key]; ok {
+				continue
+			}
+			if _, err := tx.Exec(t.remove, profile, key); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO profiles (name, modified) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET modified = excluded.modified`, profile, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	cs.saved[profile] = next
+	return nil
+}
+
+func (cs *configStorage) Delete(profile string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	tx, err := cs.d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`DELETE FROM profiles WHERE name = ?`, profile)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", config.ErrNoProfile, profile)
+	}
+	for _, q := range []string{
+		`DELETE FROM servers WHERE profile = ?`,
+		`DELETE FROM settings WHERE profile = ?`,
+	} {
+		if _, err := tx.Exec(q, profile); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	delete(cs.saved, profile)
+	return nil
+}
+
+func (cs *configStorage) Profiles() (map[string]time.Time, error) {
+	rows, err := cs.d.db.Query(`SELECT name, modified FROM profiles`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	profiles := make(map[string]time.Time)
+	for rows.Next() {
+		var name string
+		var modified int64
+		if err := rows.Scan(&name, &modified); err != nil {
+			return nil, err
+		}
+		profiles[name] = time.Unix(0, modified)
+	}
+	return profiles, rows.Err()
+}
+
+// Backup keeps the original next to the database as
+// catalog.db.PROFILE.v<N>.bak.
+func (cs *configStorage) Backup(profile string, data []byte, version int) error {
+	return os.WriteFile(fmt.Sprintf("%s.%s.v%d.bak", cs.d.path, profile, version), data, 0600)
+}
@@ -0,0 +1,102 @@
+// Package storage keeps the catalog in a single SQLite database: the config
+// profiles, server logs, check history and the audit trail of tool calls.
+// Changes update the rows that changed instead of rewriting a whole file,
+// and history is queried through indexes.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS profiles (
+	name     TEXT PRIMARY KEY,
+	modified INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS servers (
+	profile TEXT NOT NULL,
+	name    TEXT NOT NULL,
+	config  TEXT NOT NULL,
+	PRIMARY KEY (profile, name)
+);
+CREATE TABLE IF NOT EXISTS settings (
+	profile TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	value   TEXT NOT NULL,
+	PRIMARY KEY (profile, key)
+);
+CREATE TABLE IF NOT EXISTS logs (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	server TEXT NOT NULL,
+	time   INTEGER NOT NULL,
+	entry  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS logs_server_time ON logs (server, time);
+CREATE TABLE IF NOT EXISTS checks (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	server TEXT NOT NULL,
+	time   INTEGER NOT NULL,
+	record TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS checks_server_time ON checks (server, time);
+CREATE TABLE IF NOT EXISTS audit (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	time   INTEGER NOT NULL,
+	client TEXT NOT NULL,
+	server TEXT NOT NULL,
+	tool   TEXT NOT NULL,
+	entry  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_time ON audit (time);
+CREATE INDEX IF NOT EXISTS audit_client_time ON audit (client, time);
+`
+
+// DB is an open catalog database.
+type DB struct {
+	db   *sql.DB
+	path string
+
+	pruneMu sync.Mutex
+	inserts map[string]int
+}
+
+// Open opens the database at path, creating it and its tables if needed.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// Create it 0600 up front: it holds server env with API keys
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	// WAL lets the CLI read while the panel writes; busy_timeout makes
+	// concurrent writers from several processes wait instead of failing
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init %s: %w", path, err)
+	}
+	return &DB{db: db, path: path, inserts: make(map[string]int)}, nil
+}
+
+// Path returns the database file.
+func (d *DB) Path() string {
+	return d.path
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+	"github.com/naukograd-software/mcp-catalog/internal/server"
+)
+
+const (
+	// maxLogRows is how many log entries are kept per server
+	maxLogRows = 20000
+	// checkRetention is how far back check results are kept
+	checkRetention = 7 * 24 * time.Hour
+	// auditRetention is how far back audit entries are kept
+	auditRetention = 90 * 24 * time.Hour
+	// pruneEvery is how many inserts into a table pass between prunes
+	pruneEvery = 500
+)
+
+// prune runs query every pruneEvery calls counted under key.
+func (d *DB) prune(key, query string, args ...any) {
+	d.pruneMu.Lock()
+	d.inserts[key]++
+	due := d.inserts[key]%pruneEvery == 1
+	d.pruneMu.Unlock()
+	if !due {
+		return
+	}
+	if _, err := d.db.Exec(query, args...); err != nil {
+		slog.Warn("prune database", "table", key, "err", err)
+	}
+}
+
+// AppendLog implements manager.LogStore.
+func (d *DB) AppendLog(name string, entry manager.LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := d.db.Exec(`INSERT INTO logs (server, time, entry) VALUES (?, ?, ?)`,
+		name, entry.Time.UnixNano(), string(data)); err != nil {
+		return err
+	}
+	d.prune("logs/"+name, `DELETE FROM logs WHERE server = ? AND id <=
+		(SELECT id FROM logs WHERE server = ? ORDER BY id DESC LIMIT 1 OFFSET ?)`, name, name, maxLogRows)
+	return nil
+}
+
+// ReadLogs implements manager.LogStore.
+func (d *DB) ReadLogs(name string, since time.Time, limit int) ([]manager.LogEntry, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := d.db.Query(`SELECT entry FROM logs WHERE server = ? AND time >= ?
+		ORDER BY id DESC LIMIT ?`, name, since.UnixNano(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]manager.LogEntry, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e manager.LogEntry
+		if json.Unmarshal([]byte(data), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	reverse(entries)
+	return entries, rows.Err()
+}
+
+// RemoveLogs implements manager.LogStore.
+func (d *DB) RemoveLogs(name string) {
+	if _, err := d.db.Exec(`DELETE FROM logs WHERE server = ?`, name); err != nil {
+		slog.Warn("remove server logs", "server", name, "err", err)
+	}
+}
+
+// RenameLogs implements manager.LogStore.
+func (d *DB) RenameLogs(oldName, newName string) error {
+	_, err := d.db.Exec(`UPDATE logs SET server = ? WHERE server = ?`, newName, oldName)
+	return err
+}
+
+// AddCheck implements manager.HistoryStore.
+func (d *DB) AddCheck(name string, rec manager.CheckRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := d.db.Exec(`INSERT INTO checks (server, time, record) VALUES (?, ?, ?)`,
+		name, rec.Time.UnixNano(), string(data)); err != nil {
+		return err
+	}
+	d.prune("checks", `DELETE FROM checks WHERE time < ?`, time.Now().Add(-checkRetention).UnixNano())
+	return nil
+}
+
+// ChecksSince implements manager.HistoryStore.
+func (d *DB) ChecksSince(name string, t time.Time) []manager.CheckRecord {
+	recs := make([]manager.CheckRecord, 0)
+	rows, err := d.db.Query(`SELECT record FROM checks WHERE server = ? AND time >= ?
+		ORDER BY time, id`, name, t.UnixNano())
+	if err != nil {
+		slog.Warn("read check history", "server", name, "err", err)
+		return recs
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data string
+		if rows.Scan(&data) != nil {
+			continue
+		}
+		var rec manager.CheckRecord
+		if json.Unmarshal([]byte(data), &rec) == nil {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+// RemoveChecks implements manager.HistoryStore.
+func (d *DB) RemoveChecks(name string) {
+	if _, err := d.db.Exec(`DELETE FROM checks WHERE server = ?`, name); err != nil {
+		slog.Warn("remove check history", "server", name, "err", err)
+	}
+}
+
+// RenameChecks implements manager.HistoryStore.
+func (d *DB) RenameChecks(oldName, newName string) error {
+	_, err := d.db.Exec(`UPDATE checks SET server = ? WHERE server = ?`, newName, oldName)
+	return err
+}
+
+// AddAudit implements server.AuditLog.
+func (d *DB) AddAudit(e server.AuditEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := d.db.Exec(`INSERT INTO audit (time, client, server, tool, entry) VALUES (?, ?, ?, ?, ?)`,
+		e.Time.UnixNano(), e.Client, e.Server, e.Tool, string(data)); err != nil {
+		return err
+	}
+	d.prune("audit", `DELETE FROM audit WHERE time < ?`, time.Now().Add(-auditRetention).UnixNano())
+	return nil
+}
+
+// Audit implements server.AuditLog.
+func (d *DB) Audit(since time.Time, client string, limit int) ([]server.AuditEntry, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := d.db.Query(`SELECT entry FROM audit WHERE time >= ? AND (? = '' OR client = ?)
+		ORDER BY time DESC, id DESC LIMIT ?`, since.UnixNano(), client, client, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]server.AuditEntry, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e server.AuditEntry
+		if json.Unmarshal([]byte(data), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, rows.Err()
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,89 @@
+package testmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// ServeHTTP serves the streamable HTTP transport: POST carries one JSON-RPC
+// message answered as JSON, initialize opens a session returned in
+// MCP-Session-Id and DELETE closes it. Other requests must name an open
+// session, as strict servers require; ping is answered without one.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimSpace(r.Header.Get("MCP-Session-Id"))
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.sessions, sessionID)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 2*1024*1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req rpcReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "invalid JSON-RPC message", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	switch {
+	case req.Method == "initialize":
+		s.nextID++
+		sessionID = fmt.Sprintf("testmcp-%d", s.nextID)
+		s.sessions[sessionID] = true
+	case sessionID != "" && !s.sessions[sessionID]:
+		s.mu.Unlock()
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	case sessionID == "" && req.Method != "ping":
+		s.mu.Unlock()
+		http.Error(w, "missing MCP-Session-Id", http.StatusBadRequest)
+		return
+	}
+	s.mu.Unlock()
+
+	if sessionID != "" {
+		w.Header().Set("MCP-Session-Id", sessionID)
+	}
+	resp := s.dispatch(r.Context(), req, sessionID)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Sessions returns how many HTTP sessions are open.
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// StartHTTP serves s on a local port; close the returned server when done.
+func (s *Server) StartHTTP() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+// HTTPServer returns an enabled streamable HTTP server entry for url, e.g.
+// the URL of StartHTTP.
+func HTTPServer(url string) *config.MCPServer {
+	return &config.MCPServer{Type: "streamableHttp", URL: url, Enabled: true}
+}
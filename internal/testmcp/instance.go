@@ -0,0 +1,48 @@
+package testmcp
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+	"github.com/naukograd-software/mcp-catalog/internal/server"
+)
+
+// Instance is a manager running in-process: the config store, the manager
+// and the HTTP API and MCP proxy served on a local port. No background loops
+// run; call Manager.Check or the API to check servers.
+type Instance struct {
+	// URL is the base URL of the API (URL+"/api/servers") and proxy (URL+"/mcp")
+	URL     string
+	Store   *config.Store
+	Manager *manager.Manager
+
+	srv  *server.Server
+	http *httptest.Server
+}
+
+// Start runs a manager on a new config in dir, e.g. a test's temporary
+// directory, holding servers.
+func Start(dir string, servers map[string]*config.MCPServer) (*Instance, error) {
+	store := config.NewStore(filepath.Join(dir, "config.json"))
+	if err := store.Load(); err != nil {
+		return nil, err
+	}
+	for name, srv := range servers {
+		if err := store.AddServer(name, srv); err != nil {
+			return nil, err
+		}
+	}
+	mgr := manager.New(store)
+	srv := server.New(store, mgr)
+	ts := httptest.NewServer(srv.Handler())
+	return &Instance{URL: ts.URL, Store: store, Manager: mgr, srv: srv, http: ts}, nil
+}
+
+// Close stops the API and the processes the proxy keeps.
+func (i *Instance) Close() {
+	i.http.Close()
+	i.srv.StopWarmStandby()
+	i.Manager.StopHealthLoop()
+}
@@ -0,0 +1,95 @@
+package testmcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// ServeStdio answers newline-delimited JSON-RPC messages read from r on w
+// until r ends or ctx is cancelled. Requests are answered one at a time.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	in := bufio.NewScanner(r)
+	in.Buffer(make([]byte, 64*1024), 2*1024*1024)
+	out := bufio.NewWriter(w)
+	for in.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var req rpcReq
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			continue
+		}
+		resp := s.dispatch(ctx, req, "")
+		if resp == nil {
+			continue
+		}
+		b, _ := json.Marshal(resp)
+		if _, err := out.Write(append(b, '\n')); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	return in.Err()
+}
+
+// childEnv names the registered server a re-executed test binary serves.
+const childEnv = "TESTMCP_SERVER"
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]func() *Server)
+)
+
+// Register makes a server available to StdioServer under name. Register the
+// same servers in the test binary's TestMain before calling RunIfChild.
+func Register(name string, build func() *Server) {
+	registryMu.Lock()
+	registry[name] = build
+	registryMu.Unlock()
+}
+
+// RunIfChild serves the registered server the process was started as by
+// StdioServer over stdin/stdout and exits; in any other process it returns
+// at once. Call it from TestMain after the Register calls.
+func RunIfChild() {
+	name := os.Getenv(childEnv)
+	if name == "" {
+		return
+	}
+	registryMu.Lock()
+	build := registry[name]
+	registryMu.Unlock()
+	if build == nil {
+		fmt.Fprintf(os.Stderr, "testmcp: no server registered as %q\n", name)
+		os.Exit(2)
+	}
+	if err := build().ServeStdio(context.Background(), os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "testmcp: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// StdioServer returns an enabled stdio server entry that runs the current
+// executable as the server registered under name. The child is a separate
+// process: requests it receives are not visible to this one.
+func StdioServer(name string) (*config.MCPServer, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return &config.MCPServer{
+		Command: exe,
+		Env:     map[string]string{childEnv: name},
+		Enabled: true,
+	}, nil
+}
@@ -0,0 +1,391 @@
+// Package testmcp is a scriptable fake MCP server for end-to-end tests of
+// the manager and the proxy. A Server answers the MCP handshake and the
+// tools, prompts and resources methods from what was added to it; any method
+// can be overridden to inject errors or odd replies, and every request it
+// receives is recorded. It serves over stdio (ServeStdio, or a child process
+// of the test binary with StdioServer) and streamable HTTP (ServeHTTP,
+// StartHTTP), and Start runs a whole manager instance in-process against it.
+package testmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the MCP protocol version a Server reports by default.
+const ProtocolVersion = "2024-11-05"
+
+// Error is a JSON-RPC error returned by a method handler.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// MethodHandler answers one request; the result is marshalled as the
+// response's result. A *Error is sent as the JSON-RPC error, any other
+// error as an internal error (-32603).
+type MethodHandler func(ctx context.Context, params json.RawMessage) (any, error)
+
+// ToolHandler runs a tools/call of one tool.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (*ToolResult, error)
+
+// Tool is a tool the Server lists and runs.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+	Handler     ToolHandler     `json:"-"`
+}
+
+// Content is one content block of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of a tools/call.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text is a tool result with a single text block.
+func Text(s string) *ToolResult {
+	return &ToolResult{Content: []Content{{Type: "text", Text: s}}}
+}
+
+// Prompt is a prompt the Server lists; prompts/get returns Text as a user
+// message.
+type Prompt struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Text        string `json:"-"`
+}
+
+// Resource is a resource the Server lists; resources/read returns Text.
+type Resource struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"-"`
+}
+
+// Request is a request or notification the Server received.
+type Request struct {
+	Time   time.Time       `json:"time"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	// Session is the MCP session of an HTTP request, empty over stdio
+	Session string `json:"session,omitempty"`
+}
+
+// Server is a fake MCP server. Its methods may be called while it serves.
+type Server struct {
+	mu        sync.Mutex
+	name      string
+	protocol  string
+	tools     []Tool
+	prompts   []Prompt
+	resources []Resource
+	methods   map[string]MethodHandler
+	delay     time.Duration
+	requests  []Request
+
+	// sessions are the open streamable HTTP sessions
+	sessions map[string]bool
+	nextID   int
+}
+
+// New returns a Server reporting name as its serverInfo name, with nothing
+// to list yet.
+func New(name string) *Server {
+	return &Server{
+		name:     name,
+		protocol: ProtocolVersion,
+		methods:  make(map[string]MethodHandler),
+		sessions: make(map[string]bool),
+	}
+}
+
+// AddTool adds a tool; an empty InputSchema becomes an empty object schema.
+func (s *Server) AddTool(t Tool) *Server {
+	if len(t.InputSchema) == 0 {
+		t.InputSchema = json.RawMessage(`{"type":"object"}`)
+	}
+	s.mu.Lock()
+	s.tools = append(s.tools, t)
+	s.mu.Unlock()
+	return s
+}
+
+// AddTextTool adds a tool that always returns text.
+func (s *Server) AddTextTool(name, text string) *Server {
+	return s.AddTool(Tool{Name: name, Handler: func(context.Context, json.RawMessage) (*ToolResult, error) {
+		return Text(text), nil
+	}})
+}
+
+// AddEchoTool adds a tool that returns its arguments as text.
+func (s *Server) AddEchoTool(name string) *Server {
+	return s.AddTool(Tool{Name: name, Handler: func(_ context.Context, args json.RawMessage) (*ToolResult, error) {
+		return Text(string(args)), nil
+	}})
+}
+
+// AddPrompt adds a prompt.
+func (s *Server) AddPrompt(p Prompt) *Server {
+	s.mu.Lock()
+	s.prompts = append(s.prompts, p)
+	s.mu.Unlock()
+	return s
+}
+
+// AddResource adds a resource.
+func (s *Server) AddResource(r Resource) *Server {
+	s.mu.Lock()
+	s.resources = append(s.resources, r)
+	s.mu.Unlock()
+	return s
+}
+
+// Handle overrides a method, built in or not; a nil handler restores the
+// built-in behaviour.
+func (s *Server) Handle(method string, h MethodHandler) *Server {
+	s.mu.Lock()
+	if h == nil {
+		delete(s.methods, method)
+	} else {
+		s.methods[method] = h
+	}
+	s.mu.Unlock()
+	return s
+}
+
+// Fail makes a method answer with a JSON-RPC error.
+func (s *Server) Fail(method string, code int, message string) *Server {
+	return s.Handle(method, func(context.Context, json.RawMessage) (any, error) {
+		return nil, &Error{Code: code, Message: message}
+	})
+}
+
+// SetDelay delays every response, e.g. to trip check or call timeouts.
+func (s *Server) SetDelay(d time.Duration) *Server {
+	s.mu.Lock()
+	s.delay = d
+	s.mu.Unlock()
+	return s
+}
+
+// SetProtocolVersion changes the protocol version initialize reports.
+func (s *Server) SetProtocolVersion(v string) *Server {
+	s.mu.Lock()
+	s.protocol = v
+	s.mu.Unlock()
+	return s
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Count returns how many requests of a method were received.
+func (s *Server) Count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, req := range s.requests {
+		if req.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+type rpcReq struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResp struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// dispatch records and answers one message; notifications return nil.
+func (s *Server) dispatch(ctx context.Context, req rpcReq, session string) *rpcResp {
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Time: time.Now(), Method: req.Method, Params: req.Params, Session: session})
+	h := s.methods[req.Method]
+	delay := s.delay
+	s.mu.Unlock()
+
+	if len(req.ID) == 0 {
+		if h != nil {
+			h(ctx, req.Params)
+		}
+		return nil
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	if h == nil {
+		h = s.builtin(req.Method)
+	}
+	resp := &rpcResp{JSONRPC: "2.0", ID: req.ID}
+	if h == nil {
+		resp.Error = &Error{Code: -32601, Message: "method not found"}
+		return resp
+	}
+	result, err := h(ctx, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: -32603, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: -32603, Message: err.Error()}
+		return resp
+	}
+	resp.Result = raw
+	return resp
+}
+
+func (s *Server) builtin(method string) MethodHandler {
+	switch method {
+	case "initialize":
+		return s.initialize
+	case "ping":
+		return func(context.Context, json.RawMessage) (any, error) { return struct{}{}, nil }
+	case "tools/list":
+		return func(context.Context, json.RawMessage) (any, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return map[string]any{"tools": append(make([]Tool, 0), s.tools...)}, nil
+		}
+	case "tools/call":
+		return s.callTool
+	case "prompts/list":
+		return func(context.Context, json.RawMessage) (any, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return map[string]any{"prompts": append(make([]Prompt, 0), s.prompts...)}, nil
+		}
+	case "prompts/get":
+		return s.getPrompt
+	case "resources/list":
+		return func(context.Context, json.RawMessage) (any, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return map[string]any{"resources": append(make([]Resource, 0), s.resources...)}, nil
+		}
+	case "resources/read":
+		return s.readResource
+	}
+	return nil
+}
+
+func (s *Server) initialize(context.Context, json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	caps := map[string]any{}
+	if len(s.tools) > 0 {
+		caps["tools"] = map[string]any{}
+	}
+	if len(s.prompts) > 0 {
+		caps["prompts"] = map[string]any{}
+	}
+	if len(s.resources) > 0 {
+		caps["resources"] = map[string]any{}
+	}
+	return map[string]any{
+		"protocolVersion": s.protocol,
+		"capabilities":    caps,
+		"serverInfo":      map[string]any{"name": s.name, "version": "1.0.0"},
+	}, nil
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+		return nil, &Error{Code: -32602, Message: "invalid tools/call params"}
+	}
+	s.mu.Lock()
+	var tool *Tool
+	for i := range s.tools {
+		if s.tools[i].Name == p.Name {
+			tool = &s.tools[i]
+			break
+		}
+	}
+	s.mu.Unlock()
+	if tool == nil {
+		return nil, &Error{Code: -32602, Message: "unknown tool: " + p.Name}
+	}
+	if tool.Handler == nil {
+		return Text(""), nil
+	}
+	return tool.Handler(ctx, p.Arguments)
+}
+
+func (s *Server) getPrompt(_ context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(params, &p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, prompt := range s.prompts {
+		if prompt.Name == p.Name {
+			return map[string]any{
+				"description": prompt.Description,
+				"messages": []map[string]any{{
+					"role":    "user",
+					"content": Content{Type: "text", Text: prompt.Text},
+				}},
+			}, nil
+		}
+	}
+	return nil, &Error{Code: -32602, Message: "unknown prompt: " + p.Name}
+}
+
+func (s *Server) readResource(_ context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	json.Unmarshal(params, &p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, res := range s.resources {
+		if res.URI == p.URI {
+			return map[string]any{
+				"contents": []map[string]any{{"uri": res.URI, "mimeType": res.MimeType, "text": res.Text}},
+			}, nil
+		}
+	}
+	return nil, &Error{Code: -32002, Message: "resource not found: " + p.URI}
+}
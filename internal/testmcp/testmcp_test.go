@@ -0,0 +1,188 @@
+package testmcp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+	"github.com/naukograd-software/mcp-catalog/internal/manager"
+	"github.com/naukograd-software/mcp-catalog/internal/testmcp"
+)
+
+func TestMain(m *testing.M) {
+	testmcp.Register("echo", func() *testmcp.Server {
+		return testmcp.New("echo").AddEchoTool("echo")
+	})
+	testmcp.RunIfChild()
+	os.Exit(m.Run())
+}
+
+func start(t *testing.T, servers map[string]*config.MCPServer) *testmcp.Instance {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	inst, err := testmcp.Start(dir, servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(inst.Close)
+	return inst
+}
+
+// rpc sends one JSON-RPC request to the proxy and returns its result and
+// the session the proxy answered in.
+func rpc(t *testing.T, url, session, method string, params any) (json.RawMessage, string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	req, _ := http.NewRequest("POST", url+"/mcp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if session != "" {
+		req.Header.Set("Mcp-Session-Id", session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	// tools/call may stream: the response is the last event's data
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		for _, line := range strings.Split(string(data), "\n") {
+			if d, ok := strings.CutPrefix(line, "data: "); ok {
+				data = []byte(d)
+			}
+		}
+	}
+	var out struct {
+		Result json.RawMessage `json:"result"`
+		Error  *testmcp.Error  `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("%s: decode response (HTTP %d): %v: %s", method, resp.StatusCode, err, data)
+	}
+	if out.Error != nil {
+		t.Fatalf("%s: %v", method, out.Error)
+	}
+	if s := resp.Header.Get("Mcp-Session-Id"); s != "" {
+		session = s
+	}
+	return out.Result, session
+}
+
+// proxyRoundTrip initializes a proxy session, lists tools and calls tool.
+func proxyRoundTrip(t *testing.T, inst *testmcp.Instance, tool string, args map[string]any) string {
+	t.Helper()
+	_, session := rpc(t, inst.URL, "", "initialize", map[string]any{
+		"protocolVersion": testmcp.ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "test", "version": "1"},
+	})
+	if session == "" {
+		t.Fatal("initialize returned no session")
+	}
+
+	raw, _ := rpc(t, inst.URL, session, "tools/list", map[string]any{})
+	var list struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	json.Unmarshal(raw, &list)
+	found := false
+	for _, tl := range list.Tools {
+		found = found || tl.Name == tool
+	}
+	if !found {
+		t.Fatalf("tools/list = %s, want %s", raw, tool)
+	}
+
+	raw, _ = rpc(t, inst.URL, session, "tools/call", map[string]any{"name": tool, "arguments": args})
+	var res testmcp.ToolResult
+	json.Unmarshal(raw, &res)
+	if res.IsError || len(res.Content) == 0 {
+		t.Fatalf("tools/call = %s", raw)
+	}
+	return res.Content[0].Text
+}
+
+func checkHealthy(t *testing.T, inst *testmcp.Instance, name string, tools int) {
+	t.Helper()
+	if err := inst.Manager.Check(name); err != nil {
+		t.Fatalf("check %s: %v", name, err)
+	}
+	info, ok := inst.Manager.GetInfo(name)
+	if !ok {
+		t.Fatalf("no info for %s", name)
+	}
+	if info.Status != manager.StatusHealthy || len(info.Tools) != tools {
+		t.Fatalf("%s: status %s, %d tools (%s)", name, info.Status, len(info.Tools), info.Error)
+	}
+}
+
+func TestStdioServer(t *testing.T) {
+	srv, err := testmcp.StdioServer("echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := start(t, map[string]*config.MCPServer{"local": srv})
+
+	checkHealthy(t, inst, "local", 1)
+	if got := proxyRoundTrip(t, inst, "local__echo", map[string]any{"msg": "hi"}); !strings.Contains(got, `"msg":"hi"`) {
+		t.Errorf("echo returned %q", got)
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	fake := testmcp.New("remote").AddTextTool("hello", "hello there")
+	ts := fake.StartHTTP()
+	defer ts.Close()
+	inst := start(t, map[string]*config.MCPServer{"remote": testmcp.HTTPServer(ts.URL)})
+
+	checkHealthy(t, inst, "remote", 1)
+	if got := proxyRoundTrip(t, inst, "remote__hello", nil); got != "hello there" {
+		t.Errorf("hello returned %q", got)
+	}
+	if n := fake.Count("tools/call"); n != 1 {
+		t.Errorf("upstream saw %d tools/call, want 1", n)
+	}
+	if n := fake.Count("initialize"); n == 0 {
+		t.Error("upstream was never initialized")
+	}
+}
+
+func TestCheckFailure(t *testing.T) {
+	fake := testmcp.New("broken").AddTextTool("hello", "hi").Fail("initialize", -32000, "backend down")
+	ts := fake.StartHTTP()
+	defer ts.Close()
+	inst := start(t, map[string]*config.MCPServer{"broken": testmcp.HTTPServer(ts.URL)})
+
+	inst.Manager.Check("broken")
+	info, _ := inst.Manager.GetInfo("broken")
+	if info == nil || info.Status != manager.StatusError || !strings.Contains(info.Error, "backend down") {
+		t.Fatalf("got %+v, want an error mentioning the upstream failure", info)
+	}
+}
+
+func TestToolHandlerError(t *testing.T) {
+	fake := testmcp.New("remote").AddTool(testmcp.Tool{Name: "fail", Handler: func(context.Context, json.RawMessage) (*testmcp.ToolResult, error) {
+		return &testmcp.ToolResult{Content: []testmcp.Content{{Type: "text", Text: "nope"}}, IsError: true}, nil
+	}})
+	ts := fake.StartHTTP()
+	defer ts.Close()
+	inst := start(t, map[string]*config.MCPServer{"remote": testmcp.HTTPServer(ts.URL)})
+
+	_, session := rpc(t, inst.URL, "", "initialize", map[string]any{"protocolVersion": testmcp.ProtocolVersion})
+	raw, _ := rpc(t, inst.URL, session, "tools/call", map[string]any{"name": "remote__fail"})
+	var res testmcp.ToolResult
+	json.Unmarshal(raw, &res)
+	if !res.IsError {
+		t.Errorf("tools/call = %s, want isError passed through", raw)
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// DiagnosticStep is the outcome of one connectivity layer
+type DiagnosticStep struct {
+	Layer      string `json:"layer"`
+	OK         bool   `json:"ok"`
+	Detail     string `json:"detail"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Diagnosis is the result of layered connectivity checks against a server URL.
+// FailedLayer is empty when every layer passed.
+type Diagnosis struct {
+	Steps       []DiagnosticStep `json:"steps"`
+	FailedLayer string           `json:"failedLayer,omitempty"`
+	Err         error            `json:"-"`
+}
+
+func (d *Diagnosis) step(layer string, start time.Time, err error, detail string) bool {
+	st := DiagnosticStep{Layer: layer, OK: err == nil, Detail: detail, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		st.Detail = err.Error()
+		d.FailedLayer = layer
+		d.Err = err
+	}
+	d.Steps = append(d.Steps, st)
+	return err == nil
+}
+
+// Diagnose walks egress policy, DNS, TCP, TLS and HTTP for a remote server
+// and stops at the first layer that fails.
+func Diagnose(ctx context.Context, srv *config.MCPServer, egress config.EgressSettings) Diagnosis {
+	var d Diagnosis
+
+	start := time.Now()
+	u, err := url.Parse(strings.TrimSpace(srv.URL))
+	if err == nil && u.Hostname() == "" {
+		err = fmt.Errorf("url %q has no host", srv.URL)
+	}
+	if !d.step("url", start, err, "parsed") {
+		return d
+	}
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	start = time.Now()
+	if !d.step("egress", start, CheckEgress(egress, host), "allowed") {
+		return d
+	}
+
+	// Behind a proxy the network layers are checked against the proxy itself;
+	// the target is only reachable through it, so TLS is left to the HTTP step.
+	viaProxy := srv.Proxy != ""
+	if viaProxy {
+		start = time.Now()
+		proxyURL, err := ParseProxy(srv.Proxy)
+		if !d.step("proxy", start, err, srv.Proxy) {
+			return d
+		}
+		host = proxyURL.Hostname()
+		port = proxyURL.Port()
+		if port == "" {
+			port = "1080"
+		}
+	}
+
+	dialer := NewDialer(srv.Dialer)
+	start = time.Now()
+	addrs, err := dialer.LookupHost(ctx, host)
+	if !d.step("dns", start, err, strings.Join(addrs, ", ")) {
+		return d
+	}
+
+	start = time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	detail := ""
+	if err == nil {
+		detail = conn.RemoteAddr().String()
+		conn.Close()
+	}
+	if !d.step("tcp", start, err, detail) {
+		return d
+	}
+
+	if u.Scheme == "https" && !viaProxy {
+		start = time.Now()
+		detail := ""
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err == nil {
+			tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+			err = tlsConn.HandshakeContext(ctx)
+			if err == nil {
+				detail = tls.VersionName(tlsConn.ConnectionState().Version)
+			}
+			tlsConn.Close()
+		}
+		if !d.step("tls", start, err, detail) {
+			return d
+		}
+	}
+
+	start = time.Now()
+	client, err := NewHTTPClient(srv, egress, 10*time.Second)
+	var req *http.Request
+	if err == nil {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	}
+	if err == nil {
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			detail = resp.Status
+			if resp.StatusCode >= 500 {
+				err = fmt.Errorf("http status %d", resp.StatusCode)
+			}
+		}
+	}
+	d.step("http", start, err, detail)
+	return d
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+const defaultConnectTimeout = 30 * time.Second
+
+// Dialer opens connections according to a server's DialerOptions
+type Dialer struct {
+	opts      config.DialerOptions
+	keepAlive time.Duration
+}
+
+// NewDialer returns a dialer for the server's options (nil means defaults).
+func NewDialer(opts *config.DialerOptions) *Dialer {
+	d := &Dialer{keepAlive: 30 * time.Second}
+	if opts != nil {
+		d.opts = *opts
+	}
+	return d
+}
+
+// Network maps a generic network ("tcp", "ip") to the forced IP family.
+func (d *Dialer) Network(network string) string {
+	switch strings.TrimSpace(d.opts.IPVersion) {
+	case "4":
+		return network + "4"
+	case "6":
+		return network + "6"
+	}
+	return network
+}
+
+func (d *Dialer) netDialer(network string) (*net.Dialer, error) {
+	nd := &net.Dialer{Timeout: defaultConnectTimeout, KeepAlive: d.keepAlive}
+	if d.opts.ConnectTimeoutMs > 0 {
+		nd.Timeout = time.Duration(d.opts.ConnectTimeoutMs) * time.Millisecond
+	}
+	bind := strings.TrimSpace(d.opts.BindInterface)
+	if bind == "" {
+		return nd, nil
+	}
+	ip, err := d.bindIP(bind, network)
+	if err != nil {
+		return nil, err
+	}
+	nd.LocalAddr = &net.TCPAddr{IP: ip}
+	return nd, nil
+}
+
+// bindIP resolves the bind option to a local source address.
+func (d *Dialer) bindIP(bind, network string) (net.IP, error) {
+	if ip := net.ParseIP(bind); ip != nil {
+		return ip, nil
+	}
+	iface, err := net.InterfaceByName(bind)
+	if err != nil {
+		return nil, fmt.Errorf("bind interface %q: %w", bind, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("bind interface %q: %w", bind, err)
+	}
+	want6 := strings.HasSuffix(network, "6")
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		is4 := ipnet.IP.To4() != nil
+		if (want6 && is4) || (strings.HasSuffix(network, "4") && !is4) {
+			continue
+		}
+		return ipnet.IP, nil
+	}
+	return nil, fmt.Errorf("bind interface %q has no usable address for %s", bind, network)
+}
+
+// DialContext connects to addr honoring IP family, bind address and timeout.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	network = d.Network(network)
+	nd, err := d.netDialer(network)
+	if err != nil {
+		return nil, err
+	}
+	return nd.DialContext(ctx, network, addr)
+}
+
+// LookupHost resolves host restricted to the forced IP family.
+func (d *Dialer) LookupHost(ctx context.Context, host string) ([]string, error) {
+	ips, err := net.DefaultResolver.LookupIP(ctx, d.Network("ip"), host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = ip.String()
+	}
+	return addrs, nil
+}
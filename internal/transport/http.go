@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// NewHTTPClient builds the client used to talk to a remote MCP server,
+// enforcing the egress policy on every request (including redirects).
+func NewHTTPClient(srv *config.MCPServer, egress config.EgressSettings, timeout time.Duration) (*http.Client, error) {
+	base, err := transportFor(srv)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &egressTransport{next: base, policy: egress},
+	}, nil
+}
+
+// ParseProxy validates an upstream proxy URL.
+func ParseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use socks5, http or https)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[string]*http.Transport)
+)
+
+// transportFor returns a shared transport for the server's network options so
+// idle connections are pooled across calls with identical settings.
+func transportFor(srv *config.MCPServer) (*http.Transport, error) {
+	key, _ := json.Marshal([]any{srv.Dialer, srv.Proxy, srv.HTTP})
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if t, ok := transports[string(key)]; ok {
+		return t, nil
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := NewDialer(srv.Dialer)
+	if h := srv.HTTP; h != nil {
+		if h.KeepAliveSec != 0 {
+			dialer.keepAlive = time.Duration(h.KeepAliveSec) * time.Second
+		}
+		if h.MaxIdleConns > 0 {
+			base.MaxIdleConns = h.MaxIdleConns
+			base.MaxIdleConnsPerHost = h.MaxIdleConns
+		}
+		if h.IdleConnTimeoutSec > 0 {
+			base.IdleConnTimeout = time.Duration(h.IdleConnTimeoutSec) * time.Second
+		}
+		base.DisableKeepAlives = h.DisableKeepAlives
+		if h.HTTP2 != nil && !*h.HTTP2 {
+			// A non-nil empty TLSNextProto disables the bundled HTTP/2 support
+			base.ForceAttemptHTTP2 = false
+			base.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		}
+	}
+	base.DialContext = dialer.DialContext
+	if srv.Proxy != "" {
+		proxyURL, err := ParseProxy(srv.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+	transports[string(key)] = base
+	return base, nil
+}
+
+type egressTransport struct {
+	next   http.RoundTripper
+	policy config.EgressSettings
+}
+
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := CheckEgress(t.policy, req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// CheckEgress reports an error if the policy does not permit contacting host.
+func CheckEgress(policy config.EgressSettings, host string) error {
+	if len(policy.AllowedHosts) == 0 && !policy.BlockByDefault {
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range policy.AllowedHosts {
+		if hostMatches(strings.ToLower(strings.TrimSpace(pattern)), host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("egress to %q blocked by policy", host)
+}
+
+func hostMatches(pattern, host string) bool {
+	if pattern == "" {
+		return false
+	}
+	if pattern == "*" || pattern == host {
+		return true
+	}
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+	return false
+}
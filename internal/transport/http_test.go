@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+func TestCheckEgress(t *testing.T) {
+	allow := func(hosts ...string) config.EgressSettings {
+		return config.EgressSettings{AllowedHosts: hosts}
+	}
+	tests := []struct {
+		name   string
+		policy config.EgressSettings
+		host   string
+		ok     bool
+	}{
+		{"no policy", config.EgressSettings{}, "anything.example", true},
+		{"block by default, empty list", config.EgressSettings{BlockByDefault: true}, "mcp.example.com", false},
+		{"star", allow("*"), "mcp.example.com", true},
+
+		{"exact", allow("mcp.example.com"), "mcp.example.com", true},
+		{"exact, case and trailing dot", allow("MCP.Example.com"), "mcp.example.COM.", true},
+		{"exact, other host", allow("mcp.example.com"), "api.example.com", false},
+		{"exact, subdomain", allow("example.com"), "mcp.example.com", false},
+		{"exact, lookalike suffix", allow("example.com"), "evilexample.com", false},
+		{"padded pattern", allow("  mcp.example.com "), "mcp.example.com", true},
+		{"empty pattern", allow(""), "", false},
+
+		{"wildcard, subdomain", allow("*.internal.corp"), "mcp.internal.corp", true},
+		{"wildcard, nested subdomain", allow("*.internal.corp"), "a.b.internal.corp", true},
+		{"wildcard, bare domain", allow("*.internal.corp"), "internal.corp", false},
+		{"wildcard, lookalike", allow("*.internal.corp"), "evilinternal.corp", false},
+		{"wildcard, suffix inside another domain", allow("*.internal.corp"), "x.internal.corp.evil.net", false},
+
+		{"cidr, inside", allow("10.0.0.0/8"), "10.20.30.40", true},
+		{"cidr, outside", allow("10.0.0.0/8"), "11.0.0.1", false},
+		{"cidr, hostname is not resolved", allow("10.0.0.0/8"), "ten.example.com", false},
+		{"cidr, ipv6", allow("fd00::/8"), "fd12::1", true},
+		{"cidr, ipv6 outside", allow("fd00::/8"), "fe80::1", false},
+		{"cidr, ipv4 host against ipv6 range", allow("::/0"), "10.0.0.1", false},
+		{"exact ip", allow("127.0.0.1"), "127.0.0.1", true},
+
+		{"second pattern matches", allow("a.example", "*.b.example"), "x.b.example", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckEgress(tt.policy, tt.host)
+			if (err == nil) != tt.ok {
+				t.Errorf("CheckEgress(%v, %q) = %v, want allowed=%v", tt.policy.AllowedHosts, tt.host, err, tt.ok)
+			}
+		})
+	}
+}
+
+var errPassed = errors.New("passed the egress check")
+
+type stopTransport struct{}
+
+func (stopTransport) RoundTrip(*http.Request) (*http.Response, error) { return nil, errPassed }
+
+// Requests are matched on the host alone, whatever port they use.
+func TestEgressTransportPorts(t *testing.T) {
+	tr := &egressTransport{next: stopTransport{}, policy: config.EgressSettings{
+		AllowedHosts: []string{"mcp.example.com", "10.0.0.0/8", "::1"},
+	}}
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://mcp.example.com/mcp", true},
+		{"https://mcp.example.com:8443/mcp", true},
+		{"http://mcp.example.com:80/mcp", true},
+		{"http://10.1.2.3:3000/mcp", true},
+		{"http://[::1]:8080/mcp", true},
+		{"http://api.example.com:8443/mcp", false},
+		{"http://mcp.example.com.evil.net:443/mcp", false},
+		{"http://11.1.2.3:3000/mcp", false},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tr.RoundTrip(req)
+		if got := errors.Is(err, errPassed); got != tt.ok {
+			t.Errorf("%s: allowed=%v, want %v (%v)", tt.url, got, tt.ok, err)
+		}
+	}
+}
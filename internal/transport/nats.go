@@ -0,0 +1,213 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/naukograd-software/mcp-catalog/internal/config"
+)
+
+// NATSConn is a minimal client of the NATS core protocol, enough for
+// request/reply over a private inbox. It is not safe for concurrent use.
+type NATSConn struct {
+	conn  net.Conn
+	r     *bufio.Reader
+	inbox string
+	stop  func() bool
+}
+
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// DialNATS connects to the server's nats:// (or tls://) URL, authenticates
+// with the URL's user info and subscribes to a fresh reply inbox. The
+// connection is closed when ctx ends.
+func DialNATS(ctx context.Context, srv *config.MCPServer, egress config.EgressSettings) (*NATSConn, error) {
+	u, err := url.Parse(strings.TrimSpace(srv.URL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS url %q: %w", srv.URL, err)
+	}
+	switch u.Scheme {
+	case "nats", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported NATS url scheme %q (use nats or tls)", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("NATS url %q has no host", srv.URL)
+	}
+	if err := CheckEgress(egress, host); err != nil {
+		return nil, err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "4222"
+	}
+
+	conn, err := NewDialer(srv.Dialer).DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	c := &NATSConn{conn: conn, r: bufio.NewReader(conn)}
+	c.stop = context.AfterFunc(ctx, func() { conn.Close() })
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := c.handshake(u); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *NATSConn) handshake(u *url.URL) error {
+	line, err := c.readLine()
+	if err != nil {
+		return fmt.Errorf("read NATS INFO: %w", err)
+	}
+	op, args, _ := strings.Cut(line, " ")
+	if !strings.EqualFold(op, "INFO") {
+		return fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+	var info natsInfo
+	_ = json.Unmarshal([]byte(args), &info)
+
+	secure := u.Scheme == "tls" || info.TLSRequired
+	if secure {
+		tc := tls.Client(c.conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.Handshake(); err != nil {
+			return fmt.Errorf("NATS TLS handshake: %w", err)
+		}
+		c.conn = tc
+		c.r = bufio.NewReader(tc)
+	}
+
+	opts := map[string]any{
+		"verbose":      false,
+		"pedantic":     false,
+		"tls_required": secure,
+		"name":         "mcp-catalog",
+		"lang":         "go",
+		"version":      "1.0.0",
+		"protocol":     1,
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts["user"] = u.User.Username()
+			opts["pass"] = pass
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(opts)
+	c.inbox = "_INBOX." + randomToken()
+	if _, err := fmt.Fprintf(c.conn, "CONNECT %s\r\nPING\r\nSUB %s 1\r\n", connect, c.inbox); err != nil {
+		return err
+	}
+	// The PONG confirms the server accepted CONNECT, including credentials
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return fmt.Errorf("NATS connect: %w", err)
+		}
+		switch op, _, _ := strings.Cut(line, " "); strings.ToUpper(op) {
+		case "PONG":
+			return nil
+		case "-ERR":
+			return fmt.Errorf("NATS connect: %s", strings.TrimSpace(strings.TrimPrefix(line, op)))
+		}
+	}
+}
+
+// Publish sends data to subject with no reply expected.
+func (c *NATSConn) Publish(subject string, data []byte) error {
+	_, err := fmt.Fprintf(c.conn, "PUB %s %d\r\n%s\r\n", subject, len(data), data)
+	return err
+}
+
+// Request sends data to subject with the connection's inbox as reply
+// subject and returns the first reply accept approves. Replies accept
+// rejects, such as notifications sent ahead of the response, are skipped.
+func (c *NATSConn) Request(subject string, data []byte, accept func(msg []byte) bool) ([]byte, error) {
+	if _, err := fmt.Fprintf(c.conn, "PUB %s %s %d\r\n%s\r\n", subject, c.inbox, len(data), data); err != nil {
+		return nil, err
+	}
+	for {
+		msg, err := c.next()
+		if err != nil {
+			return nil, err
+		}
+		if accept(msg) {
+			return msg, nil
+		}
+	}
+}
+
+// next returns the payload of the next message on the inbox, answering
+// server pings on the way.
+func (c *NATSConn) next() ([]byte, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		op, args, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(op) {
+		case "PING":
+			if _, err := io.WriteString(c.conn, "PONG\r\n"); err != nil {
+				return nil, err
+			}
+		case "-ERR":
+			return nil, fmt.Errorf("NATS error: %s", strings.TrimSpace(args))
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(args)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("malformed NATS message %q", line)
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return nil, fmt.Errorf("malformed NATS message %q", line)
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(c.r, payload); err != nil {
+				return nil, err
+			}
+			return payload[:size], nil
+		}
+	}
+}
+
+func (c *NATSConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Close ends the connection.
+func (c *NATSConn) Close() error {
+	c.stop()
+	return c.conn.Close()
+}
+
+func randomToken() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}